@@ -3,12 +3,14 @@ package ast
 import (
 	"Interpreter_in_Go/token"
 	"bytes"
+	"sort"
 	"strings"
 )
 
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position // position of the first character of the node
 }
 
 type Statement interface {
@@ -23,6 +25,7 @@ type Expression interface {
 
 type RootStatement struct {
 	Statements []Statement
+	Comments   []*Comment // all comments in the source, in order
 }
 
 func (pgr *RootStatement) TokenLiteral() string {
@@ -32,6 +35,13 @@ func (pgr *RootStatement) TokenLiteral() string {
 	return ""
 }
 
+func (pgr *RootStatement) Pos() token.Position {
+	if len(pgr.Statements) > 0 {
+		return pgr.Statements[0].Pos()
+	}
+	return token.Position{Line: 1, Column: 1}
+}
+
 func (pgr *RootStatement) String() string {
 	var out bytes.Buffer
 
@@ -42,20 +52,27 @@ func (pgr *RootStatement) String() string {
 }
 
 type LetStatement struct {
-	Token token.Token // the token.LET token
-	Name  *Identifier
-	Value Expression
+	Token   token.Token // the token.LET token
+	Name    *Identifier // nil when Pattern destructures the value
+	Pattern Pattern     // an array or hash pattern, as in 'let [a, b] = xs;'
+	Value   Expression
 }
 
 func (ls *LetStatement) statementNode() {}
 
 func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
 
+func (ls *LetStatement) Pos() token.Position { return ls.Token.Pos }
+
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
 	out.WriteString(ls.TokenLiteral() + " ")
-	out.WriteString(ls.Name.String())
+	if ls.Pattern != nil {
+		out.WriteString(ls.Pattern.String())
+	} else {
+		out.WriteString(ls.Name.String())
+	}
 	out.WriteString(" = ")
 
 	if ls.Value != nil {
@@ -74,6 +91,8 @@ func (rs *ReturnStatement) statementNode() {}
 
 func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
 
+func (rs *ReturnStatement) Pos() token.Position { return rs.Token.Pos }
+
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString(rs.TokenLiteral() + " ")
@@ -85,15 +104,294 @@ func (rs *ReturnStatement) String() string {
 	return out.String()
 }
 
+type ThrowStatement struct {
+	Token token.Token // the token.THROW token
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode() {}
+
+func (ts *ThrowStatement) TokenLiteral() string { return ts.Token.Literal }
+
+func (ts *ThrowStatement) Pos() token.Position { return ts.Token.Pos }
+
+func (ts *ThrowStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(ts.TokenLiteral() + " ")
+
+	if ts.Value != nil {
+		out.WriteString(ts.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+// DeferStatement schedules Call to be made when the enclosing function
+// returns.
+type DeferStatement struct {
+	Token token.Token // the token.DEFER token
+	Call  *CallExpression
+}
+
+func (ds *DeferStatement) statementNode() {}
+
+func (ds *DeferStatement) TokenLiteral() string { return ds.Token.Literal }
+
+func (ds *DeferStatement) Pos() token.Position { return ds.Token.Pos }
+
+func (ds *DeferStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(ds.TokenLiteral() + " ")
+
+	if ds.Call != nil {
+		out.WriteString(ds.Call.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+// ImportStatement binds the module at Path to Alias, as in 'import "lib.fl"
+// as lib', or binds its exports listed in Names, as in 'import { a, b } from
+// "lib.fl"'. Exactly one of Alias and Names is set.
+type ImportStatement struct {
+	Token token.Token // the token.IMPORT token
+	Path  *StringLiteral
+	Alias *Identifier
+	Names []*Identifier
+}
+
+func (is *ImportStatement) statementNode() {}
+
+func (is *ImportStatement) TokenLiteral() string { return is.Token.Literal }
+
+func (is *ImportStatement) Pos() token.Position { return is.Token.Pos }
+
+func (is *ImportStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(is.TokenLiteral() + " ")
+
+	if is.Alias != nil {
+		out.WriteString(`"` + is.Path.Value + `" as ` + is.Alias.Value)
+	} else {
+		names := []string{}
+		for _, name := range is.Names {
+			names = append(names, name.Value)
+		}
+		out.WriteString("{ " + strings.Join(names, ", ") + ` } from "` + is.Path.Value + `"`)
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+// ExportStatement makes the binding of Let available to the importers of
+// the module it is in.
+type ExportStatement struct {
+	Token token.Token // the token.EXPORT token
+	Let   *LetStatement
+}
+
+func (es *ExportStatement) statementNode() {}
+
+func (es *ExportStatement) TokenLiteral() string { return es.Token.Literal }
+
+func (es *ExportStatement) Pos() token.Position { return es.Token.Pos }
+
+func (es *ExportStatement) String() string {
+	return es.TokenLiteral() + " " + es.Let.String()
+}
+
+// StructStatement declares a struct type called Name with the fields in
+// Fields, as in 'struct Point { x, y }'.
+type StructStatement struct {
+	Token  token.Token // the token.STRUCT token
+	Name   *Identifier
+	Fields []string
+}
+
+func (ss *StructStatement) statementNode() {}
+
+func (ss *StructStatement) TokenLiteral() string { return ss.Token.Literal }
+
+func (ss *StructStatement) Pos() token.Position { return ss.Token.Pos }
+
+func (ss *StructStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("struct ")
+	out.WriteString(ss.Name.String())
+	if len(ss.Fields) == 0 {
+		out.WriteString(" {}")
+	} else {
+		out.WriteString(" { ")
+		out.WriteString(strings.Join(ss.Fields, ", "))
+		out.WriteString(" }")
+	}
+
+	return out.String()
+}
+
+// ClassStatement declares a class called Name, extending the class Super if
+// it is set, with the methods in Methods, as in
+// 'class Dog extends Animal { speak() { ... } }'.
+type ClassStatement struct {
+	Token   token.Token // the token.CLASS token
+	Name    *Identifier
+	Super   *Identifier
+	Methods []*Method
+	Close   token.Token // the closing '}'
+}
+
+func (cs *ClassStatement) statementNode() {}
+
+func (cs *ClassStatement) TokenLiteral() string { return cs.Token.Literal }
+
+func (cs *ClassStatement) Pos() token.Position { return cs.Token.Pos }
+
+func (cs *ClassStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("class ")
+	out.WriteString(cs.Name.String())
+	if cs.Super != nil {
+		out.WriteString(" extends ")
+		out.WriteString(cs.Super.String())
+	}
+	out.WriteString(" {")
+	for _, method := range cs.Methods {
+		out.WriteString(" ")
+		out.WriteString(method.String())
+	}
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// SelfParameters are the names of the parameters every method takes before
+// its own: the instance it was called on and its superclass's methods.
+var SelfParameters = []string{"self", "super"}
+
+// Method is a method of a class. Its Function takes the SelfParameters
+// first, followed by the parameters written in the source.
+type Method struct {
+	Token    token.Token // the token.IDENT token of its name
+	Name     string
+	Function *FunctionLiteral
+}
+
+func (md *Method) TokenLiteral() string { return md.Token.Literal }
+
+func (md *Method) Pos() token.Position { return md.Token.Pos }
+
+// Parameters returns the parameters written in the source, nil for those
+// with a pattern.
+func (md *Method) Parameters() []*Identifier {
+	return md.Function.Parameters[len(SelfParameters):]
+}
+
+func (md *Method) String() string {
+	var out bytes.Buffer
+	var params []string
+
+	for idx := len(SelfParameters); idx < len(md.Function.Parameters); idx++ {
+		params = append(params, md.Function.ParameterString(idx))
+	}
+	out.WriteString(md.Name)
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(")")
+	out.WriteString(md.Function.Body.String())
+
+	return out.String()
+}
+
+// EnumStatement declares an enum called Name with the variants in Variants,
+// as in 'enum Shape { Circle(r), Rect(w, h), Empty }'.
+type EnumStatement struct {
+	Token    token.Token // the token.ENUM token
+	Name     *Identifier
+	Variants []*Variant
+}
+
+func (es *EnumStatement) statementNode() {}
+
+func (es *EnumStatement) TokenLiteral() string { return es.Token.Literal }
+
+func (es *EnumStatement) Pos() token.Position { return es.Token.Pos }
+
+func (es *EnumStatement) String() string {
+	var out bytes.Buffer
+	var variants []string
+
+	for _, variant := range es.Variants {
+		variants = append(variants, variant.String())
+	}
+	out.WriteString("enum ")
+	out.WriteString(es.Name.String())
+	if len(variants) == 0 {
+		out.WriteString(" {}")
+	} else {
+		out.WriteString(" { ")
+		out.WriteString(strings.Join(variants, ", "))
+		out.WriteString(" }")
+	}
+
+	return out.String()
+}
+
+// Variant is a variant of an enum, with the names of its payload in Fields.
+type Variant struct {
+	Token  token.Token // the token.IDENT token of its name
+	Name   string
+	Fields []string
+}
+
+func (vr *Variant) TokenLiteral() string { return vr.Token.Literal }
+
+func (vr *Variant) Pos() token.Position { return vr.Token.Pos }
+
+func (vr *Variant) String() string {
+	if len(vr.Fields) == 0 {
+		return vr.Name
+	}
+	return vr.Name + "(" + strings.Join(vr.Fields, ", ") + ")"
+}
+
 type Identifier struct {
 	Token token.Token // the token.IDENT token
 	Value string
+
+	Binding Binding `json:"-"` // filled in by the resolver
+}
+
+// BindingKind tells how a resolved identifier is looked up at run time.
+type BindingKind int
+
+const (
+	Unresolved BindingKind = iota // looked up by name through the environment chain
+	Global                        // looked up by name in the outermost environment
+	Local                         // read from a slot of a function environment
+	BuiltIn                       // refers to a builtin function
+)
+
+// Binding is where the resolver found an identifier's declaration. For
+// locals, Depth is the number of function environments to walk out from the
+// current one and Slot the index into that environment's slots.
+type Binding struct {
+	Kind  BindingKind
+	Depth int
+	Slot  int
 }
 
 func (id *Identifier) expressionNode() {}
 
+// An identifier as a pattern matches any value and binds it, except for the
+// wildcard '_', which binds nothing.
+func (id *Identifier) patternNode() {}
+
 func (id *Identifier) TokenLiteral() string { return id.Token.Literal }
 
+func (id *Identifier) Pos() token.Position { return id.Token.Pos }
+
 func (id *Identifier) String() string { return id.Value }
 
 type ExpressionStatement struct {
@@ -105,6 +403,8 @@ func (es *ExpressionStatement) statementNode() {}
 
 func (es *ExpressionStatement) TokenLiteral() string { return es.Token.Literal }
 
+func (es *ExpressionStatement) Pos() token.Position { return es.Token.Pos }
+
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -112,15 +412,45 @@ func (es *ExpressionStatement) String() string {
 	return ""
 }
 
+// AssignStatement sets the member Target to Value, as in 'user.name =
+// "x";'.
+type AssignStatement struct {
+	Token  token.Token // the '=' token
+	Target *MemberExpression
+	Value  Expression
+}
+
+func (as *AssignStatement) statementNode() {}
+
+func (as *AssignStatement) TokenLiteral() string { return as.Token.Literal }
+
+func (as *AssignStatement) Pos() token.Position { return as.Target.Pos() }
+
+func (as *AssignStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(as.Target.String())
+	out.WriteString(" = ")
+
+	if as.Value != nil {
+		out.WriteString(as.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
 type BlockStatement struct {
 	Token      token.Token // the '{' token
 	Statements []Statement
+	Close      token.Token // the '}' token
 }
 
 func (bs *BlockStatement) statementNode() {}
 
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
 
+func (bs *BlockStatement) Pos() token.Position { return bs.Token.Pos }
+
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 
@@ -139,6 +469,8 @@ func (il *IntegerLiteral) expressionNode() {}
 
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 
+func (il *IntegerLiteral) Pos() token.Position { return il.Token.Pos }
+
 func (il *IntegerLiteral) String() string { return il.Token.Literal }
 
 type StringLiteral struct {
@@ -150,6 +482,8 @@ func (sl *StringLiteral) expressionNode() {}
 
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
 
+func (sl *StringLiteral) Pos() token.Position { return sl.Token.Pos }
+
 func (sl *StringLiteral) String() string { return sl.Token.Literal }
 
 type PrefixExpression struct {
@@ -162,6 +496,8 @@ func (pe *PrefixExpression) expressionNode() {}
 
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
 
+func (pe *PrefixExpression) Pos() token.Position { return pe.Token.Pos }
+
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -173,6 +509,30 @@ func (pe *PrefixExpression) String() string {
 	return out.String()
 }
 
+// PostfixExpression is an operator applied after its operand, eg. 'x?'.
+type PostfixExpression struct {
+	Token    token.Token // the operator token, eg. '?'
+	Left     Expression
+	Operator string
+}
+
+func (pe *PostfixExpression) expressionNode() {}
+
+func (pe *PostfixExpression) TokenLiteral() string { return pe.Token.Literal }
+
+func (pe *PostfixExpression) Pos() token.Position { return pe.Left.Pos() }
+
+func (pe *PostfixExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(pe.Left.String())
+	out.WriteString(pe.Operator)
+	out.WriteString(")")
+
+	return out.String()
+}
+
 type InfixExpression struct {
 	Token    token.Token // The operator token, eg. '+'
 	Left     Expression
@@ -184,6 +544,8 @@ func (ie *InfixExpression) expressionNode() {}
 
 func (ie *InfixExpression) TokenLiteral() string { return ie.Token.Literal }
 
+func (ie *InfixExpression) Pos() token.Position { return ie.Left.Pos() }
+
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -205,6 +567,8 @@ func (bl *Boolean) expressionNode() {}
 
 func (bl *Boolean) TokenLiteral() string { return bl.Token.Literal }
 
+func (bl *Boolean) Pos() token.Position { return bl.Token.Pos }
+
 func (bl *Boolean) String() string { return bl.Token.Literal }
 
 type IfExpression struct {
@@ -218,6 +582,8 @@ func (ie *IfExpression) expressionNode() {}
 
 func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
 
+func (ie *IfExpression) Pos() token.Position { return ie.Token.Pos }
+
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -233,22 +599,90 @@ func (ie *IfExpression) String() string {
 	return out.String()
 }
 
+// TryExpression evaluates Body and, when it raises an error, Catch with the
+// error bound to Param. Finally runs last in either case. At least one of
+// Catch and Finally is present; Param is optional. Param and the names
+// declared in Catch are only visible within Catch.
+type TryExpression struct {
+	Token   token.Token // the token.TRY token
+	Body    *BlockStatement
+	Param   *Identifier
+	Catch   *BlockStatement
+	Finally *BlockStatement
+
+	CatchLocals int `json:"-"` // slots for the names of the catch block, filled in by the resolver
+}
+
+func (te *TryExpression) expressionNode() {}
+
+func (te *TryExpression) TokenLiteral() string { return te.Token.Literal }
+
+func (te *TryExpression) Pos() token.Position { return te.Token.Pos }
+
+func (te *TryExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try ")
+	out.WriteString(te.Body.String())
+
+	if te.Catch != nil {
+		out.WriteString(" catch ")
+		if te.Param != nil {
+			out.WriteString("(" + te.Param.String() + ") ")
+		}
+		out.WriteString(te.Catch.String())
+	}
+	if te.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(te.Finally.String())
+	}
+	return out.String()
+}
+
 type FunctionLiteral struct {
 	Token      token.Token // the 'fn' token
 	Parameters []*Identifier
 	Body       *BlockStatement
+
+	// Patterns is nil unless a parameter is an array or hash pattern that
+	// destructures its argument, as in 'func([a, b]) { a + b }'. It then
+	// holds the pattern of each parameter, nil for plain names, and the
+	// parameter itself is nil.
+	Patterns []Pattern
+
+	Locals int `json:"-"` // slots needed by a call, filled in by the resolver
 }
 
 func (fl *FunctionLiteral) expressionNode() {}
 
 func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
 
+func (fl *FunctionLiteral) Pos() token.Position { return fl.Token.Pos }
+
+// Pattern returns the pattern destructuring the argument of parameter idx,
+// or nil when the parameter is a plain name.
+func (fl *FunctionLiteral) Pattern(idx int) Pattern {
+	if idx < len(fl.Patterns) {
+		return fl.Patterns[idx]
+	}
+	return nil
+}
+
+// ParameterString returns parameter idx as written in the source: its name
+// or the pattern destructuring its argument.
+func (fl *FunctionLiteral) ParameterString(idx int) string {
+	if pattern := fl.Pattern(idx); pattern != nil {
+		return pattern.String()
+	}
+	return fl.Parameters[idx].String()
+}
+
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 	var params []string
 
-	for _, prm := range fl.Parameters {
-		params = append(params, prm.String())
+	for idx := range fl.Parameters {
+		params = append(params, fl.ParameterString(idx))
 	}
 	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
@@ -263,12 +697,15 @@ type CallExpression struct {
 	Token     token.Token // the '(' token
 	Function  Expression  // Identifier on FunctionLiteral
 	Arguments []Expression
+	Close     token.Token // the ')' token
 }
 
 func (ce *CallExpression) expressionNode() {}
 
 func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
 
+func (ce *CallExpression) Pos() token.Position { return ce.Function.Pos() }
+
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 
@@ -287,12 +724,15 @@ func (ce *CallExpression) String() string {
 type ArrayLiteral struct {
 	Token    token.Token // the '[' token
 	Elements []Expression
+	Close    token.Token // the ']' token
 }
 
 func (al *ArrayLiteral) expressionNode() {}
 
 func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
 
+func (al *ArrayLiteral) Pos() token.Position { return al.Token.Pos }
+
 func (al *ArrayLiteral) String() string {
 	var out strings.Builder
 
@@ -318,6 +758,8 @@ func (ie *IndexExpression) expressionNode() {}
 
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
 
+func (ie *IndexExpression) Pos() token.Position { return ie.Left.Pos() }
+
 func (ie *IndexExpression) String() string {
 	var out strings.Builder
 
@@ -330,21 +772,63 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// MemberExpression accesses the attribute or method Property of Object,
+// eg. 'user.name'.
+type MemberExpression struct {
+	Token    token.Token // the '.' token
+	Object   Expression
+	Property string
+}
+
+func (me *MemberExpression) expressionNode() {}
+
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+
+func (me *MemberExpression) Pos() token.Position { return me.Object.Pos() }
+
+func (me *MemberExpression) String() string {
+	var out strings.Builder
+
+	out.WriteString("(")
+	out.WriteString(me.Object.String())
+	out.WriteString(".")
+	out.WriteString(me.Property)
+	out.WriteString(")")
+
+	return out.String()
+}
+
 type HashLiteral struct {
-	Token token.Token
+	Token token.Token // the '{' token
 	Pairs map[Expression]Expression
+	Close token.Token // the '}' token
+}
+
+// Keys returns the keys of the hash literal in source order, which the
+// Pairs map on its own does not preserve.
+func (hl *HashLiteral) Keys() []Expression {
+	keys := make([]Expression, 0, len(hl.Pairs))
+	for key := range hl.Pairs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Pos().Before(keys[j].Pos())
+	})
+	return keys
 }
 
 func (hl *HashLiteral) expressionNode() {}
 
 func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
 
+func (hl *HashLiteral) Pos() token.Position { return hl.Token.Pos }
+
 func (hl *HashLiteral) String() string {
 	var out strings.Builder
 
 	var pairs []string
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, key := range hl.Keys() {
+		pairs = append(pairs, key.String()+":"+hl.Pairs[key].String())
 	}
 	out.WriteString("{")
 	out.WriteString(strings.Join(pairs, ", "))
@@ -352,3 +836,196 @@ func (hl *HashLiteral) String() string {
 
 	return out.String()
 }
+
+// MatchExpression evaluates to the value of the first of its Arms whose
+// pattern matches Subject and whose guard, if any, holds.
+type MatchExpression struct {
+	Token   token.Token // the token.MATCH token
+	Subject Expression
+	Arms    []*MatchArm
+	Close   token.Token // the closing '}'
+}
+
+func (me *MatchExpression) expressionNode() {}
+
+func (me *MatchExpression) TokenLiteral() string { return me.Token.Literal }
+
+func (me *MatchExpression) Pos() token.Position { return me.Token.Pos }
+
+func (me *MatchExpression) String() string {
+	var out bytes.Buffer
+	var arms []string
+
+	for _, arm := range me.Arms {
+		arms = append(arms, arm.String())
+	}
+	out.WriteString("match ")
+	out.WriteString(me.Subject.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(arms, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// MatchArm is 'pattern if guard => value' within a match expression. The
+// value is either an expression, in Value, or a block, in Body; Guard is
+// optional. Each arm is a scope of its own, holding the names bound by its
+// pattern.
+type MatchArm struct {
+	Token   token.Token // the token.ARROW token
+	Pattern Pattern
+	Guard   Expression
+	Value   Expression
+	Body    *BlockStatement
+	Locals  int `json:"-"` // slots for the names of the arm, filled in by the resolver
+}
+
+func (ma *MatchArm) TokenLiteral() string { return ma.Token.Literal }
+
+func (ma *MatchArm) Pos() token.Position { return ma.Pattern.Pos() }
+
+func (ma *MatchArm) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ma.Pattern.String())
+	if ma.Guard != nil {
+		out.WriteString(" if ")
+		out.WriteString(ma.Guard.String())
+	}
+	out.WriteString(" => ")
+	if ma.Body != nil {
+		out.WriteString(ma.Body.String())
+	} else {
+		out.WriteString(ma.Value.String())
+	}
+
+	return out.String()
+}
+
+// Pattern is the shape a value is matched against, binding the identifiers
+// within it to the parts of the value when it matches.
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+// LiteralPattern matches values equal to its integer, string or boolean
+// literal, which may be a negated integer.
+type LiteralPattern struct {
+	Value Expression
+}
+
+func (lp *LiteralPattern) patternNode() {}
+
+func (lp *LiteralPattern) TokenLiteral() string { return lp.Value.TokenLiteral() }
+
+func (lp *LiteralPattern) Pos() token.Position { return lp.Value.Pos() }
+
+func (lp *LiteralPattern) String() string { return lp.Value.String() }
+
+// ArrayPattern matches arrays with an element matching each of Elements,
+// followed by any number of elements bound to Rest when it is set, as in
+// '[first, ...rest]', and by none otherwise.
+type ArrayPattern struct {
+	Token    token.Token // the '[' token
+	Elements []Pattern
+	Rest     *Identifier
+}
+
+func (ap *ArrayPattern) patternNode() {}
+
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+
+func (ap *ArrayPattern) Pos() token.Position { return ap.Token.Pos }
+
+func (ap *ArrayPattern) String() string {
+	var elements []string
+
+	for _, element := range ap.Elements {
+		elements = append(elements, element.String())
+	}
+	if ap.Rest != nil {
+		elements = append(elements, "..."+ap.Rest.String())
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// HashPattern matches hashes that have each of Keys with a value matching
+// the pattern of the same index in Values, as in '{"kind": k}'. Other keys
+// are ignored.
+type HashPattern struct {
+	Token  token.Token // the '{' token
+	Keys   []Expression
+	Values []Pattern
+}
+
+func (hp *HashPattern) patternNode() {}
+
+func (hp *HashPattern) TokenLiteral() string { return hp.Token.Literal }
+
+func (hp *HashPattern) Pos() token.Position { return hp.Token.Pos }
+
+func (hp *HashPattern) String() string {
+	var pairs []string
+
+	for idx, key := range hp.Keys {
+		pairs = append(pairs, key.String()+": "+hp.Values[idx].String())
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// VariantPattern matches the values of the variant Variant of the enum Enum
+// whose payload matches Fields, as in 'Shape.Circle(r)'.
+type VariantPattern struct {
+	Enum    *Identifier
+	Variant string
+	Fields  []Pattern
+}
+
+func (vp *VariantPattern) patternNode() {}
+
+func (vp *VariantPattern) TokenLiteral() string { return vp.Enum.TokenLiteral() }
+
+func (vp *VariantPattern) Pos() token.Position { return vp.Enum.Pos() }
+
+func (vp *VariantPattern) String() string {
+	var fields []string
+
+	for _, field := range vp.Fields {
+		fields = append(fields, field.String())
+	}
+	if len(fields) == 0 {
+		return vp.Enum.String() + "." + vp.Variant
+	}
+	return vp.Enum.String() + "." + vp.Variant + "(" + strings.Join(fields, ", ") + ")"
+}
+
+// DefaultPattern is an element of an array or hash pattern with a value to
+// match in place of the element when it is missing, as in '[a, b = 0]'.
+type DefaultPattern struct {
+	Token   token.Token // the '=' token
+	Pattern Pattern
+	Default Expression
+}
+
+func (dp *DefaultPattern) patternNode() {}
+
+func (dp *DefaultPattern) TokenLiteral() string { return dp.Token.Literal }
+
+func (dp *DefaultPattern) Pos() token.Position { return dp.Pattern.Pos() }
+
+func (dp *DefaultPattern) String() string { return dp.Pattern.String() + " = " + dp.Default.String() }
+
+// Comment is a '//' line comment. Comments are not part of the statement
+// tree; the parser collects them on RootStatement.Comments.
+type Comment struct {
+	Token token.Token // the token.COMMENT token
+	Text  string      // the comment text, including the leading '//'
+}
+
+func (cm *Comment) TokenLiteral() string { return cm.Token.Literal }
+
+func (cm *Comment) Pos() token.Position { return cm.Token.Pos }
+
+func (cm *Comment) String() string { return cm.Text }
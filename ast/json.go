@@ -0,0 +1,305 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"Interpreter_in_Go/token"
+)
+
+// nodeKinds maps the "kind" of a JSON encoded node to its Go type. Every node
+// type has to be listed here to be decodable.
+var nodeKinds = kindsOf(
+	&RootStatement{},
+	&LetStatement{},
+	&ReturnStatement{},
+	&ThrowStatement{},
+	&DeferStatement{},
+	&ImportStatement{},
+	&ExportStatement{},
+	&StructStatement{},
+	&ClassStatement{},
+	&Method{},
+	&EnumStatement{},
+	&Variant{},
+	&ExpressionStatement{},
+	&AssignStatement{},
+	&BlockStatement{},
+	&Identifier{},
+	&IntegerLiteral{},
+	&StringLiteral{},
+	&Boolean{},
+	&PrefixExpression{},
+	&PostfixExpression{},
+	&InfixExpression{},
+	&IfExpression{},
+	&TryExpression{},
+	&FunctionLiteral{},
+	&CallExpression{},
+	&ArrayLiteral{},
+	&IndexExpression{},
+	&MemberExpression{},
+	&HashLiteral{},
+	&MatchExpression{},
+	&MatchArm{},
+	&LiteralPattern{},
+	&ArrayPattern{},
+	&HashPattern{},
+	&VariantPattern{},
+	&DefaultPattern{},
+	&Comment{},
+)
+
+func kindsOf(nodes ...Node) map[string]reflect.Type {
+	kinds := make(map[string]reflect.Type, len(nodes))
+	for _, node := range nodes {
+		typ := reflect.TypeOf(node).Elem()
+		kinds[typ.Name()] = typ
+	}
+	return kinds
+}
+
+var (
+	nodeType  = reflect.TypeOf((*Node)(nil)).Elem()
+	tokenType = reflect.TypeOf(token.Token{})
+)
+
+// jsonToken is the encoded form of a token.Token.
+type jsonToken struct {
+	Type    token.TokenType `json:"type"`
+	Literal string          `json:"literal"`
+	Line    int             `json:"line"`
+	Column  int             `json:"column"`
+}
+
+// jsonPair is the encoded form of one HashLiteral entry.
+type jsonPair struct {
+	Key   json.RawMessage `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// EncodeJSON encodes a node and all of its children. Every node becomes an
+// object holding its "kind", its "pos" and one member per field of the node,
+// named after the field with a lower-case first letter. Hash literal pairs are
+// encoded as a list of {"key", "value"} objects in source order.
+func EncodeJSON(node Node) ([]byte, error) {
+	var out bytes.Buffer
+	if err := encodeValue(&out, reflect.ValueOf(&node).Elem()); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func encodeNode(out *bytes.Buffer, node Node) error {
+	value := reflect.ValueOf(node).Elem()
+	typ := value.Type()
+	pos := node.Pos()
+
+	fmt.Fprintf(out, `{"kind":%q,"pos":{"line":%d,"column":%d}`, typ.Name(), pos.Line, pos.Column)
+	for idx := 0; idx < typ.NumField(); idx++ {
+		field := typ.Field(idx)
+		if !field.IsExported() || field.Tag.Get("json") == "-" {
+			continue
+		}
+		fmt.Fprintf(out, ",%q:", fieldName(field))
+
+		var err error
+		if hash, ok := node.(*HashLiteral); ok && field.Name == "Pairs" {
+			err = encodePairs(out, hash)
+		} else {
+			err = encodeValue(out, value.Field(idx))
+		}
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", typ.Name(), field.Name, err)
+		}
+	}
+	out.WriteString("}")
+	return nil
+}
+
+func encodeValue(out *bytes.Buffer, value reflect.Value) error {
+	switch {
+	case value.Type() == tokenType:
+		tokn := value.Interface().(token.Token)
+		return writeJSON(out, jsonToken{tokn.Type, tokn.Literal, tokn.Pos.Line, tokn.Pos.Column})
+	case value.Type().Implements(nodeType):
+		if value.IsNil() {
+			out.WriteString("null")
+			return nil
+		}
+		return encodeNode(out, value.Interface().(Node))
+	case value.Kind() == reflect.Slice:
+		out.WriteString("[")
+		for idx := 0; idx < value.Len(); idx++ {
+			if idx > 0 {
+				out.WriteString(",")
+			}
+			if err := encodeValue(out, value.Index(idx)); err != nil {
+				return err
+			}
+		}
+		out.WriteString("]")
+		return nil
+	default:
+		return writeJSON(out, value.Interface())
+	}
+}
+
+func encodePairs(out *bytes.Buffer, hash *HashLiteral) error {
+	out.WriteString("[")
+	for idx, key := range hash.Keys() {
+		if idx > 0 {
+			out.WriteString(",")
+		}
+		out.WriteString(`{"key":`)
+		if err := encodeNode(out, key); err != nil {
+			return err
+		}
+		out.WriteString(`,"value":`)
+		value := hash.Pairs[key]
+		if err := encodeValue(out, reflect.ValueOf(&value).Elem()); err != nil {
+			return err
+		}
+		out.WriteString("}")
+	}
+	out.WriteString("]")
+	return nil
+}
+
+func writeJSON(out *bytes.Buffer, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	out.Write(data)
+	return nil
+}
+
+// DecodeJSON reconstructs a node tree from the output of EncodeJSON. The
+// "pos" members are ignored; positions are restored from the tokens.
+func DecodeJSON(data []byte) (Node, error) {
+	return decodeNode(data)
+}
+
+func decodeNode(data json.RawMessage) (Node, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	if members == nil {
+		return nil, nil
+	}
+	var kind string
+	if err := json.Unmarshal(members["kind"], &kind); err != nil {
+		return nil, fmt.Errorf("node without a valid kind: %s", truncate(data))
+	}
+	typ, ok := nodeKinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown node kind %q", kind)
+	}
+	node := reflect.New(typ)
+	value := node.Elem()
+
+	for idx := 0; idx < typ.NumField(); idx++ {
+		field := typ.Field(idx)
+		if !field.IsExported() || field.Tag.Get("json") == "-" {
+			continue
+		}
+		raw, ok := members[fieldName(field)]
+		if !ok {
+			continue
+		}
+		var err error
+		if kind == "HashLiteral" && field.Name == "Pairs" {
+			err = decodePairs(raw, value.Field(idx))
+		} else {
+			err = decodeValue(raw, value.Field(idx))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", kind, field.Name, err)
+		}
+	}
+	return node.Interface().(Node), nil
+}
+
+func decodeValue(raw json.RawMessage, value reflect.Value) error {
+	switch {
+	case value.Type() == tokenType:
+		var tokn jsonToken
+		if err := json.Unmarshal(raw, &tokn); err != nil {
+			return err
+		}
+		value.Set(reflect.ValueOf(token.Token{
+			Type:    tokn.Type,
+			Literal: tokn.Literal,
+			Pos:     token.Position{Line: tokn.Line, Column: tokn.Column},
+		}))
+		return nil
+	case value.Type().Implements(nodeType):
+		node, err := decodeNode(raw)
+		if err != nil || node == nil {
+			return err
+		}
+		decoded := reflect.ValueOf(node)
+		if !decoded.Type().AssignableTo(value.Type()) {
+			return fmt.Errorf("%s is not a valid %s", decoded.Elem().Type().Name(), value.Type())
+		}
+		value.Set(decoded)
+		return nil
+	case value.Kind() == reflect.Slice && value.Type().Elem().Implements(nodeType):
+		var elements []json.RawMessage
+		if err := json.Unmarshal(raw, &elements); err != nil {
+			return err
+		}
+		if elements == nil {
+			return nil
+		}
+		slice := reflect.MakeSlice(value.Type(), len(elements), len(elements))
+		for idx, element := range elements {
+			if err := decodeValue(element, slice.Index(idx)); err != nil {
+				return fmt.Errorf("[%d]: %w", idx, err)
+			}
+		}
+		value.Set(slice)
+		return nil
+	default:
+		return json.Unmarshal(raw, value.Addr().Interface())
+	}
+}
+
+func decodePairs(raw json.RawMessage, value reflect.Value) error {
+	var pairs []jsonPair
+	if err := json.Unmarshal(raw, &pairs); err != nil {
+		return err
+	}
+	decoded := make(map[Expression]Expression, len(pairs))
+	for idx, pair := range pairs {
+		var key, val Expression
+		if err := decodeValue(pair.Key, reflect.ValueOf(&key).Elem()); err != nil {
+			return fmt.Errorf("[%d].key: %w", idx, err)
+		}
+		if err := decodeValue(pair.Value, reflect.ValueOf(&val).Elem()); err != nil {
+			return fmt.Errorf("[%d].value: %w", idx, err)
+		}
+		if key == nil {
+			return fmt.Errorf("[%d].key: missing key", idx)
+		}
+		decoded[key] = val
+	}
+	value.Set(reflect.ValueOf(decoded))
+	return nil
+}
+
+func fieldName(field reflect.StructField) string {
+	return strings.ToLower(field.Name[:1]) + field.Name[1:]
+}
+
+func truncate(data []byte) string {
+	if len(data) > 40 {
+		return string(data[:40]) + "..."
+	}
+	return string(data)
+}
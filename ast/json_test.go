@@ -0,0 +1,90 @@
+package ast_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/lexer"
+	"Interpreter_in_Go/parser"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	input := `
+// every kind of node
+let add = func(x, y) { return x + y; };
+let list = [1, "two", true, -3, !false];
+let hash = {"one": 1, 2: "two", true: list[0]};
+if (add(1, 2) > 2) { hash["one"] } else { 0 };
+try { throw error("no"); } catch (e) { e } finally { 1 };
+parse_int("1")? + 1;
+func() { defer puts(1); };
+import "lib.fl" as lib;
+import { a, b } from "lib.fl";
+export let c = a;
+user.name = req.header("X").len;
+struct Point { x, y }
+class Dog extends Animal { init(name) { super.init(name); } speak() { self.name } }
+Dog("rex") instanceof Animal;
+let [first, second = 2, ...others] = [1];
+let swap = func([a, b], {"k": k}) { [b, a] };
+enum Shape { Circle(r), Empty }
+match (x) { Shape.Circle(r) if r > 0 => r, [a, ...b] => { a } {"k": -1} => 0, _ => "" };
+`
+	psr := parser.NewParser(lexer.NewLexer(input))
+	root := psr.ParseRootStatement()
+	if len(psr.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", psr.Errors())
+	}
+	encoded, err := ast.EncodeJSON(root)
+	if err != nil {
+		t.Fatalf("EncodeJSON returned error: %s", err)
+	}
+	decoded, err := ast.DecodeJSON(encoded)
+	if err != nil {
+		t.Fatalf("DecodeJSON returned error: %s", err)
+	}
+	decodedRoot, ok := decoded.(*ast.RootStatement)
+	if !ok {
+		t.Fatalf("decoded node is not *ast.RootStatement. got=%T", decoded)
+	}
+	if decodedRoot.String() != root.String() {
+		t.Errorf("decoded tree differs.\nexpected=%q\ngot=%q", root.String(), decodedRoot.String())
+	}
+	if len(decodedRoot.Comments) != 1 || decodedRoot.Comments[0].Text != "// every kind of node" {
+		t.Errorf("comments not decoded. got=%v", decodedRoot.Comments)
+	}
+	reencoded, err := ast.EncodeJSON(decodedRoot)
+	if err != nil {
+		t.Fatalf("EncodeJSON returned error: %s", err)
+	}
+	if !bytes.Equal(encoded, reencoded) {
+		t.Errorf("re-encoded tree differs.\nexpected=%s\ngot=%s", encoded, reencoded)
+	}
+}
+
+func TestDecodeJSONErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"kind": "Loop"}`, `unknown node kind "Loop"`},
+		{`{"statements": []}`, `node without a valid kind`},
+		{
+			`{"kind": "LetStatement", "name": {"kind": "IntegerLiteral"}}`,
+			`LetStatement.Name: IntegerLiteral is not a valid *ast.Identifier`,
+		},
+		{`[1, 2]`, `cannot unmarshal array`},
+	}
+	for _, tt := range tests {
+		_, err := ast.DecodeJSON([]byte(tt.input))
+		if err == nil {
+			t.Errorf("DecodeJSON(%s) returned no error", tt.input)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("wrong error. expected to contain %q, got=%q", tt.expected, err.Error())
+		}
+	}
+}
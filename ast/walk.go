@@ -0,0 +1,356 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// A Visitor's Visit method is invoked for each node encountered by Walk. If
+// the result visitor w is not nil, Walk visits each of the children of node
+// with the visitor w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses a syntax tree in depth-first order: it starts by calling
+// v.Visit(node); node must not be nil. Children are visited in source order,
+// hash literal pairs as key followed by value. Comments are not visited.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	switch node := node.(type) {
+	case *RootStatement:
+		walkStatements(v, node.Statements)
+	case *LetStatement:
+		walkIfPresent(v, node.Name)
+		walkIfPresent(v, node.Pattern)
+		walkIfPresent(v, node.Value)
+	case *ReturnStatement:
+		walkIfPresent(v, node.ReturnValue)
+	case *ThrowStatement:
+		walkIfPresent(v, node.Value)
+	case *DeferStatement:
+		walkIfPresent(v, node.Call)
+	case *ImportStatement:
+		walkIfPresent(v, node.Path)
+		walkIfPresent(v, node.Alias)
+		for _, name := range node.Names {
+			walkIfPresent(v, name)
+		}
+	case *ExportStatement:
+		walkIfPresent(v, node.Let)
+	case *StructStatement:
+		walkIfPresent(v, node.Name)
+	case *ClassStatement:
+		walkIfPresent(v, node.Name)
+		walkIfPresent(v, node.Super)
+		for _, method := range node.Methods {
+			walkIfPresent(v, method)
+		}
+	case *Method:
+		walkIfPresent(v, node.Function)
+	case *EnumStatement:
+		walkIfPresent(v, node.Name)
+		for _, variant := range node.Variants {
+			walkIfPresent(v, variant)
+		}
+	case *ExpressionStatement:
+		walkIfPresent(v, node.Expression)
+	case *AssignStatement:
+		walkIfPresent(v, node.Target)
+		walkIfPresent(v, node.Value)
+	case *BlockStatement:
+		walkStatements(v, node.Statements)
+	case *PrefixExpression:
+		walkIfPresent(v, node.Right)
+	case *PostfixExpression:
+		walkIfPresent(v, node.Left)
+	case *InfixExpression:
+		walkIfPresent(v, node.Left)
+		walkIfPresent(v, node.Right)
+	case *IfExpression:
+		walkIfPresent(v, node.Condition)
+		walkIfPresent(v, node.Consequence)
+		walkIfPresent(v, node.Alternative)
+	case *TryExpression:
+		walkIfPresent(v, node.Body)
+		walkIfPresent(v, node.Param)
+		walkIfPresent(v, node.Catch)
+		walkIfPresent(v, node.Finally)
+	case *FunctionLiteral:
+		for _, param := range node.Parameters {
+			walkIfPresent(v, param)
+		}
+		for _, pattern := range node.Patterns {
+			walkIfPresent(v, pattern)
+		}
+		walkIfPresent(v, node.Body)
+	case *CallExpression:
+		walkIfPresent(v, node.Function)
+		walkExpressions(v, node.Arguments)
+	case *ArrayLiteral:
+		walkExpressions(v, node.Elements)
+	case *IndexExpression:
+		walkIfPresent(v, node.Left)
+		walkIfPresent(v, node.Index)
+	case *MemberExpression:
+		walkIfPresent(v, node.Object)
+	case *HashLiteral:
+		for _, key := range node.Keys() {
+			walkIfPresent(v, key)
+			walkIfPresent(v, node.Pairs[key])
+		}
+	case *MatchExpression:
+		walkIfPresent(v, node.Subject)
+		for _, arm := range node.Arms {
+			walkIfPresent(v, arm)
+		}
+	case *MatchArm:
+		walkIfPresent(v, node.Pattern)
+		walkIfPresent(v, node.Guard)
+		walkIfPresent(v, node.Value)
+		walkIfPresent(v, node.Body)
+	case *LiteralPattern:
+		walkIfPresent(v, node.Value)
+	case *ArrayPattern:
+		for _, element := range node.Elements {
+			walkIfPresent(v, element)
+		}
+		walkIfPresent(v, node.Rest)
+	case *HashPattern:
+		for idx, key := range node.Keys {
+			walkIfPresent(v, key)
+			walkIfPresent(v, node.Values[idx])
+		}
+	case *VariantPattern:
+		walkIfPresent(v, node.Enum)
+		for _, field := range node.Fields {
+			walkIfPresent(v, field)
+		}
+	case *DefaultPattern:
+		walkIfPresent(v, node.Pattern)
+		walkIfPresent(v, node.Default)
+	case *Identifier, *IntegerLiteral, *StringLiteral, *Boolean, *Variant, *Comment:
+		// leaves
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", node))
+	}
+	v.Visit(nil)
+}
+
+func walkStatements(v Visitor, list []Statement) {
+	for _, stmt := range list {
+		walkIfPresent(v, stmt)
+	}
+}
+
+func walkExpressions(v Visitor, list []Expression) {
+	for _, expr := range list {
+		walkIfPresent(v, expr)
+	}
+}
+
+// walkIfPresent walks node unless it is nil, which is the case for optional
+// children and for parts the parser failed to produce.
+func walkIfPresent(v Visitor, node Node) {
+	if !isNilNode(node) {
+		Walk(v, node)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses a syntax tree in depth-first order: it starts by calling
+// f(node); node must not be nil. If f returns true, Inspect invokes f
+// recursively for each of the non-nil children of node, followed by a call
+// of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Rewrite traverses a syntax tree in depth-first order and replaces every
+// node with the result of calling fn on it. Children are rewritten before
+// their parent, so fn always sees a node whose subtrees are final.
+//
+// The tree is modified in place and the replacement for node is returned.
+// Returning the argument unchanged keeps a node; returning nil removes a
+// statement from its list and clears any other child. A replacement must fit
+// the place of the node it replaces, e.g. a Statement for a Statement or an
+// *Identifier for a parameter; Rewrite panics otherwise.
+func Rewrite(node Node, fn func(Node) Node) Node {
+	switch node := node.(type) {
+	case *RootStatement:
+		node.Statements = rewriteStatements(node.Statements, fn)
+	case *LetStatement:
+		node.Name = rewriteChild(node.Name, fn)
+		node.Pattern = rewriteChild(node.Pattern, fn)
+		node.Value = rewriteChild(node.Value, fn)
+	case *ReturnStatement:
+		node.ReturnValue = rewriteChild(node.ReturnValue, fn)
+	case *ThrowStatement:
+		node.Value = rewriteChild(node.Value, fn)
+	case *DeferStatement:
+		node.Call = rewriteChild(node.Call, fn)
+	case *ImportStatement:
+		node.Path = rewriteChild(node.Path, fn)
+		node.Alias = rewriteChild(node.Alias, fn)
+		for idx, name := range node.Names {
+			node.Names[idx] = rewriteChild(name, fn)
+		}
+	case *ExportStatement:
+		node.Let = rewriteChild(node.Let, fn)
+	case *StructStatement:
+		node.Name = rewriteChild(node.Name, fn)
+	case *ClassStatement:
+		node.Name = rewriteChild(node.Name, fn)
+		node.Super = rewriteChild(node.Super, fn)
+		for idx, method := range node.Methods {
+			node.Methods[idx] = rewriteChild(method, fn)
+		}
+	case *Method:
+		node.Function = rewriteChild(node.Function, fn)
+	case *EnumStatement:
+		node.Name = rewriteChild(node.Name, fn)
+		for idx, variant := range node.Variants {
+			node.Variants[idx] = rewriteChild(variant, fn)
+		}
+	case *ExpressionStatement:
+		node.Expression = rewriteChild(node.Expression, fn)
+	case *AssignStatement:
+		node.Target = rewriteChild(node.Target, fn)
+		node.Value = rewriteChild(node.Value, fn)
+	case *BlockStatement:
+		node.Statements = rewriteStatements(node.Statements, fn)
+	case *PrefixExpression:
+		node.Right = rewriteChild(node.Right, fn)
+	case *PostfixExpression:
+		node.Left = rewriteChild(node.Left, fn)
+	case *InfixExpression:
+		node.Left = rewriteChild(node.Left, fn)
+		node.Right = rewriteChild(node.Right, fn)
+	case *IfExpression:
+		node.Condition = rewriteChild(node.Condition, fn)
+		node.Consequence = rewriteChild(node.Consequence, fn)
+		node.Alternative = rewriteChild(node.Alternative, fn)
+	case *TryExpression:
+		node.Body = rewriteChild(node.Body, fn)
+		node.Param = rewriteChild(node.Param, fn)
+		node.Catch = rewriteChild(node.Catch, fn)
+		node.Finally = rewriteChild(node.Finally, fn)
+	case *FunctionLiteral:
+		for idx, param := range node.Parameters {
+			node.Parameters[idx] = rewriteChild(param, fn)
+		}
+		for idx, pattern := range node.Patterns {
+			node.Patterns[idx] = rewriteChild(pattern, fn)
+		}
+		node.Body = rewriteChild(node.Body, fn)
+	case *CallExpression:
+		node.Function = rewriteChild(node.Function, fn)
+		node.Arguments = rewriteExpressions(node.Arguments, fn)
+	case *ArrayLiteral:
+		node.Elements = rewriteExpressions(node.Elements, fn)
+	case *IndexExpression:
+		node.Left = rewriteChild(node.Left, fn)
+		node.Index = rewriteChild(node.Index, fn)
+	case *MemberExpression:
+		node.Object = rewriteChild(node.Object, fn)
+	case *HashLiteral:
+		pairs := make(map[Expression]Expression, len(node.Pairs))
+		for _, key := range node.Keys() {
+			value := rewriteChild(node.Pairs[key], fn)
+			if key = rewriteChild(key, fn); key != nil {
+				pairs[key] = value
+			}
+		}
+		node.Pairs = pairs
+	case *MatchExpression:
+		node.Subject = rewriteChild(node.Subject, fn)
+		for idx, arm := range node.Arms {
+			node.Arms[idx] = rewriteChild(arm, fn)
+		}
+	case *MatchArm:
+		node.Pattern = rewriteChild(node.Pattern, fn)
+		node.Guard = rewriteChild(node.Guard, fn)
+		node.Value = rewriteChild(node.Value, fn)
+		node.Body = rewriteChild(node.Body, fn)
+	case *LiteralPattern:
+		node.Value = rewriteChild(node.Value, fn)
+	case *ArrayPattern:
+		for idx, element := range node.Elements {
+			node.Elements[idx] = rewriteChild(element, fn)
+		}
+		node.Rest = rewriteChild(node.Rest, fn)
+	case *HashPattern:
+		node.Keys = rewriteExpressions(node.Keys, fn)
+		for idx, value := range node.Values {
+			node.Values[idx] = rewriteChild(value, fn)
+		}
+	case *VariantPattern:
+		node.Enum = rewriteChild(node.Enum, fn)
+		for idx, field := range node.Fields {
+			node.Fields[idx] = rewriteChild(field, fn)
+		}
+	case *DefaultPattern:
+		node.Pattern = rewriteChild(node.Pattern, fn)
+		node.Default = rewriteChild(node.Default, fn)
+	case *Identifier, *IntegerLiteral, *StringLiteral, *Boolean, *Variant, *Comment:
+		// leaves
+	default:
+		panic(fmt.Sprintf("ast.Rewrite: unexpected node type %T", node))
+	}
+	return fn(node)
+}
+
+// rewriteChild rewrites a single child, checking that its replacement can
+// take its place. Nil children are left alone.
+func rewriteChild[T Node](child T, fn func(Node) Node) T {
+	var zero T
+	if isNilNode(child) {
+		return zero
+	}
+	replaced := Rewrite(child, fn)
+	if isNilNode(replaced) {
+		return zero
+	}
+	typed, ok := replaced.(T)
+	if !ok {
+		panic(fmt.Sprintf("ast.Rewrite: cannot replace %T with %T", child, replaced))
+	}
+	return typed
+}
+
+func rewriteStatements(list []Statement, fn func(Node) Node) []Statement {
+	rewritten := list[:0]
+	for _, stmt := range list {
+		if stmt = rewriteChild(stmt, fn); stmt != nil {
+			rewritten = append(rewritten, stmt)
+		}
+	}
+	return rewritten
+}
+
+func rewriteExpressions(list []Expression, fn func(Node) Node) []Expression {
+	for idx, expr := range list {
+		list[idx] = rewriteChild(expr, fn)
+	}
+	return list
+}
+
+// isNilNode reports whether node is nil or a typed nil pointer, which is how
+// absent children such as a missing else branch are stored.
+func isNilNode(node Node) bool {
+	if node == nil {
+		return true
+	}
+	value := reflect.ValueOf(node)
+	return value.Kind() == reflect.Pointer && value.IsNil()
+}
@@ -0,0 +1,103 @@
+package ast_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/lexer"
+	"Interpreter_in_Go/parser"
+	"Interpreter_in_Go/token"
+)
+
+func parse(t *testing.T, input string) *ast.RootStatement {
+	t.Helper()
+	psr := parser.NewParser(lexer.NewLexer(input))
+	root := psr.ParseRootStatement()
+	if len(psr.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", psr.Errors())
+	}
+	return root
+}
+
+func TestInspect(t *testing.T) {
+	root := parse(t, `let f = func(a, b) { if (a) { b } else { -a } }; {"k": f(1, [2])}["k"];`)
+
+	var visited []string
+	ast.Inspect(root, func(node ast.Node) bool {
+		if node != nil {
+			visited = append(visited, strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast."))
+		}
+		return true
+	})
+	expected := []string{
+		"RootStatement",
+		"LetStatement", "Identifier", "FunctionLiteral", "Identifier", "Identifier",
+		"BlockStatement", "ExpressionStatement", "IfExpression", "Identifier",
+		"BlockStatement", "ExpressionStatement", "Identifier",
+		"BlockStatement", "ExpressionStatement", "PrefixExpression", "Identifier",
+		"ExpressionStatement", "IndexExpression", "HashLiteral", "StringLiteral",
+		"CallExpression", "Identifier", "IntegerLiteral", "ArrayLiteral", "IntegerLiteral",
+		"StringLiteral",
+	}
+	if strings.Join(visited, " ") != strings.Join(expected, " ") {
+		t.Errorf("wrong visiting order.\nexpected=%v\ngot=%v", expected, visited)
+	}
+}
+
+func TestInspectPrunes(t *testing.T) {
+	root := parse(t, `let x = 1; let f = func(y) { let z = y; z };`)
+
+	var names []string
+	ast.Inspect(root, func(node ast.Node) bool {
+		if _, ok := node.(*ast.FunctionLiteral); ok {
+			return false
+		}
+		if let, ok := node.(*ast.LetStatement); ok {
+			names = append(names, let.Name.Value)
+		}
+		return true
+	})
+	if strings.Join(names, ",") != "x,f" {
+		t.Errorf("function body was not skipped. got=%v", names)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	root := parse(t, `let a = x; puts(x, {x: x}); func(x) { x; y; };`)
+
+	rewritten := ast.Rewrite(root, func(node ast.Node) ast.Node {
+		switch node := node.(type) {
+		case *ast.Identifier:
+			if node.Value == "x" {
+				return &ast.Identifier{Token: token.Token{Type: token.IDENT, Literal: "z", Pos: node.Pos()}, Value: "z"}
+			}
+		case *ast.ExpressionStatement:
+			if ident, ok := node.Expression.(*ast.Identifier); ok && ident.Value == "y" {
+				return nil
+			}
+		}
+		return node
+	})
+	expected := `let a = z;puts(z, {z:z})func(z)z`
+	if rewritten.String() != expected {
+		t.Errorf("wrong rewritten tree.\nexpected=%q\ngot=%q", expected, rewritten.String())
+	}
+}
+
+func TestRewriteRejectsMisfits(t *testing.T) {
+	root := parse(t, `let a = 1;`)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected Rewrite to panic")
+		}
+	}()
+	ast.Rewrite(root, func(node ast.Node) ast.Node {
+		if _, ok := node.(*ast.Identifier); ok {
+			return &ast.IntegerLiteral{Value: 1}
+		}
+		return node
+	})
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"Interpreter_in_Go/bytecode"
+	"Interpreter_in_Go/compiler"
+)
+
+// bytecodeExt is the extension of files written by 'flint build'.
+const bytecodeExt = ".flc"
+
+// buildCommand implements 'flint build file [-o output] [-O]', which compiles a
+// script to a bytecode file that 'flint run' executes without parsing it.
+// The output defaults to the script's name with the .flc extension.
+func buildCommand(args []string) int {
+	flags := flag.NewFlagSet("build", flag.ContinueOnError)
+	output := flags.String("o", "", "write the bytecode to this file")
+	optimized := optimizeFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	name := flags.Arg(0)
+	// flags may also follow the file name
+	if err := flags.Parse(flags.Args()[min(1, flags.NArg()):]); err != nil {
+		return 2
+	}
+	if name == "" || flags.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: flint build file [-o output] [-O]")
+		return 2
+	}
+	if *output == "" {
+		*output = strings.TrimSuffix(name, sourceExt) + bytecodeExt
+	}
+	_, src, err := readSource(name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	root, ok := resolveSource(name, src, *optimized)
+	if !ok {
+		return 1
+	}
+	cmp := compiler.New()
+	if err := cmp.Compile(root); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", name, err)
+		return 1
+	}
+	var out bytes.Buffer
+	if err := bytecode.Encode(&out, cmp.Bytecode()); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", name, err)
+		return 1
+	}
+	if err := os.WriteFile(*output, out.Bytes(), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return 0
+}
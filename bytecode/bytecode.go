@@ -0,0 +1,301 @@
+// Package bytecode reads and writes compiled programs as .flc files, so they
+// can be run without being parsed and compiled again.
+//
+// A file starts with the magic bytes "FLC\x00", followed by the format
+// version as a big endian uint16, the length of the payload as a big endian
+// uint32, the payload and the CRC-32 (IEEE) checksum of the payload. The
+// payload holds the program's instructions and line table, the names of its
+// globals and its constant pool, where functions carry their instructions,
+// slot layout and line tables. Integers are varints and strings are prefixed
+// with their length.
+package bytecode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+
+	"Interpreter_in_Go/code"
+	"Interpreter_in_Go/compiler"
+	"Interpreter_in_Go/object"
+	"Interpreter_in_Go/token"
+)
+
+// Version is the format version written by Encode, and the only one Decode
+// accepts. It changes whenever the layout or the instruction set does.
+const Version = 2
+
+// Magic starts every bytecode file.
+const Magic = "FLC\x00"
+
+var (
+	ErrNotBytecode = errors.New("not a bytecode file")
+	ErrChecksum    = errors.New("bytecode checksum mismatch")
+	ErrMalformed   = errors.New("malformed bytecode")
+)
+
+// VersionError reports a file written by an incompatible version.
+type VersionError struct {
+	Version int
+}
+
+func (err *VersionError) Error() string {
+	return fmt.Sprintf("bytecode format version %d is not supported, want %d", err.Version, Version)
+}
+
+const (
+	tagInteger  byte = 1
+	tagString   byte = 2
+	tagFunction byte = 3
+)
+
+const headerSize = len(Magic) + 2 + 4
+
+// IsBytecode reports whether data starts like a bytecode file.
+func IsBytecode(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(Magic))
+}
+
+// Encode writes bc to w.
+func Encode(w io.Writer, bc *compiler.Bytecode) error {
+	enc := &encoder{}
+	enc.instructions(bc.Instructions, bc.Lines)
+	enc.strings(bc.GlobalNames)
+
+	enc.uint(len(bc.Constants))
+	for _, constant := range bc.Constants {
+		if err := enc.constant(constant); err != nil {
+			return err
+		}
+	}
+	payload := enc.buf
+
+	header := make([]byte, 0, headerSize)
+	header = append(header, Magic...)
+	header = binary.BigEndian.AppendUint16(header, Version)
+	header = binary.BigEndian.AppendUint32(header, uint32(len(payload)))
+
+	out := append(header, payload...)
+	out = binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(payload))
+	_, err := w.Write(out)
+	return err
+}
+
+// Decode reads a program written by Encode, checking its version and
+// checksum, and validates its instructions so that running it cannot make
+// the virtual machine read out of bounds.
+func Decode(data []byte) (*compiler.Bytecode, error) {
+	if !IsBytecode(data) {
+		return nil, ErrNotBytecode
+	}
+	if len(data) < len(Magic)+2 {
+		return nil, ErrMalformed
+	}
+	if version := int(binary.BigEndian.Uint16(data[len(Magic):])); version != Version {
+		return nil, &VersionError{Version: version}
+	}
+	if len(data) < headerSize {
+		return nil, ErrMalformed
+	}
+	size := int(binary.BigEndian.Uint32(data[len(Magic)+2:]))
+	if len(data) != headerSize+size+4 {
+		return nil, ErrMalformed
+	}
+	payload := data[headerSize : headerSize+size]
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(data[headerSize+size:]) {
+		return nil, ErrChecksum
+	}
+
+	dec := &decoder{buf: payload}
+	bc := &compiler.Bytecode{}
+	bc.Instructions, bc.Lines = dec.instructions()
+	bc.GlobalNames = dec.strings()
+
+	count := dec.uint()
+	for idx := 0; idx < count && dec.err == nil; idx++ {
+		bc.Constants = append(bc.Constants, dec.constant())
+	}
+	if dec.err == nil && len(dec.buf) != 0 {
+		dec.err = ErrMalformed
+	}
+	if dec.err != nil {
+		return nil, dec.err
+	}
+	if err := validate(bc); err != nil {
+		return nil, err
+	}
+	return bc, nil
+}
+
+type encoder struct {
+	buf []byte
+}
+
+func (enc *encoder) uint(value int) { enc.buf = binary.AppendUvarint(enc.buf, uint64(value)) }
+
+func (enc *encoder) int(value int64) { enc.buf = binary.AppendVarint(enc.buf, value) }
+
+func (enc *encoder) string(value string) {
+	enc.uint(len(value))
+	enc.buf = append(enc.buf, value...)
+}
+
+func (enc *encoder) strings(values []string) {
+	enc.uint(len(values))
+	for _, value := range values {
+		enc.string(value)
+	}
+}
+
+func (enc *encoder) instructions(ins code.Instructions, lines code.LineTable) {
+	enc.uint(len(ins))
+	enc.buf = append(enc.buf, ins...)
+
+	enc.uint(len(lines))
+	for _, entry := range lines {
+		enc.uint(entry.Offset)
+		enc.uint(entry.Pos.Line)
+		enc.uint(entry.Pos.Column)
+	}
+}
+
+func (enc *encoder) constant(constant object.Object) error {
+	switch constant := constant.(type) {
+	case *object.Integer:
+		enc.buf = append(enc.buf, tagInteger)
+		enc.int(constant.Value)
+	case *object.String:
+		enc.buf = append(enc.buf, tagString)
+		enc.string(constant.Value)
+	case *object.CompiledFunction:
+		enc.buf = append(enc.buf, tagFunction)
+		enc.string(constant.Name)
+		enc.uint(constant.NumLocals)
+		enc.uint(constant.NumParameters)
+		enc.uint(len(constant.Cells))
+		for _, slot := range constant.Cells {
+			enc.uint(slot)
+		}
+		enc.strings(constant.LocalNames)
+		enc.strings(constant.FreeNames)
+		enc.instructions(constant.Instructions, constant.Lines)
+	default:
+		return fmt.Errorf("cannot encode constant of type %s", constant.Type())
+	}
+	return nil
+}
+
+// decoder reads the payload. The first error sticks; later reads return
+// zero values.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (dec *decoder) uint() int {
+	if dec.err != nil {
+		return 0
+	}
+	value, read := binary.Uvarint(dec.buf)
+	if read <= 0 || value > math.MaxInt32 {
+		dec.err = ErrMalformed
+		return 0
+	}
+	dec.buf = dec.buf[read:]
+	return int(value)
+}
+
+func (dec *decoder) int() int64 {
+	if dec.err != nil {
+		return 0
+	}
+	value, read := binary.Varint(dec.buf)
+	if read <= 0 {
+		dec.err = ErrMalformed
+		return 0
+	}
+	dec.buf = dec.buf[read:]
+	return value
+}
+
+func (dec *decoder) bytes(count int) []byte {
+	if dec.err != nil {
+		return nil
+	}
+	if count > len(dec.buf) {
+		dec.err = ErrMalformed
+		return nil
+	}
+	value := dec.buf[:count:count]
+	dec.buf = dec.buf[count:]
+	return value
+}
+
+func (dec *decoder) byte() byte {
+	if value := dec.bytes(1); value != nil {
+		return value[0]
+	}
+	return 0
+}
+
+func (dec *decoder) string() string { return string(dec.bytes(dec.uint())) }
+
+// count reads a number of elements that each take at least one byte, which
+// bounds allocations by the size of the input.
+func (dec *decoder) count() int {
+	count := dec.uint()
+	if count > len(dec.buf) {
+		dec.err = ErrMalformed
+		return 0
+	}
+	return count
+}
+
+func (dec *decoder) strings() []string {
+	values := make([]string, dec.count())
+	for idx := range values {
+		values[idx] = dec.string()
+	}
+	return values
+}
+
+func (dec *decoder) instructions() (code.Instructions, code.LineTable) {
+	ins := code.Instructions(dec.bytes(dec.uint()))
+
+	lines := make(code.LineTable, dec.count())
+	for idx := range lines {
+		lines[idx].Offset = dec.uint()
+		lines[idx].Pos = token.Position{Line: dec.uint(), Column: dec.uint()}
+	}
+	return ins, lines
+}
+
+func (dec *decoder) constant() object.Object {
+	switch tag := dec.byte(); tag {
+	case tagInteger:
+		return &object.Integer{Value: dec.int()}
+	case tagString:
+		return &object.String{Value: dec.string()}
+	case tagFunction:
+		fn := &object.CompiledFunction{Name: dec.string()}
+		fn.NumLocals = dec.uint()
+		fn.NumParameters = dec.uint()
+		fn.Cells = make([]int, dec.count())
+		for idx := range fn.Cells {
+			fn.Cells[idx] = dec.uint()
+		}
+		fn.LocalNames = dec.strings()
+		fn.FreeNames = dec.strings()
+		fn.Instructions, fn.Lines = dec.instructions()
+		return fn
+	default:
+		if dec.err == nil {
+			dec.err = ErrMalformed
+		}
+		return nil
+	}
+}
@@ -0,0 +1,159 @@
+package bytecode
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"Interpreter_in_Go/code"
+	"Interpreter_in_Go/compiler"
+	"Interpreter_in_Go/lexer"
+	"Interpreter_in_Go/object"
+	"Interpreter_in_Go/parser"
+	"Interpreter_in_Go/token"
+	"Interpreter_in_Go/vm"
+)
+
+const program = `
+let greeting = "hello";
+let makeCounter = func(start) {
+	let step = func(n) { start + n };
+	step
+};
+let fib = func(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) };
+[greeting, makeCounter(-40)(2), fib(10), {"a": true}["a"]]`
+
+func compile(t *testing.T, input string) *compiler.Bytecode {
+	t.Helper()
+	root := parser.NewParser(lexer.NewLexer(input)).ParseRootStatement()
+	cmp := compiler.New()
+	if err := cmp.Compile(root); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+	return cmp.Bytecode()
+}
+
+func encode(t *testing.T, bc *compiler.Bytecode) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	if err := Encode(&out, bc); err != nil {
+		t.Fatalf("encode error: %s", err)
+	}
+	return out.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	original := compile(t, program)
+	decoded, err := Decode(encode(t, original))
+	if err != nil {
+		t.Fatalf("decode error: %s", err)
+	}
+	if decoded.Instructions.String() != original.Instructions.String() {
+		t.Errorf("instructions differ.\nwant=%s\ngot=%s", original.Instructions, decoded.Instructions)
+	}
+	if len(decoded.Constants) != len(original.Constants) {
+		t.Fatalf("wrong number of constants. want=%d, got=%d", len(original.Constants), len(decoded.Constants))
+	}
+	for idx, constant := range original.Constants {
+		fn, ok := constant.(*object.CompiledFunction)
+		if !ok {
+			if decoded.Constants[idx].Inspect() != constant.Inspect() {
+				t.Errorf("constant %d differs. want=%s, got=%s", idx, constant.Inspect(), decoded.Constants[idx].Inspect())
+			}
+			continue
+		}
+		got := decoded.Constants[idx].(*object.CompiledFunction)
+		if got.Name != fn.Name || got.Instructions.String() != fn.Instructions.String() ||
+			got.NumLocals != fn.NumLocals || len(got.Cells) != len(fn.Cells) || len(got.Lines) != len(fn.Lines) {
+			t.Errorf("function %d differs. want=%+v, got=%+v", idx, fn, got)
+		}
+	}
+
+	machine := vm.New(decoded)
+	if err := machine.Run(); err != nil {
+		t.Fatalf("vm error: %s", err)
+	}
+	if result := machine.Result().Inspect(); result != "[hello, -38, 55, true]" {
+		t.Errorf("wrong result. got=%s", result)
+	}
+}
+
+func TestLineTables(t *testing.T) {
+	bc := compile(t, "let a = 1;\nlet f = func(x) {\n\tx +\n\ta\n};")
+	decoded, err := Decode(encode(t, bc))
+	if err != nil {
+		t.Fatalf("decode error: %s", err)
+	}
+	fn := decoded.Constants[len(decoded.Constants)-1].(*object.CompiledFunction)
+
+	// the instruction reading a
+	offset := len(code.Make(code.OpGetLocal, 0))
+	want := token.Position{Line: 4, Column: 2}
+	if got := fn.Lines.Lookup(offset); got != want {
+		t.Errorf("wrong position of instruction %d. want=%s, got=%s", offset, want, got)
+	}
+}
+
+func TestRejectsDamagedFiles(t *testing.T) {
+	data := encode(t, compile(t, program))
+
+	flipped := bytes.Clone(data)
+	flipped[headerSize+3] ^= 0x10
+	if _, err := Decode(flipped); !errors.Is(err, ErrChecksum) {
+		t.Errorf("flipped bit not detected. got=%v", err)
+	}
+
+	for size := 0; size < len(data); size++ {
+		if _, err := Decode(data[:size]); err == nil {
+			t.Errorf("truncated file of %d bytes accepted", size)
+		}
+	}
+
+	if _, err := Decode([]byte("let a = 1;")); !errors.Is(err, ErrNotBytecode) {
+		t.Errorf("source accepted as bytecode. got=%v", err)
+	}
+}
+
+func TestRejectsOtherVersions(t *testing.T) {
+	data := encode(t, compile(t, "1"))
+	data[len(Magic)+1] = Version + 1
+
+	_, err := Decode(data)
+	var versionErr *VersionError
+	if !errors.As(err, &versionErr) || versionErr.Version != Version+1 {
+		t.Fatalf("expected a version error. got=%v", err)
+	}
+}
+
+func TestRejectsInvalidInstructions(t *testing.T) {
+	tests := []struct {
+		name string
+		bc   *compiler.Bytecode
+	}{
+		{"constant out of range", &compiler.Bytecode{
+			Instructions: code.Make(code.OpConstant, 3),
+		}},
+		{"stack underflow", &compiler.Bytecode{
+			Instructions: code.Make(code.OpAdd),
+		}},
+		{"jump into an operand", &compiler.Bytecode{
+			Instructions: append(code.Make(code.OpConstant, 0), code.Make(code.OpJump, 1)...),
+			Constants:    []object.Object{&object.Integer{Value: 1}},
+		}},
+		{"local in the main program", &compiler.Bytecode{
+			Instructions: code.Make(code.OpGetLocal, 0),
+		}},
+		{"unknown opcode", &compiler.Bytecode{
+			Instructions: code.Instructions{255},
+		}},
+		{"function without return", &compiler.Bytecode{
+			Constants: []object.Object{&object.CompiledFunction{Instructions: code.Make(code.OpNull)}},
+		}},
+	}
+	for _, tt := range tests {
+		_, err := Decode(encode(t, tt.bc))
+		if !errors.Is(err, ErrMalformed) {
+			t.Errorf("%s: expected a malformed bytecode error. got=%v", tt.name, err)
+		}
+	}
+}
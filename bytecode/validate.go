@@ -0,0 +1,232 @@
+package bytecode
+
+import (
+	"fmt"
+	"slices"
+
+	"Interpreter_in_Go/code"
+	"Interpreter_in_Go/compiler"
+	"Interpreter_in_Go/object"
+)
+
+// maxLocals mirrors the one byte operands addressing locals and free
+// variables.
+const maxLocals = 256
+
+// validate checks that every operand of bc refers to something that exists
+// and that no instruction pops more values than its function pushed, so a
+// decoded program is as safe to run as a freshly compiled one.
+func validate(bc *compiler.Bytecode) error {
+	main := &object.CompiledFunction{Instructions: bc.Instructions}
+	if err := validateFunction(bc, main, true); err != nil {
+		return fmt.Errorf("%w: main program: %s", ErrMalformed, err)
+	}
+	for idx, constant := range bc.Constants {
+		fn, ok := constant.(*object.CompiledFunction)
+		if !ok {
+			continue
+		}
+		if err := validateFunction(bc, fn, false); err != nil {
+			return fmt.Errorf("%w: function %d: %s", ErrMalformed, idx, err)
+		}
+	}
+	return nil
+}
+
+func validateFunction(bc *compiler.Bytecode, fn *object.CompiledFunction, main bool) error {
+	if fn.NumLocals > maxLocals || len(fn.FreeNames) > maxLocals {
+		return fmt.Errorf("too many locals or free variables")
+	}
+	if fn.NumParameters > fn.NumLocals || len(fn.LocalNames) != fn.NumLocals {
+		return fmt.Errorf("inconsistent local slots")
+	}
+	for _, slot := range fn.Cells {
+		if slot < 0 || slot >= fn.NumLocals {
+			return fmt.Errorf("cell slot %d out of range", slot)
+		}
+	}
+	effects, err := decodeInstructions(bc, fn)
+	if err != nil {
+		return err
+	}
+	return checkStack(fn.Instructions, effects, main)
+}
+
+// effect is what an instruction does to the stack and where control goes
+// next.
+type effect struct {
+	pops, pushes int
+	next         int  // offset of the following instruction
+	jump         int  // jump target, or -1
+	falls        bool // whether control can continue with next
+}
+
+func decodeInstructions(bc *compiler.Bytecode, fn *object.CompiledFunction) (map[int]effect, error) {
+	effects := make(map[int]effect)
+	ins := fn.Instructions
+
+	for offset := 0; offset < len(ins); {
+		def, err := code.Lookup(ins[offset])
+		if err != nil {
+			return nil, err
+		}
+		width := 0
+		for _, operandWidth := range def.OperandWidths {
+			width += operandWidth
+		}
+		if offset+1+width > len(ins) {
+			return nil, fmt.Errorf("truncated %s at %d", def.Name, offset)
+		}
+		operands, _ := code.ReadOperands(def, ins[offset+1:])
+		eff := effect{next: offset + 1 + width, jump: -1, falls: true}
+		if err := describe(bc, fn, code.Opcode(ins[offset]), operands, &eff); err != nil {
+			return nil, fmt.Errorf("%s at %d: %s", def.Name, offset, err)
+		}
+		effects[offset] = eff
+		offset = eff.next
+	}
+	return effects, nil
+}
+
+func describe(bc *compiler.Bytecode, fn *object.CompiledFunction, op code.Opcode, operands []int, eff *effect) error {
+	inRange := func(value, limit int, what string) error {
+		if value >= limit {
+			return fmt.Errorf("%s %d out of range", what, value)
+		}
+		return nil
+	}
+	isCell := slices.Contains(fn.Cells, operands0(operands))
+
+	switch op {
+	case code.OpConstant:
+		eff.pushes = 1
+		return inRange(operands[0], len(bc.Constants), "constant")
+	case code.OpPop:
+		eff.pops = 1
+	case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv,
+		code.OpEqual, code.OpNotEqual, code.OpGreaterThan, code.OpLessThan, code.OpIndex:
+		eff.pops, eff.pushes = 2, 1
+	case code.OpTrue, code.OpFalse, code.OpNull:
+		eff.pushes = 1
+	case code.OpMinus, code.OpBang:
+		eff.pops, eff.pushes = 1, 1
+	case code.OpJump:
+		eff.jump, eff.falls = operands[0], false
+	case code.OpJumpNotTruthy:
+		eff.pops, eff.jump = 1, operands[0]
+	case code.OpGetGlobal:
+		eff.pushes = 1
+		return inRange(operands[0], len(bc.GlobalNames), "global")
+	case code.OpSetGlobal:
+		eff.pops = 1
+		return inRange(operands[0], len(bc.GlobalNames), "global")
+	case code.OpGetLocal, code.OpSetLocal, code.OpGetCell, code.OpSetCell, code.OpLoadCell:
+		if op == code.OpSetLocal || op == code.OpSetCell {
+			eff.pops = 1
+		} else {
+			eff.pushes = 1
+		}
+		if err := inRange(operands[0], fn.NumLocals, "local"); err != nil {
+			return err
+		}
+		if wantCell := op == code.OpGetCell || op == code.OpSetCell || op == code.OpLoadCell; wantCell != isCell {
+			return fmt.Errorf("local %d used as the wrong kind of slot", operands[0])
+		}
+	case code.OpGetFree, code.OpLoadFree:
+		eff.pushes = 1
+		return inRange(operands[0], len(fn.FreeNames), "free variable")
+	case code.OpGetBuiltIn:
+		eff.pushes = 1
+		return inRange(operands[0], len(object.BuiltIns), "builtin")
+	case code.OpArray:
+		eff.pops, eff.pushes = operands[0], 1
+	case code.OpHash:
+		if operands[0]%2 != 0 {
+			return fmt.Errorf("odd number of hash elements")
+		}
+		eff.pops, eff.pushes = operands[0], 1
+	case code.OpClosure:
+		if err := inRange(operands[0], len(bc.Constants), "constant"); err != nil {
+			return err
+		}
+		closed, ok := bc.Constants[operands[0]].(*object.CompiledFunction)
+		if !ok {
+			return fmt.Errorf("constant %d is not a function", operands[0])
+		}
+		if operands[1] != len(closed.FreeNames) {
+			return fmt.Errorf("function %d captures %d variables, not %d", operands[0], len(closed.FreeNames), operands[1])
+		}
+		eff.pops, eff.pushes = operands[1], 1
+	case code.OpCall, code.OpTailCall:
+		eff.pops, eff.pushes = operands[0]+1, 1
+	case code.OpReturnValue:
+		eff.pops, eff.falls = 1, false
+	case code.OpReturn:
+		eff.falls = false
+	default:
+		return fmt.Errorf("unexpected opcode")
+	}
+	return nil
+}
+
+func operands0(operands []int) int {
+	if len(operands) == 0 {
+		return -1
+	}
+	return operands[0]
+}
+
+// checkStack follows every path through ins, checking that the stack depth
+// at each instruction is the same along all of them and never negative.
+// Only the main program may run off its end.
+func checkStack(ins code.Instructions, effects map[int]effect, main bool) error {
+	depths := map[int]int{}
+	work := []int{0}
+	depths[0] = 0
+
+	visit := func(offset, depth int) error {
+		if offset == len(ins) {
+			if !main {
+				return fmt.Errorf("function ends without returning")
+			}
+			return nil
+		}
+		if _, ok := effects[offset]; !ok {
+			return fmt.Errorf("jump to %d is not an instruction", offset)
+		}
+		if seen, ok := depths[offset]; ok {
+			if seen != depth {
+				return fmt.Errorf("inconsistent stack depth at %d", offset)
+			}
+			return nil
+		}
+		depths[offset] = depth
+		work = append(work, offset)
+		return nil
+	}
+	if len(ins) == 0 {
+		return visit(0, 0)
+	}
+	for len(work) > 0 {
+		offset := work[len(work)-1]
+		work = work[:len(work)-1]
+
+		eff := effects[offset]
+		depth := depths[offset] - eff.pops
+		if depth < 0 {
+			return fmt.Errorf("stack underflow at %d", offset)
+		}
+		depth += eff.pushes
+		if eff.falls {
+			if err := visit(eff.next, depth); err != nil {
+				return err
+			}
+		}
+		if eff.jump >= 0 {
+			if err := visit(eff.jump, depth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"Interpreter_in_Go/token"
+)
+
+// Instructions is a sequence of encoded instructions: an opcode byte followed
+// by its operands in big endian order.
+type Instructions []byte
+
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+
+	for idx := 0; idx < len(ins); {
+		def, err := Lookup(ins[idx])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			idx++
+			continue
+		}
+		operands, read := ReadOperands(def, ins[idx+1:])
+		fmt.Fprintf(&out, "%04d %s\n", idx, ins.formatInstruction(def, operands))
+		idx += 1 + read
+	}
+	return out.String()
+}
+
+func (ins Instructions) formatInstruction(def *Definition, operands []int) string {
+	count := len(def.OperandWidths)
+	if len(operands) != count {
+		return fmt.Sprintf("ERROR: operand len %d does not match defined %d\n", len(operands), count)
+	}
+	switch count {
+	case 0:
+		return def.Name
+	case 1:
+		return fmt.Sprintf("%s %d", def.Name, operands[0])
+	case 2:
+		return fmt.Sprintf("%s %d %d", def.Name, operands[0], operands[1])
+	}
+	return fmt.Sprintf("ERROR: unhandled operand count for %s\n", def.Name)
+}
+
+type Opcode byte
+
+const (
+	OpConstant Opcode = iota
+	OpPop
+
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+
+	OpTrue
+	OpFalse
+	OpNull
+
+	OpEqual
+	OpNotEqual
+	OpGreaterThan
+	OpLessThan
+
+	OpMinus
+	OpBang
+
+	OpJumpNotTruthy
+	OpJump
+
+	OpGetGlobal
+	OpSetGlobal
+	OpGetLocal
+	OpSetLocal
+	OpGetCell
+	OpSetCell
+	OpLoadCell
+	OpGetFree
+	OpLoadFree
+	OpGetBuiltIn
+
+	OpArray
+	OpHash
+	OpIndex
+
+	OpClosure
+	OpCall
+	OpReturnValue
+	OpReturn
+	OpTailCall
+)
+
+// Definition describes an opcode: its readable name and the width in bytes
+// of each of its operands.
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}},
+	OpPop:      {"OpPop", []int{}},
+
+	OpAdd: {"OpAdd", []int{}},
+	OpSub: {"OpSub", []int{}},
+	OpMul: {"OpMul", []int{}},
+	OpDiv: {"OpDiv", []int{}},
+
+	OpTrue:  {"OpTrue", []int{}},
+	OpFalse: {"OpFalse", []int{}},
+	OpNull:  {"OpNull", []int{}},
+
+	OpEqual:       {"OpEqual", []int{}},
+	OpNotEqual:    {"OpNotEqual", []int{}},
+	OpGreaterThan: {"OpGreaterThan", []int{}},
+	OpLessThan:    {"OpLessThan", []int{}},
+
+	OpMinus: {"OpMinus", []int{}},
+	OpBang:  {"OpBang", []int{}},
+
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+	OpJump:          {"OpJump", []int{2}},
+
+	OpGetGlobal:  {"OpGetGlobal", []int{2}},
+	OpSetGlobal:  {"OpSetGlobal", []int{2}},
+	OpGetLocal:   {"OpGetLocal", []int{1}},
+	OpSetLocal:   {"OpSetLocal", []int{1}},
+	OpGetCell:    {"OpGetCell", []int{1}},
+	OpSetCell:    {"OpSetCell", []int{1}},
+	OpLoadCell:   {"OpLoadCell", []int{1}},
+	OpGetFree:    {"OpGetFree", []int{1}},
+	OpLoadFree:   {"OpLoadFree", []int{1}},
+	OpGetBuiltIn: {"OpGetBuiltIn", []int{1}},
+
+	OpArray: {"OpArray", []int{2}},
+	OpHash:  {"OpHash", []int{2}},
+	OpIndex: {"OpIndex", []int{}},
+
+	// constant index of the function, number of free variables
+	OpClosure:     {"OpClosure", []int{2, 1}},
+	OpCall:        {"OpCall", []int{1}},
+	OpReturnValue: {"OpReturnValue", []int{}},
+	OpReturn:      {"OpReturn", []int{}},
+
+	// a call whose result the calling function returns
+	OpTailCall: {"OpTailCall", []int{1}},
+}
+
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+	return def, nil
+}
+
+// Make encodes an instruction. It returns an empty slice for an unknown
+// opcode.
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+	length := 1
+	for _, width := range def.OperandWidths {
+		length += width
+	}
+	instruction := make([]byte, length)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for idx, operand := range operands {
+		width := def.OperandWidths[idx]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(operand))
+		case 1:
+			instruction[offset] = byte(operand)
+		}
+		offset += width
+	}
+	return instruction
+}
+
+// ReadOperands decodes the operands of an instruction of kind def and
+// returns them with the number of bytes read.
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for idx, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[idx] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[idx] = int(ReadUint8(ins[offset:]))
+		}
+		offset += width
+	}
+	return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 { return binary.BigEndian.Uint16(ins) }
+
+func ReadUint8(ins Instructions) uint8 { return ins[0] }
+
+// LineEntry maps the instructions from Offset up to the next entry to the
+// source position they were compiled from.
+type LineEntry struct {
+	Offset int
+	Pos    token.Position
+}
+
+// LineTable is the debug information of a sequence of instructions, ordered
+// by offset.
+type LineTable []LineEntry
+
+// Lookup returns the source position of the instruction at offset, or the
+// zero Position when the table does not cover it.
+func (lt LineTable) Lookup(offset int) token.Position {
+	idx := sort.Search(len(lt), func(idx int) bool { return lt[idx].Offset > offset })
+	if idx == 0 {
+		return token.Position{}
+	}
+	return lt[idx-1].Pos
+}
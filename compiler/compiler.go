@@ -0,0 +1,481 @@
+package compiler
+
+import (
+	"errors"
+	"fmt"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/code"
+	"Interpreter_in_Go/object"
+	"Interpreter_in_Go/token"
+)
+
+// maxLocals is the number of locals and free variables a function may have,
+// bounded by the one byte operand addressing them.
+const maxLocals = 256
+
+// Bytecode is a compiled program, ready to be run by the virtual machine.
+type Bytecode struct {
+	Instructions code.Instructions
+	Lines        code.LineTable
+	Constants    []object.Object
+	GlobalNames  []string // name of each global, by index
+}
+
+type EmittedInstruction struct {
+	Opcode   code.Opcode
+	Position int
+}
+
+type CompilationScope struct {
+	instructions        code.Instructions
+	lines               code.LineTable
+	lastInstruction     EmittedInstruction
+	previousInstruction EmittedInstruction
+}
+
+// Compiler turns a syntax tree into bytecode whose behavior matches the
+// evaluator's.
+type Compiler struct {
+	constants   []object.Object
+	symbolTable *SymbolTable
+
+	scopes     []CompilationScope
+	scopeIndex int
+
+	pos token.Position // of the node being compiled
+}
+
+func New() *Compiler {
+	return NewWithState(NewSymbolTable(), []object.Object{})
+}
+
+// NewWithState creates a compiler that continues where an earlier one left
+// off, so globals and constants carry over from one REPL line to the next.
+func NewWithState(symbolTable *SymbolTable, constants []object.Object) *Compiler {
+	return &Compiler{
+		constants:   constants,
+		symbolTable: symbolTable,
+		scopes:      []CompilationScope{{}},
+	}
+}
+
+// SymbolTable returns the global symbol table, for use with NewWithState.
+func (cmp *Compiler) SymbolTable() *SymbolTable { return cmp.symbolTable }
+
+func (cmp *Compiler) Bytecode() *Bytecode {
+	return &Bytecode{
+		Instructions: cmp.currentInstructions(),
+		Lines:        cmp.scopes[cmp.scopeIndex].lines,
+		Constants:    cmp.constants,
+		GlobalNames:  cmp.symbolTable.Names(),
+	}
+}
+
+func (cmp *Compiler) Compile(node ast.Node) error {
+	if node != nil {
+		outer := cmp.pos
+		cmp.pos = node.Pos()
+		defer func() { cmp.pos = outer }()
+	}
+	switch node := node.(type) {
+	case *ast.RootStatement:
+		cmp.symbolTable.bindNames(node)
+		for _, stmt := range node.Statements {
+			if err := cmp.Compile(stmt); err != nil {
+				return err
+			}
+		}
+	case *ast.ExpressionStatement:
+		if err := cmp.Compile(node.Expression); err != nil {
+			return err
+		}
+		cmp.emit(code.OpPop)
+	case *ast.LetStatement:
+		if node.Pattern != nil {
+			return unsupported(node.Pattern)
+		}
+		if err := cmp.Compile(node.Value); err != nil {
+			return err
+		}
+		if _, ok := node.Value.(*ast.FunctionLiteral); ok {
+			cmp.nameLastFunction(node.Name.Value)
+		}
+		cmp.storeSymbol(cmp.symbolTable.Define(node.Name.Value))
+	case *ast.ReturnStatement:
+		if err := cmp.Compile(node.ReturnValue); err != nil {
+			return err
+		}
+		cmp.emit(code.OpReturnValue)
+
+	case *ast.Identifier:
+		cmp.loadSymbol(cmp.symbolTable.Resolve(node.Value))
+	case *ast.IntegerLiteral:
+		cmp.emit(code.OpConstant, cmp.addConstant(&object.Integer{Value: node.Value}))
+	case *ast.StringLiteral:
+		cmp.emit(code.OpConstant, cmp.addConstant(&object.String{Value: node.Value}))
+	case *ast.Boolean:
+		if node.Value {
+			cmp.emit(code.OpTrue)
+		} else {
+			cmp.emit(code.OpFalse)
+		}
+	case *ast.ArrayLiteral:
+		for _, elem := range node.Elements {
+			if err := cmp.Compile(elem); err != nil {
+				return err
+			}
+		}
+		cmp.emit(code.OpArray, len(node.Elements))
+	case *ast.HashLiteral:
+		keys := node.Keys()
+		for _, key := range keys {
+			if err := cmp.Compile(key); err != nil {
+				return err
+			}
+			if err := cmp.Compile(node.Pairs[key]); err != nil {
+				return err
+			}
+		}
+		cmp.emit(code.OpHash, len(keys)*2)
+
+	case *ast.PrefixExpression:
+		if err := cmp.Compile(node.Right); err != nil {
+			return err
+		}
+		switch node.Operator {
+		case "!":
+			cmp.emit(code.OpBang)
+		case "-":
+			cmp.emit(code.OpMinus)
+		default:
+			return fmt.Errorf("unknown operator %s", node.Operator)
+		}
+	case *ast.InfixExpression:
+		return cmp.compileInfix(node)
+	case *ast.IndexExpression:
+		if err := cmp.Compile(node.Left); err != nil {
+			return err
+		}
+		if err := cmp.Compile(node.Index); err != nil {
+			return err
+		}
+		cmp.emit(code.OpIndex)
+
+	case *ast.IfExpression:
+		return cmp.compileIf(node)
+	case *ast.FunctionLiteral:
+		return cmp.compileFunction(node)
+	case *ast.CallExpression:
+		if err := cmp.Compile(node.Function); err != nil {
+			return err
+		}
+		for _, arg := range node.Arguments {
+			if err := cmp.Compile(arg); err != nil {
+				return err
+			}
+		}
+		cmp.emit(code.OpCall, len(node.Arguments))
+
+	case *ast.ThrowStatement, *ast.TryExpression, *ast.PostfixExpression, *ast.DeferStatement,
+		*ast.ImportStatement, *ast.ExportStatement, *ast.MemberExpression, *ast.AssignStatement,
+		*ast.StructStatement, *ast.ClassStatement, *ast.EnumStatement, *ast.MatchExpression:
+		return unsupported(node)
+
+	default:
+		return fmt.Errorf("cannot compile %T", node)
+	}
+	return nil
+}
+
+// ErrUnsupported is wrapped by the errors of programs using a feature only
+// the evaluator implements.
+var ErrUnsupported = errors.New("not supported by the vm engine")
+
+func unsupported(node ast.Node) error {
+	return fmt.Errorf("%s: %s %w", node.Pos(), node.TokenLiteral(), ErrUnsupported)
+}
+
+var infixOpcodes = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+	">":  code.OpGreaterThan,
+	"<":  code.OpLessThan,
+}
+
+func (cmp *Compiler) compileInfix(node *ast.InfixExpression) error {
+	if node.Operator == "instanceof" {
+		return unsupported(node)
+	}
+	op, ok := infixOpcodes[node.Operator]
+	if !ok {
+		return fmt.Errorf("unknown operator %s", node.Operator)
+	}
+	if err := cmp.Compile(node.Left); err != nil {
+		return err
+	}
+	if err := cmp.Compile(node.Right); err != nil {
+		return err
+	}
+	cmp.emit(op)
+	return nil
+}
+
+func (cmp *Compiler) compileIf(node *ast.IfExpression) error {
+	if err := cmp.Compile(node.Condition); err != nil {
+		return err
+	}
+	jumpNotTruthyPos := cmp.emit(code.OpJumpNotTruthy, 9999)
+
+	if err := cmp.compileBlockValue(node.Consequence); err != nil {
+		return err
+	}
+	jumpPos := cmp.emit(code.OpJump, 9999)
+	cmp.changeOperand(jumpNotTruthyPos, len(cmp.currentInstructions()))
+
+	if node.Alternative == nil {
+		cmp.emit(code.OpNull)
+	} else if err := cmp.compileBlockValue(node.Alternative); err != nil {
+		return err
+	}
+	cmp.changeOperand(jumpPos, len(cmp.currentInstructions()))
+	return nil
+}
+
+// compileBlockValue compiles a block that leaves the value of its last
+// statement on the stack, or null when that is not an expression.
+func (cmp *Compiler) compileBlockValue(block *ast.BlockStatement) error {
+	for _, stmt := range block.Statements {
+		if err := cmp.Compile(stmt); err != nil {
+			return err
+		}
+	}
+	if cmp.lastInstructionIs(code.OpPop) && endsWithExpression(block.Statements) {
+		cmp.removeLastPop()
+	} else {
+		cmp.emit(code.OpNull)
+	}
+	return nil
+}
+
+func endsWithExpression(stmts []ast.Statement) bool {
+	if len(stmts) == 0 {
+		return false
+	}
+	_, ok := stmts[len(stmts)-1].(*ast.ExpressionStatement)
+	return ok
+}
+
+func (cmp *Compiler) compileFunction(node *ast.FunctionLiteral) error {
+	for _, pattern := range node.Patterns {
+		if pattern != nil {
+			return unsupported(pattern)
+		}
+	}
+	cmp.enterScope(NewFunctionSymbolTable(cmp.symbolTable, node))
+
+	for _, param := range node.Parameters {
+		cmp.symbolTable.Define(param.Value)
+	}
+	var body []ast.Statement
+	if node.Body != nil {
+		body = node.Body.Statements
+	}
+	for _, stmt := range body {
+		if err := cmp.Compile(stmt); err != nil {
+			return err
+		}
+	}
+	if cmp.lastInstructionIs(code.OpPop) && endsWithExpression(body) {
+		cmp.replaceLastPopWithReturn()
+	}
+	if !cmp.lastInstructionIs(code.OpReturnValue) {
+		cmp.emit(code.OpReturn)
+	}
+
+	markTailCalls(cmp.currentInstructions())
+	symbols := cmp.symbolTable
+	lines := cmp.scopes[cmp.scopeIndex].lines
+	instructions := cmp.leaveScope()
+
+	names := symbols.Names()
+	if len(names) > maxLocals || len(symbols.FreeSymbols) > maxLocals {
+		return fmt.Errorf("function has more than %d locals or free variables", maxLocals)
+	}
+	fn := &object.CompiledFunction{
+		Instructions:  instructions,
+		Lines:         lines,
+		NumLocals:     len(names),
+		NumParameters: len(node.Parameters),
+		LocalNames:    names,
+	}
+	for idx, name := range names {
+		if symbols.store[name].Scope == CellScope {
+			fn.Cells = append(fn.Cells, idx)
+		}
+	}
+	for _, sym := range symbols.FreeSymbols {
+		fn.FreeNames = append(fn.FreeNames, sym.Name)
+		switch sym.Scope {
+		case CellScope:
+			cmp.emit(code.OpLoadCell, sym.Index)
+		case FreeScope:
+			cmp.emit(code.OpLoadFree, sym.Index)
+		default:
+			return fmt.Errorf("cannot capture %s symbol %s", sym.Scope, sym.Name)
+		}
+	}
+	cmp.emit(code.OpClosure, cmp.addConstant(fn), len(symbols.FreeSymbols))
+	return nil
+}
+
+// nameLastFunction names the function just compiled, which is always the
+// last constant.
+func (cmp *Compiler) nameLastFunction(name string) {
+	if compiled, ok := cmp.constants[len(cmp.constants)-1].(*object.CompiledFunction); ok {
+		compiled.Name = name
+	}
+}
+
+// markTailCalls turns the calls of a function whose result it returns, right
+// away or after jumping to the end of if expressions, into tail calls.
+func markTailCalls(ins code.Instructions) {
+	for offset := 0; offset < len(ins); {
+		op := code.Opcode(ins[offset])
+		next := offset + instructionWidth(op)
+		if op == code.OpCall && returnsAt(ins, next) {
+			ins[offset] = byte(code.OpTailCall)
+		}
+		offset = next
+	}
+}
+
+func returnsAt(ins code.Instructions, offset int) bool {
+	for offset < len(ins) {
+		switch code.Opcode(ins[offset]) {
+		case code.OpReturnValue:
+			return true
+		case code.OpJump:
+			target := int(code.ReadUint16(ins[offset+1:]))
+			if target <= offset {
+				return false
+			}
+			offset = target
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+func instructionWidth(op code.Opcode) int {
+	def, err := code.Lookup(byte(op))
+	if err != nil {
+		return 1
+	}
+	width := 1
+	for _, operandWidth := range def.OperandWidths {
+		width += operandWidth
+	}
+	return width
+}
+
+func (cmp *Compiler) loadSymbol(sym Symbol) {
+	switch sym.Scope {
+	case GlobalScope:
+		cmp.emit(code.OpGetGlobal, sym.Index)
+	case LocalScope:
+		cmp.emit(code.OpGetLocal, sym.Index)
+	case CellScope:
+		cmp.emit(code.OpGetCell, sym.Index)
+	case FreeScope:
+		cmp.emit(code.OpGetFree, sym.Index)
+	case BuiltInScope:
+		cmp.emit(code.OpGetBuiltIn, sym.Index)
+	}
+}
+
+func (cmp *Compiler) storeSymbol(sym Symbol) {
+	switch sym.Scope {
+	case GlobalScope:
+		cmp.emit(code.OpSetGlobal, sym.Index)
+	case LocalScope:
+		cmp.emit(code.OpSetLocal, sym.Index)
+	case CellScope:
+		cmp.emit(code.OpSetCell, sym.Index)
+	}
+}
+
+func (cmp *Compiler) addConstant(ob object.Object) int {
+	cmp.constants = append(cmp.constants, ob)
+	return len(cmp.constants) - 1
+}
+
+// emit appends an instruction to the current scope and returns its position.
+func (cmp *Compiler) emit(op code.Opcode, operands ...int) int {
+	ins := code.Make(op, operands...)
+	pos := len(cmp.currentInstructions())
+	cmp.scopes[cmp.scopeIndex].instructions = append(cmp.currentInstructions(), ins...)
+
+	scope := &cmp.scopes[cmp.scopeIndex]
+	if count := len(scope.lines); count == 0 || scope.lines[count-1].Pos != cmp.pos {
+		scope.lines = append(scope.lines, code.LineEntry{Offset: pos, Pos: cmp.pos})
+	}
+	scope.previousInstruction = scope.lastInstruction
+	scope.lastInstruction = EmittedInstruction{Opcode: op, Position: pos}
+	return pos
+}
+
+func (cmp *Compiler) currentInstructions() code.Instructions {
+	return cmp.scopes[cmp.scopeIndex].instructions
+}
+
+func (cmp *Compiler) lastInstructionIs(op code.Opcode) bool {
+	if len(cmp.currentInstructions()) == 0 {
+		return false
+	}
+	return cmp.scopes[cmp.scopeIndex].lastInstruction.Opcode == op
+}
+
+func (cmp *Compiler) removeLastPop() {
+	scope := &cmp.scopes[cmp.scopeIndex]
+	end := scope.lastInstruction.Position
+	scope.instructions = scope.instructions[:end]
+	for count := len(scope.lines); count > 0 && scope.lines[count-1].Offset >= end; count-- {
+		scope.lines = scope.lines[:count-1]
+	}
+	scope.lastInstruction = scope.previousInstruction
+}
+
+func (cmp *Compiler) replaceLastPopWithReturn() {
+	pos := cmp.scopes[cmp.scopeIndex].lastInstruction.Position
+	cmp.replaceInstruction(pos, code.Make(code.OpReturnValue))
+	cmp.scopes[cmp.scopeIndex].lastInstruction.Opcode = code.OpReturnValue
+}
+
+func (cmp *Compiler) replaceInstruction(pos int, ins []byte) {
+	copy(cmp.currentInstructions()[pos:], ins)
+}
+
+func (cmp *Compiler) changeOperand(pos int, operand int) {
+	op := code.Opcode(cmp.currentInstructions()[pos])
+	cmp.replaceInstruction(pos, code.Make(op, operand))
+}
+
+func (cmp *Compiler) enterScope(symbolTable *SymbolTable) {
+	cmp.scopes = append(cmp.scopes, CompilationScope{})
+	cmp.scopeIndex++
+	cmp.symbolTable = symbolTable
+}
+
+func (cmp *Compiler) leaveScope() code.Instructions {
+	instructions := cmp.currentInstructions()
+	cmp.scopes = cmp.scopes[:len(cmp.scopes)-1]
+	cmp.scopeIndex--
+	cmp.symbolTable = cmp.symbolTable.Outer
+	return instructions
+}
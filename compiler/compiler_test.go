@@ -0,0 +1,349 @@
+package compiler
+
+import (
+	"errors"
+	"testing"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/code"
+	"Interpreter_in_Go/lexer"
+	"Interpreter_in_Go/object"
+	"Interpreter_in_Go/parser"
+)
+
+type compilerTestCase struct {
+	input                string
+	expectedConstants    []any
+	expectedInstructions []code.Instructions
+}
+
+func TestIntegerArithmetic(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "1 + 2",
+			expectedConstants: []any{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "1 < 2",
+			expectedConstants: []any{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpLessThan),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input:             "-1; !true",
+			expectedConstants: []any{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpMinus),
+				code.Make(code.OpPop),
+				code.Make(code.OpTrue),
+				code.Make(code.OpBang),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+	runCompilerTests(t, tests)
+}
+
+func TestConditionals(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "if (true) { 10 }; 3333;",
+			expectedConstants: []any{10, 3333},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 10),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpJump, 11),
+				code.Make(code.OpNull),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// a block not ending in an expression has the value null
+			input:             "if (true) { let a = 1; }",
+			expectedConstants: []any{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 14),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpNull),
+				code.Make(code.OpJump, 15),
+				code.Make(code.OpNull),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+	runCompilerTests(t, tests)
+}
+
+func TestGlobalsAndBuiltIns(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "let one = 1; one; two;",
+			expectedConstants: []any{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpPop),
+				code.Make(code.OpGetGlobal, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// bindings hide the builtins of the same name
+			input:             "let len = 1; len([]);",
+			expectedConstants: []any{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpArray, 0),
+				code.Make(code.OpCall, 1),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+	runCompilerTests(t, tests)
+}
+
+func TestFunctions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: "func(a) { let b = a; b }",
+			expectedConstants: []any{
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpSetLocal, 1),
+					code.Make(code.OpGetLocal, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "func() { }",
+			expectedConstants: []any{
+				[]code.Instructions{
+					code.Make(code.OpReturn),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			input: "func(a) { func(b) { a + b } }",
+			expectedConstants: []any{
+				[]code.Instructions{
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpLoadCell, 0),
+					code.Make(code.OpClosure, 0, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 1, 0),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+	runCompilerTests(t, tests)
+}
+
+func TestTailCalls(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: "func(f) { if (true) { f() } else { [f()] } }",
+			expectedConstants: []any{
+				[]code.Instructions{
+					code.Make(code.OpTrue),
+					code.Make(code.OpJumpNotTruthy, 11),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpTailCall, 0),
+					code.Make(code.OpJump, 18),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpCall, 0),
+					code.Make(code.OpArray, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			// the main program has no frame to hand over
+			input:             "return f();",
+			expectedConstants: []any{},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpCall, 0),
+				code.Make(code.OpReturnValue),
+			},
+		},
+	}
+	runCompilerTests(t, tests)
+}
+
+func TestCapturedLocalsLiveInCells(t *testing.T) {
+	root := parse("func(a, b) { let get = func() { b + c }; let c = 1; get() }")
+
+	cmp := New()
+	if err := cmp.Compile(root); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+	constants := cmp.Bytecode().Constants
+	fn := constants[len(constants)-1].(*object.CompiledFunction)
+
+	if fn.NumParameters != 2 || fn.NumLocals != 4 {
+		t.Fatalf("wrong slots. parameters=%d, locals=%d", fn.NumParameters, fn.NumLocals)
+	}
+	wantCells := []int{1, 3} // b and c
+	if len(fn.Cells) != len(wantCells) || fn.Cells[0] != wantCells[0] || fn.Cells[1] != wantCells[1] {
+		t.Errorf("wrong cells. want=%v, got=%v", wantCells, fn.Cells)
+	}
+}
+
+func TestSymbolResolutionFollowsTheEvaluator(t *testing.T) {
+	// the first x is read before the local x is bound, so it is the global
+	tests := []compilerTestCase{
+		{
+			input: "let x = 1; func() { let y = x; let x = 2; x + y };",
+			expectedConstants: []any{
+				1,
+				2,
+				[]code.Instructions{
+					code.Make(code.OpGetGlobal, 0),
+					code.Make(code.OpSetLocal, 0),
+					code.Make(code.OpConstant, 1),
+					code.Make(code.OpSetLocal, 1),
+					code.Make(code.OpGetLocal, 1),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpClosure, 2, 0),
+				code.Make(code.OpPop),
+			},
+		},
+	}
+	runCompilerTests(t, tests)
+}
+
+func TestUnsupportedFeatures(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let f = func() { throw "x" };`, "1:18: throw not supported by the vm engine"},
+		{`let f = func() { ok(1)? };`, "1:18: ? not supported by the vm engine"},
+		{`let f = func() { defer g(); };`, "1:18: defer not supported by the vm engine"},
+		{`import "lib.fl" as lib;`, "1:1: import not supported by the vm engine"},
+		{`let f = func(x) { x.y };`, "1:19: . not supported by the vm engine"},
+		{`struct Point { x, y }`, "1:1: struct not supported by the vm engine"},
+		{`class Dog {}`, "1:1: class not supported by the vm engine"},
+		{`let f = func(x, y) { x instanceof y };`, "1:22: instanceof not supported by the vm engine"},
+		{`enum Color { Red, Green }`, "1:1: enum not supported by the vm engine"},
+		{`let [a, b] = [1, 2];`, "1:5: [ not supported by the vm engine"},
+		{`let f = func(x, {"k": k}) { k };`, "1:17: { not supported by the vm engine"},
+		{`let f = func(x) { match (x) { _ => 1 } };`, "1:19: match not supported by the vm engine"},
+		{"1; try { 2 } catch (e) { 3 };", "1:4: try not supported by the vm engine"},
+	}
+	for _, tt := range tests {
+		err := New().Compile(parse(tt.input))
+		if !errors.Is(err, ErrUnsupported) {
+			t.Errorf("error for %q does not wrap ErrUnsupported. got=%v", tt.input, err)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func parse(input string) *ast.RootStatement {
+	return parser.NewParser(lexer.NewLexer(input)).ParseRootStatement()
+}
+
+func runCompilerTests(t *testing.T, tests []compilerTestCase) {
+	t.Helper()
+
+	for _, tt := range tests {
+		cmp := New()
+		if err := cmp.Compile(parse(tt.input)); err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+		bytecode := cmp.Bytecode()
+
+		want := concatInstructions(tt.expectedInstructions)
+		if bytecode.Instructions.String() != want.String() {
+			t.Errorf("wrong instructions for %q.\nwant=%s\ngot=%s", tt.input, want, bytecode.Instructions)
+		}
+		testConstants(t, tt.input, tt.expectedConstants, bytecode.Constants)
+	}
+}
+
+func testConstants(t *testing.T, input string, expected []any, actual []object.Object) {
+	t.Helper()
+
+	if len(expected) != len(actual) {
+		t.Errorf("wrong number of constants for %q. want=%d, got=%d", input, len(expected), len(actual))
+		return
+	}
+	for idx, constant := range expected {
+		switch constant := constant.(type) {
+		case int:
+			integer, ok := actual[idx].(*object.Integer)
+			if !ok || integer.Value != int64(constant) {
+				t.Errorf("constant %d of %q is not %d. got=%s", idx, input, constant, actual[idx].Inspect())
+			}
+		case []code.Instructions:
+			fn, ok := actual[idx].(*object.CompiledFunction)
+			if !ok {
+				t.Errorf("constant %d of %q is not a function. got=%T", idx, input, actual[idx])
+				continue
+			}
+			want := concatInstructions(constant)
+			if fn.Instructions.String() != want.String() {
+				t.Errorf("wrong instructions of constant %d of %q.\nwant=%s\ngot=%s", idx, input, want, fn.Instructions)
+			}
+		}
+	}
+}
+
+func concatInstructions(list []code.Instructions) code.Instructions {
+	var out code.Instructions
+	for _, ins := range list {
+		out = append(out, ins...)
+	}
+	return out
+}
@@ -0,0 +1,213 @@
+package compiler
+
+import (
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/object"
+)
+
+type SymbolScope string
+
+const (
+	GlobalScope  SymbolScope = "GLOBAL"
+	LocalScope   SymbolScope = "LOCAL"
+	CellScope    SymbolScope = "CELL" // a local captured by an inner function
+	FreeScope    SymbolScope = "FREE"
+	BuiltInScope SymbolScope = "BUILTIN"
+)
+
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+}
+
+// SymbolTable maps the names of one scope, the program or a function body, to
+// the place their value lives at run time. Lookups follow the evaluator: a
+// direct use only sees the locals declared before it, while the body of an
+// inner function sees every local of the functions enclosing it. Names found
+// nowhere are globals, which may be bound later or never, except for the
+// builtins no program compiled with the table binds.
+type SymbolTable struct {
+	Outer *SymbolTable
+
+	store    map[string]Symbol
+	declared map[string]bool // locals whose declaration has been compiled
+	names    []string        // name of each local or global, by index
+	bound    map[string]bool // of the global table: names bound anywhere, which hide builtins
+
+	FreeSymbols []Symbol // the captured symbols, as seen from the outer table
+	free        map[string]Symbol
+}
+
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{store: make(map[string]Symbol), bound: make(map[string]bool)}
+}
+
+// bindNames records the names of the lets and parameters within root. A use
+// of a builtin's name that may refer to one of them is compiled as a global,
+// which the virtual machine starts out holding the builtin.
+func (st *SymbolTable) bindNames(root ast.Node) {
+	ast.Inspect(root, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			if node.Name != nil {
+				st.bound[node.Name.Value] = true
+			}
+		case *ast.FunctionLiteral:
+			for _, param := range node.Parameters {
+				if param != nil {
+					st.bound[param.Value] = true
+				}
+			}
+		}
+		return true
+	})
+}
+
+// NewFunctionSymbolTable creates the table of fn's body. All of its locals,
+// the parameters followed by every name bound by a let outside of inner
+// functions, get a slot up front; the ones inner functions may refer to live
+// in cells.
+func NewFunctionSymbolTable(outer *SymbolTable, fn *ast.FunctionLiteral) *SymbolTable {
+	st := &SymbolTable{
+		Outer:    outer,
+		store:    make(map[string]Symbol),
+		declared: make(map[string]bool),
+		free:     make(map[string]Symbol),
+	}
+	captured := capturedNames(fn)
+
+	add := func(name string) {
+		if _, ok := st.store[name]; ok {
+			return
+		}
+		scope := LocalScope
+		if captured[name] {
+			scope = CellScope
+		}
+		st.store[name] = Symbol{Name: name, Scope: scope, Index: len(st.names)}
+		st.names = append(st.names, name)
+	}
+	for _, param := range fn.Parameters {
+		add(param.Value)
+	}
+	if fn.Body != nil {
+		ast.Inspect(fn.Body, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.LetStatement:
+				if node.Name != nil {
+					add(node.Name.Value)
+				}
+			case *ast.FunctionLiteral:
+				return false
+			}
+			return true
+		})
+	}
+	return st
+}
+
+// capturedNames collects every identifier inside the functions nested in fn.
+// It is a superset of the locals of fn they capture.
+func capturedNames(fn *ast.FunctionLiteral) map[string]bool {
+	names := make(map[string]bool)
+	if fn.Body == nil {
+		return names
+	}
+	ast.Inspect(fn.Body, func(node ast.Node) bool {
+		if inner, ok := node.(*ast.FunctionLiteral); ok {
+			ast.Inspect(inner, func(node ast.Node) bool {
+				if ident, ok := node.(*ast.Identifier); ok {
+					names[ident.Value] = true
+				}
+				return true
+			})
+			return false
+		}
+		return true
+	})
+	return names
+}
+
+func (st *SymbolTable) global() bool { return st.Outer == nil }
+
+// Define declares name in this scope and returns the symbol to bind it to.
+func (st *SymbolTable) Define(name string) Symbol {
+	if st.global() {
+		return st.globalSymbol(name)
+	}
+	st.declared[name] = true
+	return st.store[name]
+}
+
+// Names returns the name of every local, or of every global, by index.
+func (st *SymbolTable) Names() []string {
+	return st.names
+}
+
+// Resolve returns the symbol a use of name in this scope refers to.
+func (st *SymbolTable) Resolve(name string) Symbol {
+	if st.global() {
+		if idx := builtInIndex(name); idx >= 0 && !st.bound[name] {
+			return Symbol{Name: name, Scope: BuiltInScope, Index: idx}
+		}
+		return st.globalSymbol(name)
+	}
+	if st.declared[name] {
+		return st.store[name]
+	}
+	return st.resolveOuter(name)
+}
+
+// resolveOuter resolves name in the scopes enclosing this function, where
+// everything declared is visible.
+func (st *SymbolTable) resolveOuter(name string) Symbol {
+	if sym, ok := st.free[name]; ok {
+		return sym
+	}
+	outer := st.Outer
+	var sym Symbol
+	switch {
+	case outer.global():
+		return outer.Resolve(name)
+	case hasSymbol(outer.store, name):
+		sym = outer.store[name]
+	default:
+		sym = outer.resolveOuter(name)
+		if sym.Scope == GlobalScope || sym.Scope == BuiltInScope {
+			return sym
+		}
+	}
+	return st.defineFree(sym)
+}
+
+func (st *SymbolTable) defineFree(original Symbol) Symbol {
+	st.FreeSymbols = append(st.FreeSymbols, original)
+	sym := Symbol{Name: original.Name, Scope: FreeScope, Index: len(st.FreeSymbols) - 1}
+	st.free[original.Name] = sym
+	return sym
+}
+
+func (st *SymbolTable) globalSymbol(name string) Symbol {
+	if sym, ok := st.store[name]; ok {
+		return sym
+	}
+	sym := Symbol{Name: name, Scope: GlobalScope, Index: len(st.names)}
+	st.store[name] = sym
+	st.names = append(st.names, name)
+	return sym
+}
+
+func hasSymbol(store map[string]Symbol, name string) bool {
+	_, ok := store[name]
+	return ok
+}
+
+func builtInIndex(name string) int {
+	for idx, def := range object.BuiltIns {
+		if def.Name == name {
+			return idx
+		}
+	}
+	return -1
+}
@@ -0,0 +1,164 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/object"
+)
+
+var (
+	// ErrCanceled is wrapped by the error of an evaluation stopped because its
+	// context was done, which includes Options.Timeout running out. The
+	// context's own error is wrapped as well.
+	ErrCanceled = errors.New("evaluation canceled")
+	// ErrStepLimit is wrapped by the error of an evaluation that took more
+	// than Options.MaxSteps steps.
+	ErrStepLimit = errors.New("step limit exceeded")
+	// ErrAllocationLimit is wrapped by the error of an evaluation that
+	// allocated more than Options.MaxObjects objects or Options.MaxBytes
+	// bytes.
+	ErrAllocationLimit = errors.New("allocation limit exceeded")
+)
+
+// checkInterval is the number of steps between two looks at the context.
+const checkInterval = 256
+
+// budget enforces the limits of one EvaluateContext call. Every evaluated
+// node is a step, and the objects made by literals, operators, calls and
+// builtins are counted with an estimate of their size. Builtins are charged
+// a step per element of the arrays and hashes passed to them before they
+// run, so a call is refused up front when its work would exceed the budget.
+type budget struct {
+	done <-chan struct{}
+	ctx  context.Context
+
+	steps, maxSteps     int64
+	objects, maxObjects int64
+	bytes, maxBytes     int64
+
+	err  error         // why the evaluation stopped, once it did
+	halt *object.Error // the result of every evaluation after that
+}
+
+// newBudget returns the budget of an evaluation under ctx and opts, or nil
+// when there is nothing to enforce.
+func newBudget(ctx context.Context, opts Options) *budget {
+	done := ctx.Done()
+	if done == nil && opts.MaxSteps <= 0 && opts.MaxObjects <= 0 && opts.MaxBytes <= 0 {
+		return nil
+	}
+	return &budget{
+		done:       done,
+		ctx:        ctx,
+		maxSteps:   opts.MaxSteps,
+		maxObjects: opts.MaxObjects,
+		maxBytes:   opts.MaxBytes,
+	}
+}
+
+// step charges n steps, returning the halting error when the evaluation
+// has to stop.
+func (b *budget) step(n int64) *object.Error {
+	if b.halt != nil {
+		return b.halt
+	}
+	before := b.steps
+	b.steps += n
+	if b.maxSteps > 0 && b.steps > b.maxSteps {
+		return b.stop(fmt.Errorf("%w (%d steps)", ErrStepLimit, b.maxSteps))
+	}
+	if b.done != nil && (n > 1 || before/checkInterval != b.steps/checkInterval) {
+		select {
+		case <-b.done:
+			return b.stop(fmt.Errorf("%w: %w", ErrCanceled, b.ctx.Err()))
+		default:
+		}
+	}
+	return nil
+}
+
+// allocate charges an object of size bytes.
+func (b *budget) allocate(size int64) *object.Error {
+	if b.halt != nil {
+		return b.halt
+	}
+	b.objects++
+	b.bytes += size
+	if b.maxObjects > 0 && b.objects > b.maxObjects {
+		return b.stop(fmt.Errorf("%w (%d objects)", ErrAllocationLimit, b.maxObjects))
+	}
+	if b.maxBytes > 0 && b.bytes > b.maxBytes {
+		return b.stop(fmt.Errorf("%w (%d bytes)", ErrAllocationLimit, b.maxBytes))
+	}
+	return nil
+}
+
+func (b *budget) stop(err error) *object.Error {
+	b.err = err
+	b.halt = createError(object.ErrorKind, "%s", err)
+	return b.halt
+}
+
+// allocates reports whether evaluating node makes a new object.
+func allocates(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.IntegerLiteral, *ast.StringLiteral, *ast.ArrayLiteral, *ast.HashLiteral,
+		*ast.FunctionLiteral, *ast.PrefixExpression, *ast.InfixExpression:
+		return true
+	}
+	return false
+}
+
+// envSize is the estimated size of the environment of a function call.
+const envSize = 64
+
+// sizeOf estimates the memory held by ob itself, not counting the objects it
+// refers to. Shared objects such as booleans and null are free.
+func sizeOf(ob object.Object) int64 {
+	switch ob := ob.(type) {
+	case *object.Integer:
+		return 8
+	case *object.String:
+		return 16 + int64(len(ob.Value))
+	case *object.Array:
+		return 24 + 16*int64(len(ob.Elements))
+	case *object.Hash:
+		return 48 + 64*int64(len(ob.Pairs))
+	case *object.Function:
+		return 64
+	case *object.Struct:
+		return 24 + 16*int64(len(ob.Values))
+	case *object.EnumValue:
+		return 24 + 16*int64(len(ob.Values))
+	case *object.Instance:
+		return 48 + 64*int64(len(ob.Fields))
+	}
+	return 0
+}
+
+// chargeResult charges ob as a newly made object. Results of builtins are
+// charged even when they return an object they were given, which keeps the
+// estimate on the safe side.
+func (b *budget) chargeResult(ob object.Object) *object.Error {
+	if size := sizeOf(ob); size > 0 {
+		return b.allocate(size)
+	}
+	return nil
+}
+
+// chargeBuiltInArgs charges a builtin for the elements of its arguments.
+func (b *budget) chargeBuiltInArgs(args []object.Object) *object.Error {
+	var work int64 = 1
+	for _, arg := range args {
+		switch arg := arg.(type) {
+		case *object.Array:
+			work += int64(len(arg.Elements))
+		case *object.Hash:
+			work += int64(len(arg.Pairs))
+		}
+	}
+	return b.step(work)
+}
@@ -3,46 +3,235 @@ package evaluator
 import (
 	"Interpreter_in_Go/ast"
 	"Interpreter_in_Go/object"
+	"Interpreter_in_Go/token"
+	"context"
 	"fmt"
+	"io"
+	"time"
 )
 
 var (
-	NULL  = &object.Null{}
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
+	NULL  = object.NULL
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
 )
 
+// DefaultMaxCallDepth is the call depth limit of evaluators whose Options
+// leave it unset.
+const DefaultMaxCallDepth = 10000
+
+// Options configure an Evaluator.
+type Options struct {
+	// MaxCallDepth bounds the number of function calls in progress at once,
+	// tail calls excepted. Zero means DefaultMaxCallDepth.
+	MaxCallDepth int
+
+	// The limits below are enforced by EvaluateContext only; zero means no
+	// limit.
+
+	// MaxSteps bounds the number of evaluation steps: every node evaluated,
+	// and every array element or hash pair handed to a builtin.
+	MaxSteps int64
+	// Timeout bounds the wall-clock time of the evaluation.
+	Timeout time.Duration
+	// MaxObjects bounds the number of objects allocated.
+	MaxObjects int64
+	// MaxBytes bounds the estimated size of the objects allocated.
+	MaxBytes int64
+
+	// Loader loads the modules imported by the evaluated programs. Without
+	// one, each Evaluator has its own, searching the working directory.
+	Loader *Loader
+	// Output is where puts writes, os.Stdout when nil.
+	Output io.Writer
+	// BuiltIns are made available to the evaluated programs and the modules
+	// they import, in addition to the standard builtins; they replace the
+	// standard builtin of the same name, if any.
+	BuiltIns map[string]*object.BuiltIn
+}
+
+// Evaluator evaluates syntax trees, keeping track of the function calls in
+// progress. It is not safe for concurrent use.
+type Evaluator struct {
+	opts     Options
+	builtIns map[string]*object.BuiltIn
+	frames   []object.Frame
+	deferred [][]*deferredCall // of each frame, in the order they were deferred
+	budget   *budget           // of the EvaluateContext call in progress, if limited
+}
+
+func New(opts Options) *Evaluator {
+	if opts.MaxCallDepth <= 0 {
+		opts.MaxCallDepth = DefaultMaxCallDepth
+	}
+	if opts.Loader == nil {
+		opts.Loader = NewLoader(".")
+	}
+	return &Evaluator{opts: opts, builtIns: builtInsFor(opts)}
+}
+
+// Evaluate evaluates node in env with a new Evaluator using the default
+// Options.
 func Evaluate(node ast.Node, env *object.Environment) object.Object {
+	return New(Options{}).Evaluate(node, env)
+}
+
+// EvaluateContext evaluates node in env with a new Evaluator using opts. See
+// Evaluator.EvaluateContext.
+func EvaluateContext(ctx context.Context, node ast.Node, env *object.Environment, opts Options) (object.Object, error) {
+	return New(opts).EvaluateContext(ctx, node, env)
+}
+
+// EvaluateContext evaluates node in env like Evaluate, but stops once ctx is
+// done or a limit of the evaluator's Options is exceeded. The evaluation
+// then returns a nil object and an error wrapping ErrCanceled, ErrStepLimit
+// or ErrAllocationLimit. Errors of the program itself are returned as error
+// objects, as by Evaluate.
+func (ev *Evaluator) EvaluateContext(ctx context.Context, node ast.Node, env *object.Environment) (object.Object, error) {
+	return ev.limit(ctx, func() object.Object { return ev.Evaluate(node, env) })
+}
+
+// CallContext calls fn, a function or builtin, with args under the same
+// limits as EvaluateContext. It lets hosts call back into functions defined
+// by programs they evaluated.
+func (ev *Evaluator) CallContext(ctx context.Context, fn object.Object, args ...object.Object) (object.Object, error) {
+	return ev.limit(ctx, func() object.Object { return ev.applyFunction(fn, args, token.Position{}) })
+}
+
+// limit runs eval under ctx and the limits of the evaluator's Options.
+func (ev *Evaluator) limit(ctx context.Context, eval func() object.Object) (object.Object, error) {
+	if ev.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ev.opts.Timeout)
+		defer cancel()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCanceled, err)
+	}
+	ev.budget = newBudget(ctx, ev.opts)
+	defer func() { ev.budget = nil }()
+
+	result := eval()
+	if ev.budget != nil && ev.budget.err != nil {
+		return nil, ev.budget.err
+	}
+	return result, nil
+}
+
+func (ev *Evaluator) Evaluate(node ast.Node, env *object.Environment) object.Object {
+	if ev.budget != nil {
+		if halt := ev.budget.step(1); halt != nil {
+			return halt
+		}
+	}
+	result := ev.eval(node, env)
+	switch result := result.(type) {
+	case *object.Error:
+		ev.locate(result, node)
+	default:
+		if ev.budget != nil && allocates(node) {
+			if halt := ev.budget.chargeResult(result); halt != nil {
+				return halt
+			}
+		}
+	}
+	return result
+}
+
+func (ev *Evaluator) eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.RootStatement:
-		return evalRootStatement(node, env)
+		return ev.evalRootStatement(node, env)
 	case *ast.LetStatement:
-		value := Evaluate(node.Value, env)
-		if isError(value) {
+		value := ev.Evaluate(node.Value, env)
+		if isAbrupt(value) {
 			return value
 		}
-		env.Set(node.Name.Value, value)
+		if node.Pattern != nil {
+			if errOb := ev.destructure(node.Pattern, value, env); errOb != nil {
+				return errOb
+			}
+			break
+		}
+		if _, ok := node.Value.(*ast.FunctionLiteral); ok {
+			value.(*object.Function).Name = node.Name.Value
+		}
+		bindValue(node.Name, value, env)
 	case *ast.ExpressionStatement:
-		return Evaluate(node.Expression, env)
+		return ev.Evaluate(node.Expression, env)
+	case *ast.AssignStatement:
+		return ev.evalAssignStatement(node, env)
 	case *ast.ReturnStatement:
-		reVal := Evaluate(node.ReturnValue, env)
-		if isError(reVal) {
+		reVal := ev.Evaluate(node.ReturnValue, env)
+		if isAbrupt(reVal) {
 			return reVal
 		}
 		return &object.Return{Value: reVal}
+	case *ast.ThrowStatement:
+		value := ev.Evaluate(node.Value, env)
+		if isAbrupt(value) {
+			return value
+		}
+		return throw(value)
+	case *ast.DeferStatement:
+		fn := ev.evalCallee(node.Call.Function, env)
+		if isAbrupt(fn) {
+			return fn
+		}
+		args := ev.evalListExpression(node.Call.Arguments, env)
+		if len(args) == 1 && isAbrupt(args[0]) {
+			return args[0]
+		}
+		if len(ev.deferred) == 0 {
+			return createError(object.ErrorKind, "defer outside of a function")
+		}
+		top := len(ev.deferred) - 1
+		ev.deferred[top] = append(ev.deferred[top], &deferredCall{fn: fn, args: args, node: node.Call})
+	case *ast.ImportStatement:
+		return ev.evalImportStatement(node, env)
+	case *ast.ExportStatement:
+		return ev.Evaluate(node.Let, env)
+	case *ast.StructStatement:
+		bindValue(node.Name, object.NewStructType(node.Name.Value, node.Fields), env)
+	case *ast.EnumStatement:
+		evalEnumStatement(node, env)
+	case *ast.ClassStatement:
+		class := &object.Class{Name: node.Name.Value, Methods: make(map[string]*object.Function)}
+		if node.Super != nil {
+			super := ev.Evaluate(node.Super, env)
+			if isAbrupt(super) {
+				return super
+			}
+			superClass, ok := super.(*object.Class)
+			if !ok {
+				return createError(object.TypeErrorKind, "class %s cannot extend %s", class.Name, super.Type())
+			}
+			class.Super = superClass
+		}
+		for _, method := range node.Methods {
+			class.Methods[method.Name] = &object.Function{
+				Name:       class.Name + "." + method.Name,
+				Parameters: method.Function.Parameters,
+				Patterns:   method.Function.Patterns,
+				Env:        env,
+				Body:       method.Function.Body,
+				Locals:     method.Function.Locals,
+			}
+		}
+		bindValue(node.Name, class, env)
 	case *ast.CallExpression:
-		fn := Evaluate(node.Function, env)
-		if isError(fn) {
+		fn := ev.evalCallee(node.Function, env)
+		if isAbrupt(fn) {
 			return fn
 		}
-		args := evalListExpression(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
+		args := ev.evalListExpression(node.Arguments, env)
+		if len(args) == 1 && isAbrupt(args[0]) {
 			return args[0]
 		}
-		return applyFunction(fn, args)
+		return ev.applyFunction(fn, args, node.Pos())
 
 	case *ast.Identifier:
-		return evalIdentifier(node, env)
+		return ev.evalIdentifier(node, env)
 
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
@@ -51,58 +240,74 @@ func Evaluate(node ast.Node, env *object.Environment) object.Object {
 	case *ast.Boolean:
 		return boolNativeToBoolObject(node.Value)
 	case *ast.ArrayLiteral:
-		values := evalListExpression(node.Elements, env)
-		if len(values) == 1 && isError(values[0]) {
+		values := ev.evalListExpression(node.Elements, env)
+		if len(values) == 1 && isAbrupt(values[0]) {
 			return values[0]
 		}
 		return &object.Array{Elements: values}
 	case *ast.HashLiteral:
-		return evalHashLiteral(node, env)
+		return ev.evalHashLiteral(node, env)
 
 	case *ast.PrefixExpression:
-		right := Evaluate(node.Right, env)
-		if isError(right) {
+		right := ev.Evaluate(node.Right, env)
+		if isAbrupt(right) {
 			return right
 		}
 		return evalPrefixExpression(node.Operator, right)
+	case *ast.PostfixExpression:
+		left := ev.Evaluate(node.Left, env)
+		if isAbrupt(left) {
+			return left
+		}
+		return evalUnwrapExpression(left)
 	case *ast.InfixExpression:
-		lt := Evaluate(node.Left, env)
-		if isError(lt) {
+		lt := ev.Evaluate(node.Left, env)
+		if isAbrupt(lt) {
 			return lt
 		}
-		rt := Evaluate(node.Right, env)
-		if isError(rt) {
+		rt := ev.Evaluate(node.Right, env)
+		if isAbrupt(rt) {
 			return rt
 		}
 		return evalInfixExpression(node.Operator, lt, rt)
 	case *ast.IndexExpression:
-		lt := Evaluate(node.Left, env)
-		if isError(lt) {
+		lt := ev.Evaluate(node.Left, env)
+		if isAbrupt(lt) {
 			return lt
 		}
-		idx := Evaluate(node.Index, env)
-		if isError(idx) {
+		idx := ev.Evaluate(node.Index, env)
+		if isAbrupt(idx) {
 			return idx
 		}
 		return evalIndexExpression(lt, idx)
+	case *ast.MemberExpression:
+		ob := ev.Evaluate(node.Object, env)
+		if isAbrupt(ob) {
+			return ob
+		}
+		return getAttr(ob, node.Property)
 
 	case *ast.BlockStatement:
-		return evalBlockStatement(node, env)
+		return ev.evalBlockStatement(node, env)
 	case *ast.IfExpression:
-		return evalConditionalExpression(node, env)
+		return ev.evalConditionalExpression(node, env)
+	case *ast.TryExpression:
+		return ev.evalTryExpression(node, env)
+	case *ast.MatchExpression:
+		return ev.evalMatchExpression(node, env)
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Body: body, Env: env}
+		return &object.Function{Parameters: params, Patterns: node.Patterns, Body: body, Env: env, Locals: node.Locals}
 	}
 	return nil
 }
 
-func evalRootStatement(root *ast.RootStatement, env *object.Environment) object.Object {
+func (ev *Evaluator) evalRootStatement(root *ast.RootStatement, env *object.Environment) object.Object {
 	var result object.Object
 
 	for _, stmt := range root.Statements {
-		result = Evaluate(stmt, env)
+		result = ev.Evaluate(stmt, env)
 
 		switch result := result.(type) {
 		case *object.Error:
@@ -114,11 +319,11 @@ func evalRootStatement(root *ast.RootStatement, env *object.Environment) object.
 	return result
 }
 
-func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+func (ev *Evaluator) evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
 	var result object.Object
 
 	for _, stmt := range block.Statements {
-		result = Evaluate(stmt, env)
+		result = ev.Evaluate(stmt, env)
 
 		if result != nil {
 			rt := result.Type()
@@ -130,12 +335,12 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 	return result
 }
 
-func evalListExpression(args []ast.Expression, env *object.Environment) []object.Object {
+func (ev *Evaluator) evalListExpression(args []ast.Expression, env *object.Environment) []object.Object {
 	var result []object.Object
 
 	for _, arg := range args {
-		value := Evaluate(arg, env)
-		if isError(value) {
+		value := ev.Evaluate(arg, env)
+		if isAbrupt(value) {
 			return []object.Object{value}
 		}
 		result = append(result, value)
@@ -149,8 +354,19 @@ func evalIndexExpression(lt, idx object.Object) object.Object {
 		return evalArrayIndexExpression(lt, idx)
 	case lt.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(lt, idx)
+	case lt.Type() == object.EXCEPTION_OBJ && idx.Type() == object.STRING_OBJ:
+		if field, ok := lt.(*object.Exception).Field(idx.(*object.String).Value); ok {
+			return field
+		}
+		return NULL
+	case lt.Type() == object.MODULE_OBJ && idx.Type() == object.STRING_OBJ:
+		module, name := lt.(*object.Module), idx.(*object.String).Value
+		if value, ok := module.Exports[name]; ok {
+			return value
+		}
+		return createError(object.NameErrorKind, "module %q has no export %s", module.Name, name)
 	default:
-		return createError("index operator not supported: %s", lt.Type())
+		return createError(object.TypeErrorKind, "index operator not supported: %s", lt.Type())
 	}
 }
 
@@ -170,7 +386,7 @@ func evalHashIndexExpression(hash, idx object.Object) object.Object {
 
 	key, ok := idx.(object.Hashable)
 	if !ok {
-		return createError("unusable as hash key: %s", idx.Type())
+		return createError(object.TypeErrorKind, "unusable as hash key: %s", idx.Type())
 	}
 	pair, ok := hashOb.Pairs[key.HashKey()]
 	if !ok {
@@ -179,20 +395,20 @@ func evalHashIndexExpression(hash, idx object.Object) object.Object {
 	return pair.Value
 }
 
-func evalHashLiteral(hash *ast.HashLiteral, env *object.Environment) object.Object {
+func (ev *Evaluator) evalHashLiteral(hash *ast.HashLiteral, env *object.Environment) object.Object {
 	pairs := make(map[object.HashKey]object.HashPair)
 
 	for keyNode, valNode := range hash.Pairs {
-		key := Evaluate(keyNode, env)
-		if isError(key) {
+		key := ev.Evaluate(keyNode, env)
+		if isAbrupt(key) {
 			return key
 		}
 		hashKey, ok := key.(object.Hashable)
 		if !ok {
-			return createError("unusable as hash key: %s", key.Type())
+			return createError(object.TypeErrorKind, "unusable as hash key: %s", key.Type())
 		}
-		value := Evaluate(valNode, env)
-		if isError(value) {
+		value := ev.Evaluate(valNode, env)
+		if isAbrupt(value) {
 			return value
 		}
 		hashed := hashKey.HashKey()
@@ -201,14 +417,35 @@ func evalHashLiteral(hash *ast.HashLiteral, env *object.Environment) object.Obje
 	return &object.Hash{Pairs: pairs}
 }
 
-func evalIdentifier(id *ast.Identifier, env *object.Environment) object.Object {
-	if builtIn, ok := builtIns[id.Value]; ok {
-		return builtIn
+func (ev *Evaluator) evalIdentifier(id *ast.Identifier, env *object.Environment) object.Object {
+	switch id.Binding.Kind {
+	case ast.Local:
+		if val, ok := env.GetSlot(id.Binding.Depth, id.Binding.Slot); ok {
+			return val
+		}
+		// read before its let ran; the resolver made sure there is no
+		// other binding the name could refer to
+		return createError(object.NameErrorKind, "Identifier '%s' not found", id.Value)
+	case ast.BuiltIn:
+		return ev.builtIns[id.Value]
 	}
 	if val, ok := env.Get(id.Value); ok {
 		return val
 	}
-	return createError("Identifier '" + id.Value + "' not found")
+	if builtIn, ok := ev.builtIns[id.Value]; ok {
+		return builtIn
+	}
+	return createError(object.NameErrorKind, "Identifier '%s' not found", id.Value)
+}
+
+// bindValue binds a let name or parameter in env, to its slot when the name
+// was resolved as a local.
+func bindValue(name *ast.Identifier, value object.Object, env *object.Environment) {
+	if name.Binding.Kind == ast.Local {
+		env.SetSlot(name.Binding.Slot, value)
+	} else {
+		env.Set(name.Value, value)
+	}
 }
 
 func evalPrefixExpression(operator string, right object.Object) object.Object {
@@ -218,30 +455,91 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 	case "-":
 		return evalPrefixNegationExpression(right)
 	default:
-		return createError("unknown operator: %s%s", operator, right.Type())
+		return createError(object.TypeErrorKind, "unknown operator: %s%s", operator, right.Type())
 	}
 }
 
 func evalInfixExpression(operator string, left, right object.Object) object.Object {
 	switch {
+	case operator == "instanceof":
+		return evalInstanceOf(left, right)
+
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
 
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(operator, left, right)
+
+	case left.Type() == object.STRUCT_OBJ && right.Type() == object.STRUCT_OBJ && (operator == "==" || operator == "!="),
+		left.Type() == object.ENUM_VALUE_OBJ && right.Type() == object.ENUM_VALUE_OBJ && (operator == "==" || operator == "!="):
+		return boolNativeToBoolObject(valuesEqual(left, right, nil) == (operator == "=="))
 	case operator == "==":
 		return boolNativeToBoolObject(left == right)
 	case operator == "!=":
 		return boolNativeToBoolObject(left != right)
 
-	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
-		return evalStringInfixExpression(operator, left, right)
-
 	case left.Type() != right.Type():
-		return createError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+		return createError(object.TypeErrorKind, "type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	default:
-		return createError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return createError(object.TypeErrorKind, "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
+// evalInstanceOf reports whether left is an instance of the class right or
+// of a class extending it, or a struct of the struct type right.
+func evalInstanceOf(left, right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Class:
+		instance, ok := left.(*object.Instance)
+		return boolNativeToBoolObject(ok && instance.Class.Extends(right))
+	case *object.StructType:
+		value, ok := left.(*object.Struct)
+		return boolNativeToBoolObject(ok && value.Def == right)
+	}
+	return createError(object.TypeErrorKind, "right operand of instanceof must be a class or struct, got %s", right.Type())
+}
+
+// valuesEqual reports whether left and right are equal by ==, comparing
+// structs and enum values of the same type field by field. The pairs in
+// comparing are being compared already and are taken to be equal, so values
+// that refer to themselves compare without recursing forever.
+func valuesEqual(left, right object.Object, comparing map[[2]object.Object]bool) bool {
+	if left == right {
+		return true
+	}
+	var leftValues, rightValues []object.Object
+	switch lt := left.(type) {
+	case *object.Struct:
+		rt, ok := right.(*object.Struct)
+		if !ok || lt.Def != rt.Def {
+			return false
+		}
+		leftValues, rightValues = lt.Values, rt.Values
+	case *object.EnumValue:
+		rt, ok := right.(*object.EnumValue)
+		if !ok || lt.Variant != rt.Variant {
+			return false
+		}
+		leftValues, rightValues = lt.Values, rt.Values
+	default:
+		return evalInfixExpression("==", left, right) == TRUE
+	}
+	pair := [2]object.Object{left, right}
+	if comparing[pair] {
+		return true
+	}
+	if comparing == nil {
+		comparing = make(map[[2]object.Object]bool)
+	}
+	comparing[pair] = true
+	for idx, value := range leftValues {
+		if !valuesEqual(value, rightValues[idx], comparing) {
+			return false
+		}
+	}
+	return true
+}
+
 func evalIntegerInfixExpression(operator string, lt, rt object.Object) object.Object {
 	ltVal := lt.(*object.Integer).Value
 	rtVal := rt.(*object.Integer).Value
@@ -254,6 +552,9 @@ func evalIntegerInfixExpression(operator string, lt, rt object.Object) object.Ob
 	case "*":
 		return &object.Integer{Value: ltVal * rtVal}
 	case "/":
+		if rtVal == 0 {
+			return createError(object.ZeroDivisionErrorKind, "division by zero")
+		}
 		return &object.Integer{Value: ltVal / rtVal}
 
 	case "<":
@@ -265,7 +566,7 @@ func evalIntegerInfixExpression(operator string, lt, rt object.Object) object.Ob
 	case "!=":
 		return boolNativeToBoolObject(ltVal != rtVal)
 	default:
-		return createError("unknown operator: %s %s %s", lt.Type(), operator, rt.Type())
+		return createError(object.TypeErrorKind, "unknown operator: %s %s %s", lt.Type(), operator, rt.Type())
 	}
 }
 
@@ -281,27 +582,86 @@ func evalStringInfixExpression(operator string, lt, rt object.Object) object.Obj
 	case "!=":
 		return boolNativeToBoolObject(ltVal != rtVal)
 	default:
-		return createError("unknown operator: %s %s %s", lt.Type(), operator, rt.Type())
+		return createError(object.TypeErrorKind, "unknown operator: %s %s %s", lt.Type(), operator, rt.Type())
 	}
 }
 
-func evalConditionalExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
-	condition := Evaluate(ie.Condition, env)
-	if isError(condition) {
+func (ev *Evaluator) evalConditionalExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
+	condition := ev.Evaluate(ie.Condition, env)
+	if isAbrupt(condition) {
 		return condition
 	}
 	if isTruthy(condition) {
-		return Evaluate(ie.Consequence, env)
+		return ev.Evaluate(ie.Consequence, env)
 	} else if ie.Alternative != nil {
-		return Evaluate(ie.Alternative, env)
+		return ev.Evaluate(ie.Alternative, env)
 	} else {
 		return NULL
 	}
 }
 
+// throw raises value: the error of an exception, or an error with a string
+// as its message.
+func throw(value object.Object) object.Object {
+	switch value := value.(type) {
+	case *object.Exception:
+		return value.Err
+	case *object.String:
+		return createError(object.ErrorKind, "%s", value.Value)
+	default:
+		return createError(object.TypeErrorKind, "cannot throw %s, want EXCEPTION or STRING", value.Type())
+	}
+}
+
+// evalTryExpression evaluates the body of a try expression, then its catch
+// block if the body raised an error, then its finally block. The value is
+// that of the body or the catch block, unless the finally block raises an
+// error or returns, which takes precedence. The catch block runs in an
+// environment of its own, holding the error and the names it declares.
+func (ev *Evaluator) evalTryExpression(te *ast.TryExpression, env *object.Environment) object.Object {
+	result := ev.Evaluate(te.Body, env)
+
+	if errOb, ok := result.(*object.Error); ok && te.Catch != nil && ev.catchable(errOb) {
+		catchEnv := object.NewEnclosedEnvironment(env)
+		if te.CatchLocals > 0 {
+			catchEnv = object.NewFunctionEnvironment(env, te.CatchLocals)
+		}
+		if te.Param != nil {
+			bindValue(te.Param, &object.Exception{Err: errOb}, catchEnv)
+		}
+		result = ev.Evaluate(te.Catch, catchEnv)
+	}
+	if te.Finally != nil {
+		final := ev.Evaluate(te.Finally, env)
+		if final != nil && (final.Type() == object.ERROR_OBJ || final.Type() == object.RETURN_VALUE_OBJ) {
+			return final
+		}
+	}
+	return result
+}
+
+// catchable reports whether a try expression may catch errOb: an evaluation
+// stopped by its context or budget cannot be resumed.
+func (ev *Evaluator) catchable(errOb *object.Error) bool {
+	return ev.budget == nil || errOb != ev.budget.halt
+}
+
+// evalUnwrapExpression applies '?' to left: the value of an ok result, or a
+// return of an err result from the enclosing function.
+func evalUnwrapExpression(left object.Object) object.Object {
+	result, ok := left.(*object.Result)
+	if !ok {
+		return createError(object.TypeErrorKind, "operand of ? must be RESULT, got %s", left.Type())
+	}
+	if result.Ok {
+		return result.Value
+	}
+	return &object.Return{Value: result}
+}
+
 func evalPrefixNegationExpression(right object.Object) object.Object {
 	if right.Type() != object.INTEGER_OBJ {
-		return createError("unknown operator: -%s", right.Type())
+		return createError(object.TypeErrorKind, "unknown operator: -%s", right.Type())
 	}
 	value := right.(*object.Integer).Value
 	return &object.Integer{Value: -value}
@@ -341,27 +701,303 @@ func boolNativeToBoolObject(value bool) *object.Boolean {
 	}
 }
 
-func createError(format string, args ...any) *object.Error {
-	return &object.Error{Message: fmt.Sprintf(format, args...)}
+func createError(kind, format string, args ...any) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, args...), Kind: kind}
 }
 
-func isError(ob object.Object) bool {
+// isAbrupt reports whether ob ends the evaluation of the expression that
+// produced it: an error, or the return out of the enclosing function that a
+// '?' on an err result makes in the middle of an expression.
+func isAbrupt(ob object.Object) bool {
 	if ob != nil {
-		return ob.Type() == object.ERROR_OBJ
+		return ob.Type() == object.ERROR_OBJ || ob.Type() == object.RETURN_VALUE_OBJ
 	}
 	return false
 }
 
-func applyFunction(fun object.Object, args []object.Object) object.Object {
-	switch fn := fun.(type) {
-	case *object.Function:
-		evalOb := Evaluate(fn.Body, extendFunctionEnv(fn, args))
-		return unwrapReturnValue(evalOb)
-	case *object.BuiltIn:
-		return fn.Func(args...)
-	default:
-		return createError("unknown function: %s", fn.Type())
+// applyFunction calls fun. Calls in tail position of its body come back as
+// a tailCall and are made here in turn, so tail recursion runs in constant
+// Go stack.
+func (ev *Evaluator) applyFunction(fun object.Object, args []object.Object, pos token.Position) object.Object {
+	if hasBody(fun) {
+		if len(ev.frames) >= ev.opts.MaxCallDepth {
+			return ev.callDepthError(pos)
+		}
+		ev.frames = append(ev.frames, object.Frame{})
+		ev.deferred = append(ev.deferred, nil)
+		defer func() {
+			ev.frames = ev.frames[:len(ev.frames)-1]
+			ev.deferred = ev.deferred[:len(ev.deferred)-1]
+		}()
+	}
+	implicit := 0 // arguments passed to a method besides those of the call
+	for {
+		switch fn := fun.(type) {
+		case *object.Function:
+			// surplus arguments are dropped, as the vm does
+			if len(args) < len(fn.Parameters) {
+				return createError(object.ArgumentErrorKind, "wrong number of arguments. got=%d, want=%d", len(args), len(fn.Parameters))
+			}
+			// a tail call takes over the frame of its caller
+			ev.frames[len(ev.frames)-1] = object.Frame{Function: fn.Name, Pos: pos, Args: len(args) - implicit}
+			implicit = 0
+			if ev.budget != nil {
+				if halt := ev.budget.allocate(envSize); halt != nil {
+					return halt
+				}
+			}
+			fnEnv, errOb := ev.extendFunctionEnv(fn, args)
+			if errOb != nil {
+				return errOb
+			}
+			evalOb := ev.evalFunctionBody(fn.Body, fnEnv, true)
+			if call, ok := evalOb.(*tailCall); ok {
+				if len(ev.deferred[len(ev.deferred)-1]) == 0 {
+					fun, args, pos = call.fn, call.args, call.pos
+					continue
+				}
+				// the deferred calls wait for the result, so the frame stays
+				evalOb = ev.applyFunction(call.fn, call.args, call.pos)
+			}
+			return ev.runDeferred(unwrapReturnValue(evalOb))
+		case *object.BoundMethod:
+			if want := len(fn.Method.Parameters) - len(ast.SelfParameters); len(args) != want {
+				return createError(object.ArgumentErrorKind, "wrong number of arguments. got=%d, want=%d", len(args), want)
+			}
+			fun, args, implicit = fn.Method, fn.Args(args), len(ast.SelfParameters)
+		case *object.Class:
+			return ev.instantiate(fn, args, pos)
+		case constructor:
+			result := fn.New(args...)
+			if ev.budget != nil {
+				if halt := ev.budget.chargeResult(result); halt != nil {
+					return halt
+				}
+			}
+			return result
+		case *object.BuiltIn:
+			if ev.budget == nil {
+				return fn.Func(args...)
+			}
+			if halt := ev.budget.chargeBuiltInArgs(args); halt != nil {
+				return halt
+			}
+			result := fn.Func(args...)
+			if halt := ev.budget.chargeResult(result); halt != nil {
+				return halt
+			}
+			return result
+		default:
+			return createError(object.TypeErrorKind, "unknown function: %s", fn.Type())
+		}
+	}
+}
+
+// constructor is implemented by the types whose calls make a value of
+// them, such as struct types and enum variants.
+type constructor interface {
+	New(values ...object.Object) object.Object
+}
+
+// hasBody reports whether fn is a function or method written in Flint,
+// whose calls take a frame and can be made as tail calls.
+func hasBody(fn object.Object) bool {
+	switch fn.(type) {
+	case *object.Function, *object.BoundMethod:
+		return true
+	}
+	return false
+}
+
+// Callable reports whether fn can be called: a function, method, builtin,
+// class or constructor.
+func Callable(fn object.Object) bool {
+	switch fn.(type) {
+	case *object.Function, *object.BoundMethod, *object.BuiltIn, *object.Class, constructor:
+		return true
+	}
+	return false
+}
+
+// instantiate makes an instance of class, calling its init method with
+// args. A class without init takes no arguments.
+func (ev *Evaluator) instantiate(class *object.Class, args []object.Object, pos token.Position) object.Object {
+	instance := object.NewInstance(class)
+	if ev.budget != nil {
+		if halt := ev.budget.chargeResult(instance); halt != nil {
+			return halt
+		}
+	}
+	init, defining, ok := class.Method("init")
+	if !ok {
+		if len(args) != 0 {
+			return createError(object.ArgumentErrorKind, "wrong number of arguments. got=%d, want=0", len(args))
+		}
+		return instance
+	}
+	result := ev.applyFunction(&object.BoundMethod{Self: instance, Method: init, Class: defining}, args, pos)
+	if errOb, ok := result.(*object.Error); ok {
+		return errOb
+	}
+	return instance
+}
+
+// deferredCall is a call scheduled by a defer statement, with its function
+// and arguments evaluated when it was deferred.
+type deferredCall struct {
+	fn   object.Object
+	args []object.Object
+	node *ast.CallExpression
+}
+
+// runDeferred makes the deferred calls of the innermost frame, last deferred
+// first, once its function has finished with result. The calls are made even
+// when result is an error; an error raised by one of them takes precedence
+// over result, but the remaining calls are still made.
+func (ev *Evaluator) runDeferred(result object.Object) object.Object {
+	top := len(ev.deferred) - 1
+	for len(ev.deferred[top]) > 0 {
+		calls := ev.deferred[top]
+		call := calls[len(calls)-1]
+		ev.deferred[top] = calls[:len(calls)-1]
+
+		called := ev.applyFunction(call.fn, call.args, call.node.Pos())
+		if errOb, ok := called.(*object.Error); ok {
+			ev.locate(errOb, call.node)
+			result = errOb
+		}
+	}
+	return result
+}
+
+// tailCall stands for the result of a call in tail position, which
+// applyFunction makes once the calling function has finished.
+type tailCall struct {
+	fn   object.Object
+	args []object.Object
+	pos  token.Position
+}
+
+func (tc *tailCall) Type() object.ObjectType { return "TAIL_CALL" }
+
+func (tc *tailCall) Inspect() string { return "tail call" }
+
+// evalFunctionBody evaluates a block of a function body like
+// evalBlockStatement, except that the calls made by return statements, and
+// by its final expression when the block's value is the function's, are
+// tail calls. Blocks of if statements are followed; a tailCall propagates
+// out of them like a return value.
+func (ev *Evaluator) evalFunctionBody(block *ast.BlockStatement, env *object.Environment, valueIsResult bool) object.Object {
+	var result object.Object
+
+	for idx, stmt := range block.Statements {
+		final := valueIsResult && idx == len(block.Statements)-1
+
+		switch stmt := stmt.(type) {
+		case *ast.ReturnStatement:
+			result = ev.evalTailExpression(stmt.ReturnValue, env, true)
+			if !isAbrupt(result) && !isTailCall(result) {
+				result = &object.Return{Value: result}
+			}
+		case *ast.ExpressionStatement:
+			result = ev.evalTailExpression(stmt.Expression, env, final)
+		default:
+			result = ev.Evaluate(stmt, env)
+		}
+		if result != nil {
+			rt := result.Type()
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ || isTailCall(result) {
+				return result
+			}
+		}
+	}
+	if result == nil {
+		// an empty body, or one ending in a statement, has no value
+		return NULL
 	}
+	return result
+}
+
+// evalTailExpression evaluates expr, deferring it as a tailCall when it is a
+// call to a function and inTail is set.
+func (ev *Evaluator) evalTailExpression(expr ast.Expression, env *object.Environment, inTail bool) object.Object {
+	switch expr := expr.(type) {
+	case *ast.IfExpression:
+		condition := ev.Evaluate(expr.Condition, env)
+		if isAbrupt(condition) {
+			return condition
+		}
+		if isTruthy(condition) {
+			return ev.evalFunctionBody(expr.Consequence, env, inTail)
+		} else if expr.Alternative != nil {
+			return ev.evalFunctionBody(expr.Alternative, env, inTail)
+		}
+		return NULL
+	case *ast.MatchExpression:
+		arm, armEnv, abrupt := ev.selectArm(expr, env)
+		if arm == nil {
+			return abrupt
+		}
+		if arm.Body != nil {
+			return ev.evalFunctionBody(arm.Body, armEnv, inTail)
+		}
+		return ev.evalTailExpression(arm.Value, armEnv, inTail)
+	case *ast.CallExpression:
+		if !inTail {
+			break
+		}
+		fn := ev.evalCallee(expr.Function, env)
+		if isAbrupt(fn) {
+			return fn
+		}
+		args := ev.evalListExpression(expr.Arguments, env)
+		if len(args) == 1 && isAbrupt(args[0]) {
+			return args[0]
+		}
+		if !hasBody(fn) {
+			result := ev.applyFunction(fn, args, expr.Pos())
+			if errOb, ok := result.(*object.Error); ok {
+				ev.locate(errOb, expr)
+			}
+			return result
+		}
+		return &tailCall{fn: fn, args: args, pos: expr.Pos()}
+	}
+	return ev.Evaluate(expr, env)
+}
+
+// callDepthError reports the call depth limit being exceeded by the call at
+// pos, with the calls in progress as its stack.
+func (ev *Evaluator) callDepthError(pos token.Position) *object.Error {
+	return &object.Error{
+		Message: fmt.Sprintf("maximum call depth %d exceeded", ev.opts.MaxCallDepth),
+		Kind:    object.RecursionErrorKind,
+		Pos:     pos,
+		Stack:   ev.stack(),
+	}
+}
+
+// locate records where errOb was raised, at node and in the calls in
+// progress, unless it was raised further down and is only passing through.
+func (ev *Evaluator) locate(errOb *object.Error, node ast.Node) {
+	if errOb.Pos == (token.Position{}) {
+		errOb.Pos = node.Pos()
+		errOb.Stack = ev.stack()
+	}
+}
+
+// stack returns the calls in progress, innermost first.
+func (ev *Evaluator) stack() []object.Frame {
+	stack := make([]object.Frame, len(ev.frames))
+	for idx, fr := range ev.frames {
+		stack[len(stack)-1-idx] = fr
+	}
+	return stack
+}
+
+func isTailCall(ob object.Object) bool {
+	_, ok := ob.(*tailCall)
+	return ok
 }
 
 func unwrapReturnValue(ob object.Object) object.Object {
@@ -371,11 +1007,20 @@ func unwrapReturnValue(ob object.Object) object.Object {
 	return ob
 }
 
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+func (ev *Evaluator) extendFunctionEnv(fn *object.Function, args []object.Object) (*object.Environment, *object.Error) {
 	env := object.NewEnclosedEnvironment(fn.Env)
-
+	if fn.Locals > 0 {
+		env = object.NewFunctionEnvironment(fn.Env, fn.Locals)
+	}
 	for pIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[pIdx]) // binds args to param names with the help of param-index
+		if pIdx < len(fn.Patterns) && fn.Patterns[pIdx] != nil {
+			if errOb := ev.destructure(fn.Patterns[pIdx], args[pIdx], env); errOb != nil {
+				ev.locate(errOb, fn.Patterns[pIdx])
+				return nil, errOb
+			}
+			continue
+		}
+		bindValue(param, args[pIdx], env) // binds args to params with the help of param-index
 	}
-	return env
+	return env, nil
 }
@@ -1,10 +1,21 @@
 package evaluator
 
 import (
+	"Interpreter_in_Go/compiler"
 	"Interpreter_in_Go/lexer"
 	"Interpreter_in_Go/object"
 	"Interpreter_in_Go/parser"
+	"Interpreter_in_Go/token"
+	"Interpreter_in_Go/vm"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime/debug"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -29,7 +40,7 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
 	}
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testIntegerObject(t, evaluated, tt.expected)
 	}
 }
@@ -37,7 +48,7 @@ func TestEvalIntegerExpression(t *testing.T) {
 func TestEvalStringLiteral(t *testing.T) {
 	input := `"Hello World!"`
 
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	str, ok := evaluated.(*object.String)
 	if !ok {
 		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
@@ -50,7 +61,7 @@ func TestEvalStringLiteral(t *testing.T) {
 func TestStringConcatenation(t *testing.T) {
 	input := `"Hello" + " " + "World!"`
 
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	str, ok := evaluated.(*object.String)
 	if !ok {
 		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
@@ -87,7 +98,7 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"(1 > 2) == false", true},
 	}
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testBooleanObject(t, evaluated, tt.expected)
 	}
 }
@@ -105,7 +116,7 @@ func TestBangOperator(t *testing.T) {
 		{"!!5", true},
 	}
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testBooleanObject(t, evaluated, tt.expected)
 	}
 }
@@ -124,7 +135,7 @@ func TestIfElseExpressions(t *testing.T) {
 		{"if (1 < 2) { 10 } else { 10 }", 10},
 	}
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		integer, ok := tt.expected.(int)
 		if ok {
 			testIntegerObject(t, evaluated, int64(integer))
@@ -153,7 +164,7 @@ func TestReturnStatements(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testIntegerObject(t, evaluated, tt.expected)
 	}
 }
@@ -206,9 +217,17 @@ func TestErrorHandling(t *testing.T) {
 			`{"name": "Monkey"}[func(x) { x }];`,
 			`unusable as hash key: FUNCTION`,
 		},
+		{
+			"let f = func(a, b) { a }; f(1)",
+			"wrong number of arguments. got=1, want=2",
+		},
+		{
+			"let f = func(a, b) { a }; let g = func() { f(1) + 1 }; g()",
+			"wrong number of arguments. got=1, want=2",
+		},
 	}
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		errObj, ok := evaluated.(*object.Error)
 		if !ok {
 			t.Errorf("no error object returned. got=%T (%+v)", evaluated, evaluated)
@@ -231,13 +250,13 @@ func TestLetStatements(t *testing.T) {
 		{"let a = 5; let b = a; let c = a + b + 5; c;", 15},
 	}
 	for _, tt := range tests {
-		testIntegerObject(t, testEval(tt.input), tt.expected)
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
 	}
 }
 
 func TestFunctionObject(t *testing.T) {
 	input := "func(x) { x + 2; };"
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 
 	fn, ok := evaluated.(*object.Function)
 	if !ok {
@@ -266,9 +285,21 @@ func TestFunctionApplication(t *testing.T) {
 		{"let add = func(x, y) { x + y; }; add(5, 5);", 10},
 		{"let add = func(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20},
 		{"func(x) { x; }(5)", 5},
+		{"let pick = func(x) { x; }; pick(5, 6);", 5},
 	}
 	for _, tt := range tests {
-		testIntegerObject(t, testEval(tt.input), tt.expected)
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestFunctionWithoutValue(t *testing.T) {
+	tests := []string{
+		"let f = func() { }; f();",
+		"let f = func() { let x = 1; }; f();",
+		"let f = func() { }; let g = func() { f() }; [g()][0];",
+	}
+	for _, input := range tests {
+		testNullObject(t, testEval(t, input))
 	}
 }
 
@@ -285,7 +316,7 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 
 		switch expected := tt.expected.(type) {
 		case int:
@@ -314,13 +345,764 @@ let newAdder = func(x) {
 let addTwo = newAdder(2);
 addTwo(2);
 `
-	testIntegerObject(t, testEval(input), 4)
+	testIntegerObject(t, testEval(t, input), 4)
+}
+
+func TestClosuresSeeLaterBindings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`
+let countDown = func(x) { if (x == 0) { return 0; } countDown(x - 1) };
+countDown(5);`, 0},
+		{`
+let isEven = func(n) { if (n == 0) { true } else { isOdd(n - 1) } };
+let isOdd = func(n) { if (n == 0) { false } else { isEven(n - 1) } };
+if (isEven(10)) { 1 } else { 0 }`, 1},
+		{`
+let wrapper = func() {
+	let countDown = func(x) { if (x == 0) { return 0; } countDown(x - 1) };
+	countDown(3) + 7
+};
+wrapper();`, 7},
+		{`
+let outer = func() {
+	let get = func() { n };
+	let n = 5;
+	get()
+};
+outer();`, 5},
+		{`
+let counter = func(start) {
+	let inner = func() { func() { start } };
+	inner()()
+};
+counter(9);`, 9},
+		{`
+let x = 10;
+let f = func() { let y = x; let x = 1; x + y };
+f();`, 11},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestTailCallsRunInConstantStack(t *testing.T) {
+	// deep enough to overflow this stack limit without tail calls
+	defer debug.SetMaxStack(debug.SetMaxStack(8 << 20))
+
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`
+let count = func(n, acc) { if (n == 0) { return acc; } count(n - 1, acc + 1) };
+count(100000, 0);`, 100000},
+		{`
+let sum = func(n, acc) { if (n == 0) { acc } else { return sum(n - 1, acc + n); } };
+sum(100000, 0);`, 5000050000},
+		{`
+let isEven = func(n) { if (n == 0) { return 1; } isOdd(n - 1) };
+let isOdd = func(n) { if (n == 0) { return 0; } isEven(n - 1) };
+isEven(100001);`, 0},
+		{`
+let loop = func(n) { if (n > 0) { let next = n - 1; return loop(next); } len([n]) };
+loop(100000);`, 1},
+	}
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestCallDepthLimit(t *testing.T) {
+	input := `let deep = func(n) { if (n == 0) { 0 } else { 1 + deep(n - 1) } };
+deep(20000);`
+	evaluated := testEval(t, input)
+
+	errOb, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+	}
+	if errOb.Message != "maximum call depth 10000 exceeded" || errOb.Kind != object.RecursionErrorKind {
+		t.Errorf("wrong error. got=%s (%s)", errOb.Message, errOb.Kind)
+	}
+	expected := errOb.Inspect() + " (1:51)" + strings.Repeat("\n\tat deep (1:51) with 1 argument", 20) +
+		"\n\t... 9980 more"
+	if errOb.Traceback() != expected {
+		t.Errorf("wrong traceback. expected=%q, got=%q", expected, errOb.Traceback())
+	}
+
+	// below the limit, and tail calls do not count
+	testIntegerObject(t, testEval(t, strings.Replace(input, "20000", "9000", 1)), 9000)
+	testIntegerObject(t, testEval(t, `
+let count = func(n) { if (n == 0) { 0 } else { count(n - 1) } };
+count(20000);`), 0)
+}
+
+func TestConfiguredCallDepth(t *testing.T) {
+	input := `
+let f = func(n) { [g(n)] };
+let g = func(n) { if (n == 0) { 0 } else { [f(n - 1)] } };
+let start = func() { [f(5)] };
+start();`
+	root := parser.NewParser(lexer.NewLexer(input)).ParseRootStatement()
+	evaluated := New(Options{MaxCallDepth: 4}).Evaluate(root, object.NewEnvironment())
+
+	errOb, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T (%+v)", evaluated, evaluated)
+	}
+	expected := errOb.Inspect() + ` (2:20)
+	at f (3:45) with 1 argument
+	at g (2:20) with 1 argument
+	at f (4:23) with 1 argument
+	at start (5:1) with 0 arguments`
+	if errOb.Message != "maximum call depth 4 exceeded" || errOb.Traceback() != expected {
+		t.Errorf("wrong result. expected=%q, got=%q", expected, errOb.Traceback())
+	}
+}
+
+func TestErrorStacks(t *testing.T) {
+	tests := []struct {
+		input string
+		pos   token.Position
+		stack []object.Frame
+	}{
+		{"1 + true", token.Position{Line: 1, Column: 1}, []object.Frame{}},
+		{
+			"let f = func(x) { len(x) };\nlet g = func() { [f(1)] };\ng();",
+			token.Position{Line: 1, Column: 19},
+			[]object.Frame{call("f", 2, 19, 1), call("g", 3, 1, 0)},
+		},
+		{
+			"let f = func(x) { [x + missing] };\n[1, func(a, b) { [f(a)] }(2, 3)];",
+			token.Position{Line: 1, Column: 24},
+			[]object.Frame{call("f", 2, 19, 1), call("", 2, 5, 2)},
+		},
+		{
+			// the tail call to f takes over the frame of g
+			"let f = func(x) { x + true };\nlet g = func() { f(1) };\n[g()];",
+			token.Position{Line: 1, Column: 19},
+			[]object.Frame{call("f", 2, 18, 1)},
+		},
+	}
+	for _, tt := range tests {
+		errOb, ok := testEval(t, tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errOb.Pos != tt.pos {
+			t.Errorf("wrong position for %q. expected=%s, got=%s", tt.input, tt.pos, errOb.Pos)
+		}
+		if !reflect.DeepEqual(errOb.Stack, tt.stack) {
+			t.Errorf("wrong stack for %q. expected=%v, got=%v", tt.input, tt.stack, errOb.Stack)
+		}
+	}
+}
+
+func TestTryCatch(t *testing.T) {
+	// raised is the message of an error that is not caught
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`try { 1 + true } catch (e) { e["message"] }`, "type mismatch: INTEGER + BOOLEAN"},
+		{`try { 1 + true } catch (e) { e["kind"] }`, "TypeError"},
+		{`try { missing } catch (e) { e["kind"] }`, "NameError"},
+		{`try { len(1, 2) } catch (e) { e["kind"] }`, "ArgumentError"},
+		{`let f = func(n) { 1 + f(n) }; try { f(0) } catch (e) { e["kind"] }`, "RecursionError"},
+		{`try { 1 / 0 } catch (e) { e["kind"] + ": " + e["message"] }`, "ZeroDivisionError: division by zero"},
+		{`let d = 0; try { 1 / d } catch (e) { 0 }`, 0},
+		{`try { throw "boom" } catch (e) { e["kind"] + ": " + e["message"] }`, "Error: boom"},
+		{`try { throw error("bad input", "ValueError") } catch (e) { e["kind"] + ": " + e["message"] }`, "ValueError: bad input"},
+		{`try { throw error("x") } catch (e) { e["unknown"] }`, nil},
+		{`try { missing } catch (e) { e.kind + ": " + e.message }`, "NameError: Identifier 'missing' not found"},
+		{`let f = func() { throw "inner" }; try { f() } catch (e) { e.stack[0] }`, "f (1:41) with 0 arguments"},
+		{`let f = func() { throw "inner" }; try { [f()] } catch (e) { e["stack"][0] }`, "f (1:42) with 0 arguments"},
+		{`try { throw "x" } catch { 7 }`, 7},
+		{`let r = try { 5 } catch (e) { 0 }; r`, 5},
+		{`try { 1 } finally { 2 }`, 1},
+		{`try { try { throw "a" } catch (e) { throw e } } catch (e) { e["message"] }`, "a"},
+		{`try { try { throw "a" } finally { throw "b" } } catch (e) { e["message"] }`, "b"},
+		{`let f = func() { try { return 1 } finally { return 2 } }; f()`, 2},
+		{`let f = func() { try { return 1 } catch (e) { 2 }; 3 }; f()`, 1},
+		{`let f = func() { try { throw "x" } catch (e) { return e["message"] }; 3 }; f()`, "x"},
+		{`try { throw "x" } catch (e) { let m = e.message; m }`, "x"},
+		{`let e = 1; let f = func() { try { throw "x" } catch (e) { 2 }; e }; f() + e`, 2},
+		{`try { throw "x" } catch (e) { 1 }; e`, raised{"Identifier 'e' not found", object.NameErrorKind}},
+		{`try { throw "x" } finally { 1 }`, raised{"x", object.ErrorKind}},
+		{`try { throw "x" } catch (e) { e + 1 }`, raised{"type mismatch: EXCEPTION + INTEGER", object.TypeErrorKind}},
+		{`throw 1`, raised{"cannot throw INTEGER, want EXCEPTION or STRING", object.TypeErrorKind}},
+		{`throw error("custom")`, raised{"custom", object.ErrorKind}},
+		{`let div = func(a, b) { a / b }; div(1, 0)`, raised{"division by zero", object.ZeroDivisionErrorKind}},
+	}
+	for _, tt := range tests {
+		checkEvalResult(t, tt.input, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestErrorValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`error("x")`, "Error: x"},
+		{`error("x", "ValueError")`, "ValueError: x"},
+		{`try { [1][true] } catch (e) { e }`, "TypeError: index operator not supported: ARRAY"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if _, ok := evaluated.(*object.Exception); !ok || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%s", tt.input, tt.expected, inspect(evaluated))
+		}
+	}
+}
+
+func TestResults(t *testing.T) {
+	// raised is the message of an error that is not caught
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`ok(1)`, "ok(1)"},
+		{`err("bad")`, "err(bad)"},
+		{`parse_int("42")`, "ok(42)"},
+		{`parse_int("4x2")`, `err(invalid integer "4x2")`},
+		{`[is_ok(ok(1)), is_err(ok(1)), is_ok(err(1)), is_err(err(1))]`, "[true, false, false, true]"},
+		{`unwrap(ok(1))`, "1"},
+		{`unwrap_or(parse_int("x"), 0)`, "0"},
+		{`unwrap_err(err("bad"))`, "bad"},
+		{`ok(2)? * 3`, "6"},
+		{`let f = func(s) { parse_int(s)? + 1 }; f("41")`, "42"},
+		{`let f = func(s) { parse_int(s)? + 1 }; f("x")`, `err(invalid integer "x")`},
+		{`let f = func(s) { let n = parse_int(s)?; unwrap(err("unreached")); n }; f("x")`, `err(invalid integer "x")`},
+		{`let f = func(a, b) { ok(parse_int(a)? + parse_int(b)?) }; [f("1", "2"), f("1", "y")]`, `[ok(3), err(invalid integer "y")]`},
+		{`let f = func() { [1, err("inner")?, 3] }; f()`, "err(inner)"},
+		{`let f = func() { try { err(1)? } finally { 2 } }; f()`, "err(1)"},
+		{`unwrap(err("bad"))`, raised{"called `unwrap` on err(bad)", object.ErrorKind}},
+		{`unwrap(err(error("bad", "ValueError")))`, raised{"bad", "ValueError"}},
+		{`unwrap_err(ok(1))`, raised{"called `unwrap_err` on ok(1)", object.ErrorKind}},
+		{`is_ok(1)`, raised{"argument to `is_ok` must be RESULT, got INTEGER", object.TypeErrorKind}},
+		{`1?`, raised{"operand of ? must be RESULT, got INTEGER", object.TypeErrorKind}},
+	}
+	for _, tt := range tests {
+		checkEvalResult(t, tt.input, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestDefer(t *testing.T) {
+	tests := []struct {
+		input    string
+		recorded []string
+		expected string // the inspected result or the message of the error
+	}{
+		{`let f = func() { defer record(1); defer record(2); record(3); 4 }; f()`, []string{"3", "2", "1"}, "4"},
+		{`let f = func(n) { defer record("done"); if (n > 0) { return n }; 0 }; f(5)`, []string{"done"}, "5"},
+		{`let f = func() { defer record("done"); 1 + true }; f()`, []string{"done"}, "type mismatch: INTEGER + BOOLEAN"},
+		{`let f = func() { defer record("done"); err(1)? }; f()`, []string{"done"}, "err(1)"},
+		{`let f = func(x) { defer record(x); let x = 2; x }; f(1)`, []string{"1"}, "2"},
+		{`let g = func() { record("g") }; let f = func() { defer record("f"); g() }; f()`, []string{"g", "f"}, "nil"},
+		{`let f = func() { defer fail(); defer record("still run"); 1 }; let fail = func() { throw "late" }; f()`, []string{"still run"}, "late"},
+		{`let f = func() { defer record("f"); [1, 2][5] }; let g = func() { defer record("g"); f() }; g()`, []string{"f", "g"}, "nil"},
+		{`let f = func() { defer record("f") }; try { f(); throw "x" } catch (e) { record(e["message"]) }`, []string{"f", "x"}, "nil"},
+		{`defer record(1);`, nil, "defer outside of a function"},
+	}
+	for _, tt := range tests {
+		var recorded []string
+		env := object.NewEnvironment()
+		env.Set("record", &object.BuiltIn{Func: func(args ...object.Object) object.Object {
+			recorded = append(recorded, args[0].Inspect())
+			return NULL
+		}})
+		root := parser.NewParser(lexer.NewLexer(tt.input)).ParseRootStatement()
+		evaluated := Evaluate(root, env)
+
+		if !reflect.DeepEqual(recorded, tt.recorded) {
+			t.Errorf("wrong calls for %q. expected=%q, got=%q", tt.input, tt.recorded, recorded)
+		}
+		got := inspect(evaluated)
+		if errOb, ok := evaluated.(*object.Error); ok {
+			got = errOb.Message
+		}
+		if got != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestModules(t *testing.T) {
+	dir, extra := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		filepath.Join(dir, "lib", "math.fl"): `
+			import { twice } from "util.fl";
+			export let square = func(x) { x * x };
+			export let quad = func(x) { twice(twice(x)) };
+			let hidden = 1;`,
+		filepath.Join(dir, "lib", "util.fl"): `export let twice = func(x) { x * 2 };`,
+		filepath.Join(dir, "a.fl"):           `import "b.fl" as b;`,
+		filepath.Join(dir, "b.fl"):           `import "a.fl" as a;`,
+		filepath.Join(dir, "broken.fl"):      `export let x = 1 + true;`,
+		filepath.Join(dir, "outer.fl"):       `export let get = func() { secret };`,
+		filepath.Join(extra, "found.fl"):     `export let where = "extra";`,
+	}
+	for name, src := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		input    string
+		expected string // the inspected result or the message of the error
+	}{
+		{`import "lib/math.fl" as m; m["square"](3) + m["quad"](1)`, "13"},
+		{`import { square, quad } from "lib/math.fl"; square(quad(1))`, "16"},
+		{`import "lib/math.fl" as m; m`, "<module lib/math.fl>"},
+		{`import "lib/math.fl" as m; m.square(3) + m.quad(1)`, "13"},
+		{`import "lib/math.fl" as m; m.hidden`, "MODULE has no attribute hidden"},
+		{`import "lib/math.fl" as m; import "lib/math.fl" as n; import { square } from "lib/math.fl"; [m == n, m["square"] == square]`, "[true, true]"},
+		{`let f = func() { import { twice } from "lib/util.fl"; twice(4) }; f()`, "8"},
+		{`import { where } from "found.fl"; where`, "extra"},
+		{`import "lib/math.fl" as m; m["hidden"]`, `module "lib/math.fl" has no export hidden`},
+		{`import { hidden } from "lib/math.fl";`, `module "lib/math.fl" has no export hidden`},
+		{`import { twice } from "util.fl";`, `module "util.fl" not found in ` + dir + string(filepath.ListSeparator) + extra},
+		{`import "a.fl" as a;`, "import cycle: a.fl -> b.fl -> a.fl"},
+		{`import "broken.fl" as b;`, "type mismatch: INTEGER + BOOLEAN"},
+		{`let secret = 1; import { get } from "outer.fl"; get()`, "Identifier 'secret' not found"},
+	}
+	for _, tt := range tests {
+		root := parser.NewParser(lexer.NewLexer(tt.input)).ParseRootStatement()
+		evaluated := New(Options{Loader: NewLoader(dir, extra)}).Evaluate(root, object.NewEnvironment())
+
+		got := inspect(evaluated)
+		if errOb, ok := evaluated.(*object.Error); ok {
+			got = errOb.Message
+		}
+		if got != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+// user is a host object with an attribute and a method.
+type user struct {
+	name string
+}
+
+func (u *user) Type() object.ObjectType { return "USER" }
+
+func (u *user) Inspect() string { return "<user " + u.name + ">" }
+
+func (u *user) GetAttr(name string) (object.Object, bool) {
+	if name == "name" {
+		return &object.String{Value: u.name}, true
+	}
+	return nil, false
+}
+
+func (u *user) SetAttr(name string, value object.Object) error {
+	if name != "name" {
+		return object.ErrNoAttribute
+	}
+	str, ok := value.(*object.String)
+	if !ok {
+		return errors.New("name must be STRING")
+	}
+	u.name = str.Value
+	return nil
+}
+
+func (u *user) CallMethod(name string, args ...object.Object) (object.Object, bool) {
+	if name != "greet" {
+		return nil, false
+	}
+	return &object.String{Value: args[0].Inspect() + ", " + u.name}, true
+}
+
+func TestMembers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string // the inspected result or the message of the error
+		kind     string
+	}{
+		{`u.name`, "ada", ""},
+		{`u.name = "bob"; [u.name, u]`, "[bob, <user bob>]", ""},
+		{`u.greet("hi")`, "hi, ada", ""},
+		{`let f = func(x) { x.greet("yo") }; f(u)`, "yo, ada", ""},
+		{`let f = func() { defer u.greet("bye"); 1 }; f()`, "1", ""},
+		{`u.age`, "USER has no attribute age", object.AttributeErrorKind},
+		{`u.fly()`, "USER has no method fly", object.AttributeErrorKind},
+		{`u.age = 1;`, "cannot set attribute age of USER", object.AttributeErrorKind},
+		{`u.name = 1;`, "name must be STRING", object.ErrorKind},
+		{`{"a": 1}.a`, "HASH has no attribute a", object.AttributeErrorKind},
+		{`[1].len`, "ARRAY has no attribute len", object.AttributeErrorKind},
+		{`[1].size()`, "ARRAY has no method size", object.AttributeErrorKind},
+		{`let x = 1; x.y = 2;`, "cannot set attribute y of INTEGER", object.AttributeErrorKind},
+		{`try { u.age } catch (e) { e["kind"] }`, "AttributeError", ""},
+	}
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.Set("u", &user{name: "ada"})
+		root := parser.NewParser(lexer.NewLexer(tt.input)).ParseRootStatement()
+		evaluated := Evaluate(root, env)
+
+		got, kind := inspect(evaluated), ""
+		if errOb, ok := evaluated.(*object.Error); ok {
+			got, kind = errOb.Message, errOb.Kind
+		}
+		if got != tt.expected || kind != tt.kind {
+			t.Errorf("wrong result for %q. expected=%q (%q), got=%q (%q)", tt.input, tt.expected, tt.kind, got, kind)
+		}
+	}
+}
+
+func TestMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any // the inspected result, or the message of a raised error
+	}{
+		{`let xs = [1, 2]; xs.push(3).rest().len()`, "2"},
+		{`[1, 2, 3].first() + [1, 2, 3].last()`, "4"},
+		{`let xs = [1]; [xs.push(2), xs, len(push(xs, 2))]`, "[[1, 2], [1], 2]"},
+		{`" Hello ".trim().upper() + "X".lower()`, "HELLOx"},
+		{`"a,b,c".split(",")`, "[a, b, c]"},
+		{`["abc".len(), "abc".contains("bc")]`, "[3, true]"},
+		{`let h = {"b": 2, "a": 1, "c": 3}; [h.keys(), h.values(), h.len()]`, "[[a, b, c], [1, 2, 3], 3]"},
+		{`{2: "two", 1: "one", true: "yes"}.keys()`, "[true, 1, 2]"},
+		{`[{}.keys(), {}.values()]`, "[[], []]"},
+		{`let h = {"a": 1}; [h.has("a"), h.has("b")]`, "[true, false]"},
+		{`parse_int("4").unwrap() + parse_int("x").unwrap_or(1)`, "5"},
+		{`[ok(1).is_ok(), err(1).is_err()]`, "[true, true]"},
+		{`"abc".split(1)`, raised{"argument 2 to `split` must be STRING, got INTEGER", object.TypeErrorKind}},
+		{`{"a": 1}.has([1])`, raised{"unusable as hash key: ARRAY", object.TypeErrorKind}},
+		{`1.abs()`, raised{"INTEGER has no method abs", object.AttributeErrorKind}},
+	}
+	for _, tt := range tests {
+		checkEvalResult(t, tt.input, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`struct Point { x, y } Point(1, 2)`, "Point{x: 1, y: 2}"},
+		{`struct Point { x, y } let p = Point(1, [2]); p.x + p.y[0]`, "3"},
+		{`struct Point { x, y } let p = Point(1, 2); p.x = 5; p`, "Point{x: 5, y: 2}"},
+		{`struct Point { x, y } Point`, "<struct Point>"},
+		{`struct Unit {} Unit()`, "Unit{}"},
+		{`struct Point { x, y } [Point(1, "a") == Point(1, "a"), Point(1, 2) != Point(1, 3)]`, "[true, true]"},
+		{`struct Point { x, y } struct Pair { x, y } Point(1, 2) == Pair(1, 2)`, "false"},
+		{`struct Line { a, b } struct Point { x, y } Line(Point(0, 0), Point(1, 1)) == Line(Point(0, 0), Point(1, 1))`, "true"},
+		{`struct Box { f } Box(func(x) { x * 2 }).f(21)`, "42"},
+		{`struct N { next } let a = N(0); a.next = a; [a == a, a]`, "[true, N{next: ...}]"},
+		{`struct N { next } let a = N(0); let b = N(0); a.next = b; b.next = a; [a == b, a != b, a]`,
+			"[true, false, N{next: N{next: ...}}]"},
+		{`struct N { next } let a = N(0); a.next = [a, 1]; let b = N(0); b.next = [b, 1]; [a == b, a]`,
+			"[false, N{next: [..., 1]}]"},
+		{`struct Point { x, y } Point(1)`, raised{"wrong number of arguments. got=1, want=2", object.ArgumentErrorKind}},
+		{`struct Point { x, y } Point(1, 2).z`, raised{"Point has no field z", object.AttributeErrorKind}},
+		{`struct Point { x, y } let p = Point(1, 2); p.z = 1;`, raised{"Point has no field z", object.AttributeErrorKind}},
+		{`struct Point { x, y } Point(1, 2) + 1`, raised{"type mismatch: STRUCT + INTEGER", object.TypeErrorKind}},
+	}
+	for _, tt := range tests {
+		checkEvalResult(t, tt.input, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestClasses(t *testing.T) {
+	animals := `
+class Animal {
+	init(name) { self.name = name; self.sound = "..." }
+	speak() { self.name + " says " + self.sound }
+	rename(name) { self.name = name; self }
+}
+class Dog extends Animal {
+	init(name) { super.init(name); self.sound = "woof" }
+	speak() { "the dog " + super.speak() }
+}
+class Puppy extends Dog {}
+`
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{animals + `Animal("cat").speak()`, "cat says ..."},
+		{animals + `Dog("rex").speak()`, "the dog rex says woof"},
+		{animals + `Puppy("bit").speak()`, "the dog bit says woof"},
+		{animals + `Dog("rex")`, "Dog{name: rex, sound: woof}"},
+		{animals + `Dog("rex").rename("max").name`, "max"},
+		{animals + `let d = Dog("rex"); d.sound = "grr"; d.speak()`, "the dog rex says grr"},
+		{animals + `let speak = Dog("rex").speak; speak()`, "the dog rex says woof"},
+		{animals + `[Dog("rex").speak, Puppy("bit").rename, Animal]`, "[<method Dog.speak>, <method Animal.rename>, <class Animal>]"},
+		{animals + `let p = Puppy("bit"); [p instanceof Puppy, p instanceof Dog, p instanceof Animal]`, "[true, true, true]"},
+		{animals + `[Animal("cat") instanceof Dog, 1 instanceof Animal]`, "[false, false]"},
+		{`struct Point { x, y } [Point(1, 2) instanceof Point, {} instanceof Point]`, "[true, false]"},
+		{`class Counter { init() { self.n = 0 } add() { self.n = self.n + 1; self } } Counter().add().add().n`, "2"},
+		{`class Empty {} Empty()`, "Empty{}"},
+		{`class A { init() { self.me = self; self.all = [self] } } A()`, "A{me: ..., all: [...]}"},
+		{`class A { init() { self.me = self } } let a = A(); [a == a, a == A()]`, "[true, false]"},
+		{`class A { get() { func() { self } } } let a = A(); a.get()() == a`, "true"},
+		{`class Loop { count(n) { if (n == 0) { return "done" } self.count(n - 1) } } Loop().count(100000)`, "done"},
+		{animals + `try { Dog("rex").rename() } catch (e) { e["message"] }`, "wrong number of arguments. got=0, want=1"},
+		{`class A { fail(x) { throw "no" } } try { A().fail(1) } catch (e) { e["stack"][0] }`, "A.fail (1:42) with 1 argument"},
+		{animals + `Dog("rex").fly(1)`, raised{"Dog has no attribute fly", object.AttributeErrorKind}},
+		{`class A { m() { super.m() } } A().m()`, raised{"A has no superclass", object.AttributeErrorKind}},
+		{`class A {} class B extends A { m() { super.m() } } B().m()`, raised{"A has no method m", object.AttributeErrorKind}},
+		{`class A {} A(1)`, raised{"wrong number of arguments. got=1, want=0", object.ArgumentErrorKind}},
+		{`let B = 1; class A extends B {}`, raised{"class A cannot extend INTEGER", object.TypeErrorKind}},
+		{`class A {} 1 instanceof A()`, raised{"right operand of instanceof must be a class or struct, got INSTANCE", object.TypeErrorKind}},
+	}
+	for _, tt := range tests {
+		checkEvalResult(t, tt.input, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestEnumsAndMatch(t *testing.T) {
+	shapes := `
+enum Shape { Circle(r), Rect(w, h), Empty }
+let area = func(s) {
+	match (s) {
+		Shape.Circle(r) => 3 * r * r,
+		Shape.Rect(w, h) if w == h => { let side = w; side * side }
+		Shape.Rect(w, h) => w * h,
+		Shape.Empty => 0,
+	}
+};
+`
+	describe := `
+let describe = func(v) {
+	match (v) {
+		0 => "zero",
+		-1 => "minus one",
+		"" => "empty string",
+		true => "yes",
+		[] => "empty array",
+		[x] => "one " + describe(x),
+		[x, y, ...tail] => "many " + describe(len(tail)),
+		{"kind": "greet", "name": n} => "hello " + n,
+		{"kind": k} => "kind " + k,
+		n if n > 100 => "big",
+		_ => "other",
+	}
+};
+`
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{shapes + `[area(Shape.Circle(2)), area(Shape.Rect(3, 3)), area(Shape.Rect(2, 3)), area(Shape.Empty)]`, "[12, 9, 6, 0]"},
+		{shapes + `[Shape.Circle(1), Shape.Empty, Shape.Circle, Shape]`, "[Shape.Circle(1), Shape.Empty, <variant Shape.Circle>, <enum Shape>]"},
+		{shapes + `Shape.Rect(2, 3).h`, "3"},
+		{shapes + `[Shape.Circle(1) == Shape.Circle(1), Shape.Circle(1) == Shape.Circle(2), Shape.Empty == Shape.Empty, Shape.Empty != Shape.Circle(1)]`,
+			"[true, false, true, true]"},
+		{describe + `[describe(0), describe(-1), describe(""), describe(true), describe([])]`,
+			"[zero, minus one, empty string, yes, empty array]"},
+		{describe + `[describe([0]), describe([1, 2, 3, 4]), describe({"kind": "greet", "name": "bob"}), describe({"kind": "bye"})]`,
+			"[one zero, many other, hello bob, kind bye]"},
+		{describe + `[describe(1000), describe(5)]`, "[big, other]"},
+		{`let x = 1; match ([2, 3]) { [x, _] => x }; x`, "1"},
+		{`let x = 1; [match ([2, 3]) { [x, 5] => 0, _ => x }, x]`, "[1, 1]"},
+		{`match (1) { x => x }; let x = 2; x`, "2"},
+		{`let f = match (3) { n => func() { n } }; f()`, "3"},
+		{`match ([1, 2, 3]) { [first, ...rest] => [first, rest] }`, "[1, [2, 3]]"},
+		{`match ([1, 2, 3, 4]) { [x, ...rest] if (len(rest) > 2) => rest, _ => 0 }`, "[2, 3, 4]"},
+		{`match ([1]) { [first] => first }; first([5, 6])`, "5"},
+		{`match ([1, [2, 3]]) { [a, [b, ...c]] => [a, b, c] }`, "[1, 2, [3]]"},
+		{`let total = func(xs, acc) { match (xs) { [] => acc, [x, ...tail] => total(tail, acc + x) } }; total([1, 2, 3], 0)`, "6"},
+		{`let count = func(n) { match (n) { 0 => "done", _ => count(n - 1) } }; count(100000)`, "done"},
+		{`let f = func(x) { match (x) { 1 => { return "early"; "late" } _ => "other" } }; f(1)`, "early"},
+		{shapes + `area(Shape.Circle)`, raised{"no match arm matches <variant Shape.Circle>", object.MatchErrorKind}},
+		{`match (3) { 1 => 1, 2 => 2 }`, raised{"no match arm matches 3", object.MatchErrorKind}},
+		{shapes + `Shape.Square`, raised{"Shape has no variant Square", object.AttributeErrorKind}},
+		{shapes + `Shape.Circle(1).w`, raised{"Shape.Circle has no field w", object.AttributeErrorKind}},
+		{shapes + `Shape.Rect(1)`, raised{"wrong number of arguments. got=1, want=2", object.ArgumentErrorKind}},
+		{shapes + `match (Shape.Empty) { Shape.Square => 1 }`, raised{"Shape has no variant Square", object.AttributeErrorKind}},
+		{shapes + `match (Shape.Empty) { Shape.Circle(a, b) => 1 }`, raised{"pattern Shape.Circle(a, b) has 2 fields, want 1", object.TypeErrorKind}},
+		{`let E = 1; match (1) { E.A => 1 }`, raised{"E in pattern must be ENUM, got INTEGER", object.TypeErrorKind}},
+		{`match (1) { _ if missing => 1 }`, raised{"Identifier 'missing' not found", object.NameErrorKind}},
+	}
+	for _, tt := range tests {
+		checkEvalResult(t, tt.input, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`let [a, b, ...tail] = [1, 2, 3, 4]; [a, b, tail]`, "[1, 2, [3, 4]]"},
+		{`let [a, ...tail] = [1]; tail`, "[]"},
+		{`let {"name": n, "age": a} = {"name": "ann", "age": 30, "id": 1}; [n, a]`, "[ann, 30]"},
+		{`let [x, y = x * 10, z = y + 1] = [5]; [x, y, z]`, "[5, 50, 51]"},
+		{`let [x = 1, y = 2] = [7, 8]; [x, y]`, "[7, 8]"},
+		{`let {"name": n, "tags": [head, ...others] = ["none"]} = {"name": "ann"}; [n, head, others]`, "[ann, none, []]"},
+		{`let [[p, q], {"k": v}, _] = [[1, 2], {"k": "val"}, 3]; [p, q, v]`, "[1, 2, val]"},
+		{`let [a, b] = [1, 2]; let [a, b] = [b, a]; [a, b]`, "[2, 1]"},
+		{`let swap = func([l, r]) { [r, l] }; swap([1, 2])`, "[2, 1]"},
+		{`let greet = func({"name": who, "greeting": g = "hello"}, end) { g + " " + who + end }; [greet({"name": "bob"}, "!"), greet({"name": "al", "greeting": "hi"}, "?")]`,
+			"[hello bob!, hi al?]"},
+		{`let total = func([h, ...t], acc) { if (len(t) == 0) { acc + h } else { total(t, acc + h) } }; total([1, 2, 3], 0)`, "6"},
+		{`let count = func([n], acc) { if (n == 0) { acc } else { count([n - 1], acc + 1) } }; count([100000], 0)`, "100000"},
+		{`class Point { init({"x": x, "y": y = 0}) { self.x = x; self.y = y } } Point({"x": 3})`, "Point{x: 3, y: 0}"},
+		{`let f = func([a, b]) { a }; f`, "func([a, b]) {\na\n"},
+		{`let xs = [1, 2, 3, 4]; let [a, b, ...rest] = xs; rest`, "[3, 4]"},
+		{`let tail = func([_, ...rest]) { rest }; [tail([1, 2]), rest([1, 2])]`, "[[2], [2]]"},
+		{`match ([1]) { [a, b = 7] => a + b }`, "8"},
+		{`match ({}) { {"k": v = "default"} => v }`, "default"},
+		{`let [only] = [1, 2];`, raised{"pattern [only] does not match [1, 2]", object.MatchErrorKind}},
+		{`let [a, b] = [1];`, raised{"pattern [a, b] does not match [1]", object.MatchErrorKind}},
+		{`let {"k": v} = {};`, raised{"pattern {k: v} does not match {}", object.MatchErrorKind}},
+		{`let [a] = 1;`, raised{"pattern [a] does not match 1", object.MatchErrorKind}},
+		{`let f = func(x, {"id": id}) { id }; f(1, [])`, raised{"pattern {id: id} does not match []", object.MatchErrorKind}},
+		{`let [a = missing] = [];`, raised{"Identifier 'missing' not found", object.NameErrorKind}},
+	}
+	for _, tt := range tests {
+		checkEvalResult(t, tt.input, testEval(t, tt.input), tt.expected)
+	}
+
+	evaluated := testEval(t, `let f = func([a]) { a }; f(1)`)
+	errOb, ok := evaluated.(*object.Error)
+	if !ok || errOb.Pos.Column != 14 || len(errOb.Stack) != 1 || errOb.Stack[0].Function != "f" {
+		t.Errorf("destructuring error not raised in the frame of f. got=%s", inspect(evaluated))
+	}
+}
+
+// call is a frame of an expected stack.
+func call(name string, line, column, args int) object.Frame {
+	return object.Frame{Function: name, Pos: token.Position{Line: line, Column: column}, Args: args}
+}
+
+// loop runs forever in constant stack, allocating an integer per iteration.
+const loop = "let loop = func(n) { loop(n + 1) }; loop(0);"
+
+func TestEvaluateContextLimits(t *testing.T) {
+	tests := []struct {
+		input    string
+		opts     Options
+		expected error
+	}{
+		{loop, Options{MaxSteps: 1000}, ErrStepLimit},
+		{loop, Options{Timeout: 10 * time.Millisecond}, context.DeadlineExceeded},
+		{loop, Options{MaxObjects: 1000}, ErrAllocationLimit},
+		{loop, Options{MaxBytes: 4096}, ErrAllocationLimit},
+		{`let s = "x"; let grow = func(s) { grow(s + s) }; grow(s);`, Options{MaxBytes: 1 << 20}, ErrAllocationLimit},
+		{"let a = [1, 2, 3, 4, 5, 6, 7, 8, 9, 10]; len(a)", Options{MaxSteps: 20}, ErrStepLimit},
+		{"try { " + loop + " } catch (e) { 0 }", Options{MaxSteps: 1000}, ErrStepLimit},
+	}
+	for _, tt := range tests {
+		root := parser.NewParser(lexer.NewLexer(tt.input)).ParseRootStatement()
+		evaluated, err := EvaluateContext(context.Background(), root, object.NewEnvironment(), tt.opts)
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("wrong error for %q with %+v. expected=%v, got=%v", tt.input, tt.opts, tt.expected, err)
+		}
+		if evaluated != nil {
+			t.Errorf("result returned for %q. got=%s", tt.input, evaluated.Inspect())
+		}
+	}
+}
+
+func TestEvaluateContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	root := parser.NewParser(lexer.NewLexer(loop)).ParseRootStatement()
+	_, err := EvaluateContext(ctx, root, object.NewEnvironment(), Options{})
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("wrong error. got=%v", err)
+	}
+	if err.Error() != "evaluation canceled: context canceled" {
+		t.Errorf("wrong message. got=%q", err)
+	}
+
+	_, err = EvaluateContext(ctx, root, object.NewEnvironment(), Options{})
+	if !errors.Is(err, ErrCanceled) {
+		t.Errorf("evaluation started under a done context. got=%v", err)
+	}
+}
+
+func TestEvaluateContextWithinLimits(t *testing.T) {
+	input := `let f = func(x) { if (x == 0) { [] } else { push(f(x - 1), x) } }; len(f(50))`
+	root := parser.NewParser(lexer.NewLexer(input)).ParseRootStatement()
+	opts := Options{MaxSteps: 1e6, Timeout: time.Minute, MaxObjects: 1e6, MaxBytes: 1 << 30}
+
+	evaluated, err := EvaluateContext(context.Background(), root, object.NewEnvironment(), opts)
+	if err != nil {
+		t.Fatalf("unexpected error. got=%v", err)
+	}
+	testIntegerObject(t, evaluated, 50)
+
+	root = parser.NewParser(lexer.NewLexer("1 + true")).ParseRootStatement()
+	evaluated, err = EvaluateContext(context.Background(), root, object.NewEnvironment(), opts)
+	if err != nil {
+		t.Fatalf("error of the program returned as Go error. got=%v", err)
+	}
+	if errOb, ok := evaluated.(*object.Error); !ok || errOb.Message != "type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("wrong result. got=%s", inspect(evaluated))
+	}
+}
+
+func TestEqualityByValueAndIdentity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"a" == "a"`, true},
+		{`"a" != "b"`, true},
+		{`let f = func() { "a" }; f() == f()`, true},
+		{`[1] == [1]`, false},
+		{`let a = [1]; a == a`, true},
+		{`let a = puts; a == puts`, true},
+		{`1 == true`, false},
+	}
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestBindingsHideBuiltIns(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`let len = func(x) { 0 }; len("ab")`, 0},
+		{`let f = func(first) { first }; [f(1), first([5])]`, "[1, 5]"},
+		{`let f = func() { len("ab") }; let a = f(); let len = func(x) { 0 }; [a, f()]`, "[2, 0]"},
+		{`let [first, ...rest] = [1, 2]; [first, rest]`, "[1, [2]]"},
+		{`match ([1]) { [len] => len }`, 1},
+		{`let f = func(xs) { let n = len(xs); let len = n * 2; len }; f([1, 2])`, 4},
+	}
+	for _, tt := range tests {
+		checkEvalResult(t, tt.input, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestUnboundNames(t *testing.T) {
+	tests := []string{
+		"let f = func() { later }; f()",
+		"let f = func() { if (false) { let v = 1 }; v }; f()",
+		"undefined; let undefined = 1;",
+	}
+	for _, input := range tests {
+		evaluated := testEval(t, input)
+		if _, ok := evaluated.(*object.Error); !ok {
+			t.Errorf("no error object returned for %q. got=%T (%+v)", input, evaluated, evaluated)
+		}
+	}
 }
 
 func TestArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	result, ok := evaluated.(*object.Array)
 	if !ok {
 		t.Fatalf("object is not %T. got=%T (%+v)", object.Array{}, evaluated, evaluated)
@@ -380,7 +1162,7 @@ func TestArrayIndexExpressions(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		integer, ok := tt.expected.(int)
 		if ok {
 			testIntegerObject(t, evaluated, int64(integer))
@@ -402,7 +1184,7 @@ func TestHashLiterals(t *testing.T) {
 			false: 6,
 		}
 	`
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	result, ok := evaluated.(*object.Hash)
 	if !ok {
 		t.Fatalf("Eval didn't return %T. got=%T (%+v)", object.Hash{}, evaluated, evaluated)
@@ -462,7 +1244,7 @@ func TestHashIndexExpressions(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		integer, ok := tt.expected.(int)
 		if ok {
 			testIntegerObject(t, evaluated, int64(integer))
@@ -472,13 +1254,120 @@ func TestHashIndexExpressions(t *testing.T) {
 	}
 }
 
-func testEval(input string) object.Object {
+// raised is an expected error, by message and kind.
+type raised struct{ message, kind string }
+
+// checkEvalResult checks the result of evaluating input against expected:
+// an int, the Inspect of a value that is not an error, a raised error, or
+// nil for NULL.
+func checkEvalResult(t *testing.T, input string, evaluated object.Object, expected any) {
+	t.Helper()
+	switch expected := expected.(type) {
+	case int:
+		testIntegerObject(t, evaluated, int64(expected))
+	case string:
+		if _, ok := evaluated.(*object.Error); ok || evaluated.Inspect() != expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%s", input, expected, inspect(evaluated))
+		}
+	case raised:
+		errOb, ok := evaluated.(*object.Error)
+		if !ok || errOb.Message != expected.message || errOb.Kind != expected.kind {
+			t.Errorf("wrong result for %q. expected %s %q, got=%s", input, expected.kind, expected.message, inspect(evaluated))
+		}
+	case nil:
+		testNullObject(t, evaluated)
+	default:
+		t.Fatalf("unexpected expectation %T for %q", expected, input)
+	}
+}
+
+// testEval evaluates input and checks that the compiler and virtual machine
+// agree with the evaluator on its result.
+func testEval(t *testing.T, input string) object.Object {
+	t.Helper()
 	env := object.NewEnvironment()
 	lxr := lexer.NewLexer(input)
 	psr := parser.NewParser(lxr)
 
 	root := psr.ParseRootStatement()
-	return Evaluate(root, env)
+	evaluated := Evaluate(root, env)
+	testVM(t, input, evaluated)
+	return evaluated
+}
+
+func testVM(t *testing.T, input string, expected object.Object) {
+	t.Helper()
+	root := parser.NewParser(lexer.NewLexer(input)).ParseRootStatement()
+
+	cmp := compiler.New()
+	err := cmp.Compile(root)
+	if errors.Is(err, compiler.ErrUnsupported) {
+		return
+	}
+	if err == nil {
+		machine := vm.New(cmp.Bytecode())
+		if err = machine.Run(); err == nil {
+			if !sameObject(expected, machine.Result()) {
+				t.Errorf("vm result differs for %q. evaluator=%s, vm=%s",
+					input, inspect(expected), inspect(machine.Result()))
+			}
+			return
+		}
+	}
+	errObj, ok := expected.(*object.Error)
+	if !ok || errObj.Message != err.Error() {
+		t.Errorf("vm error differs for %q. evaluator=%s, vm=%q", input, inspect(expected), err)
+		return
+	}
+	if runtimeErr, ok := err.(*vm.RuntimeError); ok && runtimeErr.Err.Traceback() != errObj.Traceback() {
+		t.Errorf("vm traceback differs for %q. evaluator=%q, vm=%q",
+			input, errObj.Traceback(), runtimeErr.Err.Traceback())
+	}
+}
+
+// sameObject compares results of the two engines. Functions only need to
+// agree on their type, as the engines represent them differently.
+func sameObject(want, got object.Object) bool {
+	if want == nil || got == nil {
+		return want == got
+	}
+	if want.Type() != got.Type() {
+		return false
+	}
+	switch want := want.(type) {
+	case *object.Array:
+		elements := got.(*object.Array).Elements
+		if len(want.Elements) != len(elements) {
+			return false
+		}
+		for idx, elem := range want.Elements {
+			if !sameObject(elem, elements[idx]) {
+				return false
+			}
+		}
+		return true
+	case *object.Hash:
+		pairs := got.(*object.Hash).Pairs
+		if len(want.Pairs) != len(pairs) {
+			return false
+		}
+		for key, pair := range want.Pairs {
+			if other, ok := pairs[key]; !ok || !sameObject(pair.Value, other.Value) {
+				return false
+			}
+		}
+		return true
+	case *object.Function:
+		return true
+	}
+	return want.Inspect() == got.Inspect()
+}
+
+func inspect(ob object.Object) string {
+	if ob == nil {
+		return "<nil>"
+	}
+	return ob.Inspect()
 }
 
 func testIntegerObject(t *testing.T, ob object.Object, expected int64) bool {
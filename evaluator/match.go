@@ -0,0 +1,185 @@
+package evaluator
+
+import (
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/object"
+)
+
+func evalEnumStatement(node *ast.EnumStatement, env *object.Environment) {
+	enum := object.NewEnum(node.Name.Value)
+	for _, variant := range node.Variants {
+		enum.Add(variant.Name, variant.Fields)
+	}
+	bindValue(node.Name, enum, env)
+}
+
+func (ev *Evaluator) evalMatchExpression(node *ast.MatchExpression, env *object.Environment) object.Object {
+	arm, armEnv, abrupt := ev.selectArm(node, env)
+	if arm == nil {
+		return abrupt
+	}
+	if arm.Body != nil {
+		return ev.evalBlockStatement(arm.Body, armEnv)
+	}
+	return ev.Evaluate(arm.Value, armEnv)
+}
+
+// selectArm returns the first arm of node whose pattern matches the subject
+// and whose guard holds, along with the environment enclosing env in which
+// the names of its pattern are bound. Each arm is tried in an environment of
+// its own, so the names bound by an arm that does not match are dropped with
+// it. When there is none, or evaluating the subject, a pattern or a guard is
+// abrupt, it returns nil and the error or return instead.
+func (ev *Evaluator) selectArm(node *ast.MatchExpression, env *object.Environment) (*ast.MatchArm, *object.Environment, object.Object) {
+	subject := ev.Evaluate(node.Subject, env)
+	if isAbrupt(subject) {
+		return nil, nil, subject
+	}
+	for _, arm := range node.Arms {
+		armEnv := object.NewEnclosedEnvironment(env)
+		if arm.Locals > 0 {
+			armEnv = object.NewFunctionEnvironment(env, arm.Locals)
+		}
+		matched, errOb := ev.matchPattern(arm.Pattern, subject, armEnv)
+		if errOb != nil {
+			return nil, nil, errOb
+		}
+		if !matched {
+			continue
+		}
+		if arm.Guard != nil {
+			guard := ev.Evaluate(arm.Guard, armEnv)
+			if isAbrupt(guard) {
+				return nil, nil, guard
+			}
+			if !isTruthy(guard) {
+				continue
+			}
+		}
+		return arm, armEnv, nil
+	}
+	return nil, nil, createError(object.MatchErrorKind, "no match arm matches %s", subject.Inspect())
+}
+
+// matchPattern reports whether value matches pattern, binding the names in
+// the pattern in env as it goes. Patterns that cannot match anything, such
+// as a variant the enum does not have, raise an error.
+func (ev *Evaluator) matchPattern(pattern ast.Pattern, value object.Object, env *object.Environment) (bool, *object.Error) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if pattern.Value != "_" {
+			bindValue(pattern, value, env)
+		}
+		return true, nil
+	case *ast.LiteralPattern:
+		literal := ev.Evaluate(pattern.Value, env)
+		if errOb, ok := literal.(*object.Error); ok {
+			return false, errOb
+		}
+		return evalInfixExpression("==", value, literal) == TRUE, nil
+	case *ast.ArrayPattern:
+		array, ok := value.(*object.Array)
+		if !ok || pattern.Rest == nil && len(array.Elements) > len(pattern.Elements) {
+			return false, nil
+		}
+		for idx, element := range pattern.Elements {
+			if idx >= len(array.Elements) {
+				if matched, errOb := ev.matchDefault(element, env); !matched {
+					return false, errOb
+				}
+				continue
+			}
+			if matched, errOb := ev.matchPattern(element, array.Elements[idx], env); !matched {
+				return false, errOb
+			}
+		}
+		if pattern.Rest != nil && pattern.Rest.Value != "_" {
+			var rest []object.Object
+			if len(array.Elements) > len(pattern.Elements) {
+				rest = append(rest, array.Elements[len(pattern.Elements):]...)
+			}
+			bindValue(pattern.Rest, &object.Array{Elements: rest}, env)
+		}
+		return true, nil
+	case *ast.HashPattern:
+		hash, ok := value.(*object.Hash)
+		if !ok {
+			return false, nil
+		}
+		for idx, key := range pattern.Keys {
+			keyOb := ev.Evaluate(key, env)
+			if errOb, ok := keyOb.(*object.Error); ok {
+				return false, errOb
+			}
+			pair, ok := hash.Pairs[keyOb.(object.Hashable).HashKey()]
+			if !ok {
+				if matched, errOb := ev.matchDefault(pattern.Values[idx], env); !matched {
+					return false, errOb
+				}
+				continue
+			}
+			if matched, errOb := ev.matchPattern(pattern.Values[idx], pair.Value, env); !matched {
+				return false, errOb
+			}
+		}
+		return true, nil
+	case *ast.VariantPattern:
+		return ev.matchVariant(pattern, value, env)
+	case *ast.DefaultPattern:
+		return ev.matchPattern(pattern.Pattern, value, env)
+	}
+	return false, createError(object.ErrorKind, "unknown pattern %s", pattern)
+}
+
+// matchDefault matches the default of pattern in place of a missing array
+// element or hash value. Patterns without a default do not match.
+func (ev *Evaluator) matchDefault(pattern ast.Pattern, env *object.Environment) (bool, *object.Error) {
+	dflt, ok := pattern.(*ast.DefaultPattern)
+	if !ok {
+		return false, nil
+	}
+	value := ev.Evaluate(dflt.Default, env)
+	if errOb, ok := value.(*object.Error); ok {
+		return false, errOb
+	}
+	return ev.matchPattern(dflt.Pattern, value, env)
+}
+
+func (ev *Evaluator) matchVariant(pattern *ast.VariantPattern, value object.Object, env *object.Environment) (bool, *object.Error) {
+	ob := ev.Evaluate(pattern.Enum, env)
+	if errOb, ok := ob.(*object.Error); ok {
+		return false, errOb
+	}
+	enum, ok := ob.(*object.Enum)
+	if !ok {
+		return false, createError(object.TypeErrorKind, "%s in pattern must be ENUM, got %s", pattern.Enum.Value, ob.Type())
+	}
+	variant, ok := enum.Variant(pattern.Variant)
+	if !ok {
+		return false, createError(object.AttributeErrorKind, "%s has no variant %s", enum.Name, pattern.Variant)
+	}
+	if len(pattern.Fields) != len(variant.Fields) {
+		return false, createError(object.TypeErrorKind, "pattern %s has %d fields, want %d",
+			pattern, len(pattern.Fields), len(variant.Fields))
+	}
+	enumValue, ok := value.(*object.EnumValue)
+	if !ok || enumValue.Variant != variant {
+		return false, nil
+	}
+	for idx, field := range pattern.Fields {
+		if matched, errOb := ev.matchPattern(field, enumValue.Values[idx], env); !matched {
+			return false, errOb
+		}
+	}
+	return true, nil
+}
+
+// destructure binds the names in the pattern of a let or a parameter to the
+// parts of value, raising a MatchError when value does not have its shape.
+func (ev *Evaluator) destructure(pattern ast.Pattern, value object.Object, env *object.Environment) *object.Error {
+	matched, errOb := ev.matchPattern(pattern, value, env)
+	if errOb == nil && !matched {
+		errOb = createError(object.MatchErrorKind, "pattern %s does not match %s", pattern, value.Inspect())
+	}
+	return errOb
+}
@@ -0,0 +1,82 @@
+package evaluator
+
+import (
+	"errors"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/object"
+)
+
+// getAttr returns the attribute called name of ob, as read by ob.name.
+func getAttr(ob object.Object, name string) object.Object {
+	if getter, ok := ob.(object.AttrGetter); ok {
+		if value, ok := getter.GetAttr(name); ok {
+			if value == nil {
+				return NULL
+			}
+			return value
+		}
+	}
+	return createError(object.AttributeErrorKind, "%s has no attribute %s", ob.Type(), name)
+}
+
+func (ev *Evaluator) evalAssignStatement(node *ast.AssignStatement, env *object.Environment) object.Object {
+	ob := ev.Evaluate(node.Target.Object, env)
+	if isAbrupt(ob) {
+		return ob
+	}
+	value := ev.Evaluate(node.Value, env)
+	if isAbrupt(value) {
+		return value
+	}
+	name := node.Target.Property
+	setter, ok := ob.(object.AttrSetter)
+	if !ok {
+		return createError(object.AttributeErrorKind, "cannot set attribute %s of %s", name, ob.Type())
+	}
+	if err := setter.SetAttr(name, value); err != nil {
+		switch {
+		case err == object.ErrNoAttribute:
+			return createError(object.AttributeErrorKind, "cannot set attribute %s of %s", name, ob.Type())
+		case errors.Is(err, object.ErrNoAttribute):
+			return createError(object.AttributeErrorKind, "%s", err)
+		}
+		return createError(object.ErrorKind, "%s", err)
+	}
+	return nil
+}
+
+// evalCallee evaluates the function of a call. The method called by
+// ob.name(args), of a host object or from the methods of the builtin types,
+// is bound to ob as a builtin, so that it is applied like any other
+// function.
+func (ev *Evaluator) evalCallee(expr ast.Expression, env *object.Environment) object.Object {
+	member, ok := expr.(*ast.MemberExpression)
+	if !ok {
+		return ev.Evaluate(expr, env)
+	}
+	ob := ev.Evaluate(member.Object, env)
+	if isAbrupt(ob) {
+		return ob
+	}
+	caller, ok := ob.(object.MethodCaller)
+	if !ok {
+		if method := bindMethod(ob, member.Property); method != nil {
+			return method
+		}
+		if _, ok := ob.(object.AttrGetter); ok {
+			return getAttr(ob, member.Property)
+		}
+		return createError(object.AttributeErrorKind, "%s has no method %s", ob.Type(), member.Property)
+	}
+	return &object.BuiltIn{Func: func(args ...object.Object) object.Object {
+		result, ok := caller.CallMethod(member.Property, args...)
+		if !ok {
+			return createError(object.AttributeErrorKind, "%s has no method %s", ob.Type(), member.Property)
+		}
+		if result == nil {
+			return NULL
+		}
+		return result
+	}}
+}
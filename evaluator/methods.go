@@ -0,0 +1,101 @@
+package evaluator
+
+import (
+	"sort"
+	"strings"
+
+	"Interpreter_in_Go/object"
+)
+
+// methods are the methods of the builtin types by type and name, called as
+// ob.name(args) with ob as their first argument. Methods named after a
+// builtin function are that function, so xs.push(1) is push(xs, 1).
+var methods = map[object.ObjectType]map[string]*object.BuiltIn{
+	object.ARRAY_OBJ: {
+		"len":   object.GetBuiltInByName("len"),
+		"first": object.GetBuiltInByName("first"),
+		"last":  object.GetBuiltInByName("last"),
+		"rest":  object.GetBuiltInByName("rest"),
+		"push":  object.GetBuiltInByName("push"),
+	},
+	object.STRING_OBJ: {
+		"len":      object.GetBuiltInByName("len"),
+		"upper":    object.Define("upper", strings.ToUpper),
+		"lower":    object.Define("lower", strings.ToLower),
+		"trim":     object.Define("trim", strings.TrimSpace),
+		"split":    object.Define("split", strings.Split),
+		"contains": object.Define("contains", strings.Contains),
+	},
+	object.HASH_OBJ: {
+		"len": object.Define("len", func(hash *object.Hash) int {
+			return len(hash.Pairs)
+		}),
+		"keys": object.Define("keys", func(hash *object.Hash) []object.Object {
+			keys := make([]object.Object, 0, len(hash.Pairs))
+			for _, pair := range sortedPairs(hash) {
+				keys = append(keys, pair.Key)
+			}
+			return keys
+		}),
+		"values": object.Define("values", func(hash *object.Hash) []object.Object {
+			values := make([]object.Object, 0, len(hash.Pairs))
+			for _, pair := range sortedPairs(hash) {
+				values = append(values, pair.Value)
+			}
+			return values
+		}),
+		"has": object.Define("has", func(hash *object.Hash, key object.Object) object.Object {
+			hashable, ok := key.(object.Hashable)
+			if !ok {
+				return createError(object.TypeErrorKind, "unusable as hash key: %s", key.Type())
+			}
+			_, ok = hash.Pairs[hashable.HashKey()]
+			return boolNativeToBoolObject(ok)
+		}),
+	},
+	object.RESULT_OBJ: {
+		"is_ok":      object.GetBuiltInByName("is_ok"),
+		"is_err":     object.GetBuiltInByName("is_err"),
+		"unwrap":     object.GetBuiltInByName("unwrap"),
+		"unwrap_or":  object.GetBuiltInByName("unwrap_or"),
+		"unwrap_err": object.GetBuiltInByName("unwrap_err"),
+	},
+}
+
+// sortedPairs returns the pairs of hash ordered by key, integers and strings
+// by value and booleans false first, so that keys and values list them in
+// the same, stable order.
+func sortedPairs(hash *object.Hash) []object.HashPair {
+	pairs := make([]object.HashPair, 0, len(hash.Pairs))
+	for _, pair := range hash.Pairs {
+		pairs = append(pairs, pair)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		left, right := pairs[i].Key, pairs[j].Key
+		if left.Type() != right.Type() {
+			return left.Type() < right.Type()
+		}
+		switch left := left.(type) {
+		case *object.Integer:
+			return left.Value < right.(*object.Integer).Value
+		case *object.String:
+			return left.Value < right.(*object.String).Value
+		case *object.Boolean:
+			return !left.Value && right.(*object.Boolean).Value
+		}
+		return false
+	})
+	return pairs
+}
+
+// bindMethod returns the method called name of ob with ob bound as its first
+// argument, or nil when ob's type has no such method.
+func bindMethod(ob object.Object, name string) *object.BuiltIn {
+	method, ok := methods[ob.Type()][name]
+	if !ok {
+		return nil
+	}
+	return &object.BuiltIn{Func: func(args ...object.Object) object.Object {
+		return method.Func(append([]object.Object{ob}, args...)...)
+	}}
+}
@@ -0,0 +1,154 @@
+package evaluator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/lexer"
+	"Interpreter_in_Go/object"
+	"Interpreter_in_Go/parser"
+)
+
+// PathVariable is the environment variable listing the directories searched
+// for modules after the default ones, separated like PATH.
+const PathVariable = "FLINTPATH"
+
+// SearchPath returns dirs followed by the directories listed in the
+// PathVariable environment variable.
+func SearchPath(dirs ...string) []string {
+	for _, dir := range filepath.SplitList(os.Getenv(PathVariable)) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// Loader finds, evaluates and caches the modules imported by programs, so
+// that each file is evaluated once however often it is imported. A Loader
+// can be shared by the evaluations of a session, such as the lines entered
+// in a REPL. It is not safe for concurrent use.
+type Loader struct {
+	// Path lists the directories searched for imported files, after the
+	// directory of the importing module when there is one.
+	Path []string
+
+	modules map[string]*object.Module // by absolute file name
+	loading []loading                 // outermost first
+}
+
+// loading is a module being evaluated.
+type loading struct {
+	name string // as imported
+	file string // absolute
+}
+
+func NewLoader(path ...string) *Loader {
+	return &Loader{Path: path, modules: make(map[string]*object.Module)}
+}
+
+// find returns the absolute name of the file imported as name.
+func (ldr *Loader) find(name string) (string, error) {
+	dirs := ldr.Path
+	if len(ldr.loading) > 0 {
+		importer := ldr.loading[len(ldr.loading)-1].file
+		dirs = append([]string{filepath.Dir(importer)}, dirs...)
+	}
+	if filepath.IsAbs(name) {
+		dirs = []string{""}
+	}
+	for _, dir := range dirs {
+		file := filepath.Join(dir, name)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return filepath.Abs(file)
+		}
+	}
+	return "", fmt.Errorf("module %q not found in %s", name, strings.Join(dirs, string(filepath.ListSeparator)))
+}
+
+// cycle describes the chain of imports that leads back to file, or returns
+// "" when file is not being loaded.
+func (ldr *Loader) cycle(name, file string) string {
+	for idx, ld := range ldr.loading {
+		if ld.file != file {
+			continue
+		}
+		var chain []string
+		for _, ld := range ldr.loading[idx:] {
+			chain = append(chain, ld.name)
+		}
+		return strings.Join(append(chain, name), " -> ")
+	}
+	return ""
+}
+
+func (ev *Evaluator) evalImportStatement(node *ast.ImportStatement, env *object.Environment) object.Object {
+	loaded := ev.load(node.Path.Value)
+	module, ok := loaded.(*object.Module)
+	if !ok {
+		return loaded
+	}
+	if node.Alias != nil {
+		bindValue(node.Alias, module, env)
+		return nil
+	}
+	for _, name := range node.Names {
+		value, ok := module.Exports[name.Value]
+		if !ok {
+			return createError(object.ImportErrorKind, "module %q has no export %s", module.Name, name.Value)
+		}
+		bindValue(name, value, env)
+	}
+	return nil
+}
+
+// load returns the module imported as name, evaluating it in an environment
+// of its own unless it was loaded before, or the error that stopped it.
+func (ev *Evaluator) load(name string) object.Object {
+	ldr := ev.opts.Loader
+	file, err := ldr.find(name)
+	if err != nil {
+		return createError(object.ImportErrorKind, "%s", err)
+	}
+	if module, ok := ldr.modules[file]; ok {
+		return module
+	}
+	if chain := ldr.cycle(name, file); chain != "" {
+		return createError(object.ImportErrorKind, "import cycle: %s", chain)
+	}
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return createError(object.ImportErrorKind, "cannot import %q: %s", name, err)
+	}
+	psr := parser.NewParser(lexer.NewLexer(string(src)))
+	root := psr.ParseRootStatement()
+	if errs := psr.Errors(); len(errs) != 0 {
+		return createError(object.ImportErrorKind, "cannot import %q: %s", name, strings.Join(errs, "; "))
+	}
+
+	// the module's defer statements are outside of any function, like
+	// those of a program
+	ldr.loading = append(ldr.loading, loading{name: name, file: file})
+	deferred := ev.deferred
+	ev.deferred = nil
+	defer func() {
+		ldr.loading = ldr.loading[:len(ldr.loading)-1]
+		ev.deferred = deferred
+	}()
+
+	env := object.NewEnvironment()
+	if result := ev.Evaluate(root, env); isAbrupt(result) {
+		return result
+	}
+	module := &object.Module{Name: name, Exports: make(map[string]object.Object)}
+	for _, stmt := range root.Statements {
+		if export, ok := stmt.(*ast.ExportStatement); ok {
+			module.Exports[export.Let.Name.Value], _ = env.Get(export.Let.Name.Value)
+		}
+	}
+	ldr.modules[file] = module
+	return module
+}
@@ -0,0 +1,194 @@
+// Package flint embeds the Flint interpreter in Go programs.
+//
+// An Interpreter keeps the globals defined by the code it evaluated, so a
+// host can evaluate a script and then call the functions it defined:
+//
+//	interp := flint.New(flint.Options{Output: &buf})
+//	if _, err := interp.Eval(ctx, `let greet = func(name) { "hello " + name };`); err != nil {
+//		return err
+//	}
+//	greeting, err := interp.Call(ctx, "greet", &object.String{Value: "world"})
+//
+// Interpreters share no state with each other: each has its own globals,
+// builtins, output and module cache.
+package flint
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/evaluator"
+	"Interpreter_in_Go/lexer"
+	"Interpreter_in_Go/object"
+	"Interpreter_in_Go/parser"
+)
+
+// Options configure an Interpreter. The zero value gives an interpreter
+// writing to os.Stdout with the standard builtins and no limits.
+type Options struct {
+	// Output is where puts writes, os.Stdout when nil.
+	Output io.Writer
+	// BuiltIns are made available to the evaluated code in addition to the
+	// standard builtins, replacing the standard builtin of the same name.
+	BuiltIns map[string]*object.BuiltIn
+	// Path lists the directories searched for imported modules after the
+	// directory of the importing file. Eval searches the working directory
+	// first.
+	Path []string
+
+	// MaxCallDepth bounds the number of function calls in progress at once.
+	// Zero means evaluator.DefaultMaxCallDepth.
+	MaxCallDepth int
+	// The limits below apply to each call of Eval, EvalFile and Call; zero
+	// means no limit. See evaluator.Options.
+	MaxSteps   int64
+	Timeout    time.Duration
+	MaxObjects int64
+	MaxBytes   int64
+}
+
+// Interpreter evaluates Flint code and keeps its globals between
+// evaluations. It is not safe for concurrent use.
+type Interpreter struct {
+	ev     *evaluator.Evaluator
+	env    *object.Environment
+	loader *evaluator.Loader
+}
+
+func New(opts Options) *Interpreter {
+	loader := evaluator.NewLoader(opts.Path...)
+	ev := evaluator.New(evaluator.Options{
+		MaxCallDepth: opts.MaxCallDepth,
+		MaxSteps:     opts.MaxSteps,
+		Timeout:      opts.Timeout,
+		MaxObjects:   opts.MaxObjects,
+		MaxBytes:     opts.MaxBytes,
+		Loader:       loader,
+		Output:       opts.Output,
+		BuiltIns:     opts.BuiltIns,
+	})
+	return &Interpreter{ev: ev, env: object.NewEnvironment(), loader: loader}
+}
+
+// ParseError reports source that does not parse.
+type ParseError struct {
+	File     string // empty for the source given to Eval
+	Messages []string
+}
+
+func (err *ParseError) Error() string {
+	msg := strings.Join(err.Messages, "\n")
+	if err.File != "" {
+		return err.File + ": " + msg
+	}
+	return msg
+}
+
+// RuntimeError reports an error raised by the evaluated code and not caught.
+type RuntimeError struct {
+	Err *object.Error
+}
+
+func (err *RuntimeError) Error() string {
+	return err.Err.Message
+}
+
+// Traceback describes the error along with the calls that led to it.
+func (err *RuntimeError) Traceback() string {
+	return err.Err.Traceback()
+}
+
+// Eval evaluates src and returns the value of its last statement. Errors are
+// a *ParseError, a *RuntimeError, or an error wrapping one of the errors of
+// the evaluator package when ctx is done or a limit is exceeded.
+func (interp *Interpreter) Eval(ctx context.Context, src string) (object.Object, error) {
+	root, err := parse("", src)
+	if err != nil {
+		return nil, err
+	}
+	return interp.eval(ctx, root, ".")
+}
+
+// EvalFile evaluates the named file like Eval. Modules it imports are
+// searched for next to it first.
+func (interp *Interpreter) EvalFile(ctx context.Context, name string) (object.Object, error) {
+	src, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parse(name, string(src))
+	if err != nil {
+		return nil, err
+	}
+	return interp.eval(ctx, root, filepath.Dir(name))
+}
+
+func parse(name, src string) (*ast.RootStatement, error) {
+	psr := parser.NewParser(lexer.NewLexer(src))
+	root := psr.ParseRootStatement()
+	if len(psr.Errors()) != 0 {
+		return nil, &ParseError{File: name, Messages: psr.Errors()}
+	}
+	return root, nil
+}
+
+// eval evaluates root with the modules it imports searched for in dir first.
+func (interp *Interpreter) eval(ctx context.Context, root *ast.RootStatement, dir string) (ob object.Object, err error) {
+	path := interp.loader.Path
+	interp.loader.Path = append([]string{dir}, path...)
+	defer func() { interp.loader.Path = path }()
+	defer recoverPanic(&err)
+
+	return result(interp.ev.EvaluateContext(ctx, root, interp.env))
+}
+
+// Call calls the function, class or struct type bound to the global name
+// with args and returns its result, with errors as reported by Eval.
+func (interp *Interpreter) Call(ctx context.Context, name string, args ...object.Object) (ob object.Object, err error) {
+	fn, ok := interp.env.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("flint: %s is not defined", name)
+	}
+	if !evaluator.Callable(fn) {
+		return nil, fmt.Errorf("flint: %s is not a function, got %s", name, fn.Type())
+	}
+	defer recoverPanic(&err)
+	return result(interp.ev.CallContext(ctx, fn, args...))
+}
+
+// recoverPanic reports a panic of the interpreter, such as one in a builtin
+// defined by the host, as a *RuntimeError instead of crashing the host.
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = &RuntimeError{Err: &object.Error{Kind: object.ErrorKind, Message: fmt.Sprintf("internal error: %v", r)}}
+	}
+}
+
+func result(ob object.Object, err error) (object.Object, error) {
+	if err != nil {
+		return nil, err
+	}
+	if errOb, ok := ob.(*object.Error); ok {
+		return nil, &RuntimeError{Err: errOb}
+	}
+	if ob == nil {
+		return object.NULL, nil
+	}
+	return ob, nil
+}
+
+// Get returns the value of the global name.
+func (interp *Interpreter) Get(name string) (object.Object, bool) {
+	return interp.env.Get(name)
+}
+
+// Set binds the global name to value, replacing any previous binding.
+func (interp *Interpreter) Set(name string, value object.Object) {
+	interp.env.Set(name, value)
+}
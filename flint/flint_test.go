@@ -0,0 +1,137 @@
+package flint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"Interpreter_in_Go/evaluator"
+	"Interpreter_in_Go/object"
+)
+
+func TestEvalAndCall(t *testing.T) {
+	ctx := context.Background()
+	interp := New(Options{})
+	interp.Set("base", &object.Integer{Value: 10})
+
+	if _, err := interp.Eval(ctx, "let add = func(x) { x + base }; let total = add(1);"); err != nil {
+		t.Fatalf("Eval returned error: %s", err)
+	}
+	total, ok := interp.Get("total")
+	if !ok || total.Inspect() != "11" {
+		t.Errorf("wrong total. got=%v", total)
+	}
+	result, err := interp.Call(ctx, "add", &object.Integer{Value: 5})
+	if err != nil || result.Inspect() != "15" {
+		t.Errorf("wrong result of Call. got=%v, %v", result, err)
+	}
+	if _, err := interp.Eval(ctx, "struct Point { x, y }"); err != nil {
+		t.Fatalf("Eval returned error: %s", err)
+	}
+	result, err = interp.Call(ctx, "Point", &object.Integer{Value: 1}, &object.Integer{Value: 2})
+	if err != nil || result.Inspect() != "Point{x: 1, y: 2}" {
+		t.Errorf("wrong result of calling a struct type. got=%v, %v", result, err)
+	}
+	result, err = interp.Eval(ctx, "let unused = 1;")
+	if err != nil || result != object.NULL {
+		t.Errorf("wrong result of a let. got=%v, %v", result, err)
+	}
+}
+
+func TestIsolation(t *testing.T) {
+	ctx := context.Background()
+	var out1, out2 bytes.Buffer
+	shout := &object.BuiltIn{Func: func(args ...object.Object) object.Object {
+		return &object.String{Value: args[0].Inspect() + "!"}
+	}}
+	first := New(Options{Output: &out1, BuiltIns: map[string]*object.BuiltIn{"shout": shout}})
+	second := New(Options{Output: &out2})
+
+	if _, err := first.Eval(ctx, `let x = 1; puts(shout("one"));`); err != nil {
+		t.Fatalf("Eval returned error: %s", err)
+	}
+	if _, err := second.Eval(ctx, `puts("two");`); err != nil {
+		t.Fatalf("Eval returned error: %s", err)
+	}
+	if out1.String() != "one!\n" || out2.String() != "two\n" {
+		t.Errorf("wrong output. got=%q and %q", out1.String(), out2.String())
+	}
+	if _, ok := second.Get("x"); ok {
+		t.Errorf("x leaked into the second interpreter")
+	}
+	if _, err := second.Eval(ctx, `shout("two")`); err == nil {
+		t.Errorf("shout leaked into the second interpreter")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	interp := New(Options{MaxSteps: 1000})
+
+	_, err := interp.Eval(ctx, "let = 1;")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Errorf("expected a *ParseError. got=%v", err)
+	}
+	_, err = interp.Eval(ctx, "let f = func() { 1 + true }; f();")
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) || err.Error() != "type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("expected a *RuntimeError. got=%v", err)
+	} else if runtimeErr.Err.Pos.Line != 1 {
+		t.Errorf("wrong position. got=%s", runtimeErr.Err.Pos)
+	}
+	_, err = interp.Eval(ctx, "let loop = func(n) { loop(n + 1) }; loop(0);")
+	if !errors.Is(err, evaluator.ErrStepLimit) {
+		t.Errorf("expected the step limit to be exceeded. got=%v", err)
+	}
+	if _, err = interp.Call(ctx, "loop", &object.Integer{Value: 0}); !errors.Is(err, evaluator.ErrStepLimit) {
+		t.Errorf("expected the step limit to be exceeded by Call. got=%v", err)
+	}
+	if _, err = interp.Call(ctx, "missing"); err == nil || err.Error() != "flint: missing is not defined" {
+		t.Errorf("wrong error for a missing function. got=%v", err)
+	}
+	interp.Set("n", &object.Integer{Value: 1})
+	if _, err = interp.Call(ctx, "n"); err == nil || err.Error() != "flint: n is not a function, got INTEGER" {
+		t.Errorf("wrong error for a non-function. got=%v", err)
+	}
+	for src, want := range map[string]string{
+		"let two = func(a, b) { a }; two(1);": "wrong number of arguments. got=1, want=2",
+		"let empty = func() { }; empty();":    "",
+		"1 / 0;":                              "division by zero",
+	} {
+		_, err = interp.Eval(ctx, src)
+		if want == "" && err != nil || want != "" && (!errors.As(err, &runtimeErr) || err.Error() != want) {
+			t.Errorf("wrong error for %q. want=%q, got=%v", src, want, err)
+		}
+	}
+	interp.Set("explode", &object.BuiltIn{Func: func(args ...object.Object) object.Object { panic("boom") }})
+	if _, err = interp.Eval(ctx, "explode();"); !errors.As(err, &runtimeErr) || err.Error() != "internal error: boom" {
+		t.Errorf("expected a panic to become a *RuntimeError. got=%v", err)
+	}
+	if _, err = interp.Call(ctx, "explode"); !errors.As(err, &runtimeErr) || err.Error() != "internal error: boom" {
+		t.Errorf("expected a panic in Call to become a *RuntimeError. got=%v", err)
+	}
+	if _, err = interp.Call(ctx, "two", &object.Integer{Value: 1}, &object.Integer{Value: 2}); err != nil {
+		t.Errorf("interpreter unusable after a panic. got=%v", err)
+	}
+}
+
+func TestEvalFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.fl": `import { twice } from "lib.fl"; twice(21)`,
+		"lib.fl":  `export let twice = func(x) { x * 2 };`,
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err := New(Options{}).EvalFile(context.Background(), filepath.Join(dir, "main.fl"))
+	if err != nil || result.Inspect() != "42" {
+		t.Errorf("wrong result of EvalFile. got=%v, %v", result, err)
+	}
+}
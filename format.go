@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"Interpreter_in_Go/format"
+)
+
+// sourceExt is the file extension of Flint source files.
+const sourceExt = ".fl"
+
+// formatCommand implements 'flint fmt [-check] [path ...]'. Files are
+// rewritten in place; with -check they are only listed, and the exit code is
+// 1 when any of them is not formatted. Without paths it filters stdin.
+func formatCommand(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	check := flags.Bool("check", false, "list files whose formatting differs instead of rewriting them")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		return formatStdin(*check)
+	}
+	paths, err := sourceFiles(flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	status := 0
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+			continue
+		}
+		formatted, err := format.Source(src)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			status = 2
+			continue
+		}
+		if bytes.Equal(src, formatted) {
+			continue
+		}
+		if *check {
+			fmt.Println(path)
+			status = max(status, 1)
+			continue
+		}
+		if err := os.WriteFile(path, formatted, 0o644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 2
+		}
+	}
+	return status
+}
+
+func formatStdin(check bool) int {
+	src, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	formatted, err := format.Source(src)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "<stdin>: %s\n", err)
+		return 2
+	}
+	if check {
+		if !bytes.Equal(src, formatted) {
+			fmt.Println("<stdin>")
+			return 1
+		}
+		return 0
+	}
+	_, _ = os.Stdout.Write(formatted)
+	return 0
+}
+
+// sourceFiles expands the given paths into Flint source files, walking
+// directories for files with the source extension.
+func sourceFiles(paths []string) ([]string, error) {
+	var files []string
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && filepath.Ext(file) == sourceExt {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
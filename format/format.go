@@ -0,0 +1,628 @@
+package format
+
+import (
+	"errors"
+	"strings"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/lexer"
+	"Interpreter_in_Go/parser"
+	"Interpreter_in_Go/token"
+)
+
+// Source formats Flint source code into its canonical form. Comments, single
+// blank lines between statements and the line breaks between the elements
+// of a list are kept, everything else about the layout is normalized.
+func Source(src []byte) ([]byte, error) {
+	psr := parser.NewParser(lexer.NewLexer(string(src)))
+	root := psr.ParseRootStatement()
+
+	if len(psr.Errors()) != 0 {
+		return nil, errors.New(strings.Join(psr.Errors(), "\n"))
+	}
+	prt := &printer{comments: root.Comments, lines: strings.Split(string(src), "\n")}
+	prt.root(root)
+	return []byte(prt.out.String()), nil
+}
+
+// Node formats an already parsed tree. Without the source text the blank
+// lines between statements are unknown, so none are emitted.
+func Node(root *ast.RootStatement) string {
+	prt := &printer{comments: root.Comments}
+	prt.root(root)
+	return prt.out.String()
+}
+
+type printer struct {
+	out    strings.Builder
+	indent int
+
+	comments []*ast.Comment
+	next     int      // index of the next comment to be printed
+	lines    []string // source lines, nil when printing a bare tree
+}
+
+func (prt *printer) root(root *ast.RootStatement) {
+	prt.statements(root.Statements, token.Position{})
+}
+
+// statements prints one statement per line along with the comments that
+// belong before each of them. Comments positioned before end are flushed
+// after the last statement; a zero end flushes all that remain.
+func (prt *printer) statements(stmts []ast.Statement, end token.Position) {
+	first := true
+
+	for idx, stmt := range stmts {
+		first = prt.commentsBefore(stmt.Pos(), first)
+		if !first && prt.blankLineBefore(stmt.Pos().Line) {
+			prt.out.WriteString("\n")
+		}
+		limit := end
+		var next ast.Statement
+		if idx+1 < len(stmts) {
+			next = stmts[idx+1]
+			limit = next.Pos()
+		}
+		prt.writeIndent()
+		prt.statement(stmt)
+		if needsSemicolon(stmt, next) {
+			prt.out.WriteString(";")
+		}
+		prt.trailingComment(limit)
+		prt.out.WriteString("\n")
+		first = false
+	}
+	prt.commentsBefore(end, first)
+}
+
+// commentsBefore prints every pending comment positioned before pos on its
+// own line and reports whether the enclosing list is still empty.
+func (prt *printer) commentsBefore(pos token.Position, first bool) bool {
+	for prt.hasCommentBefore(pos) {
+		comment := prt.comments[prt.next]
+		if !first && prt.blankLineBefore(comment.Pos().Line) {
+			prt.out.WriteString("\n")
+		}
+		prt.writeIndent()
+		prt.out.WriteString(comment.Text)
+		prt.out.WriteString("\n")
+		prt.next++
+		first = false
+	}
+	return first
+}
+
+// trailingComment keeps a comment that followed code on its source line at
+// the end of the statement just printed.
+func (prt *printer) trailingComment(limit token.Position) {
+	if !prt.hasCommentBefore(limit) {
+		return
+	}
+	comment := prt.comments[prt.next]
+	if !prt.followsCode(comment) {
+		return
+	}
+	prt.out.WriteString(" ")
+	prt.out.WriteString(comment.Text)
+	prt.next++
+}
+
+func (prt *printer) hasCommentBefore(pos token.Position) bool {
+	if prt.next >= len(prt.comments) {
+		return false
+	}
+	return pos == token.Position{} || prt.comments[prt.next].Pos().Before(pos)
+}
+
+func (prt *printer) followsCode(comment *ast.Comment) bool {
+	pos := comment.Pos()
+	if pos.Line < 1 || pos.Line > len(prt.lines) {
+		return false
+	}
+	line := prt.lines[pos.Line-1]
+	return strings.TrimSpace(line[:pos.Column-1]) != ""
+}
+
+func (prt *printer) blankLineBefore(line int) bool {
+	if line < 2 || line > len(prt.lines) {
+		return false
+	}
+	return strings.TrimSpace(prt.lines[line-2]) == ""
+}
+
+func (prt *printer) writeIndent() {
+	prt.out.WriteString(strings.Repeat("\t", prt.indent))
+}
+
+// needsSemicolon reports whether stmt must be terminated when printed on its
+// own line. An if or try expression only needs one when the statement after
+// it would otherwise be parsed as its continuation.
+func needsSemicolon(stmt, next ast.Statement) bool {
+	exprStmt, ok := stmt.(*ast.ExpressionStatement)
+	if !ok {
+		return false
+	}
+	if !endsWithBlock(exprStmt.Expression) {
+		return true
+	}
+	nextStmt, ok := next.(*ast.ExpressionStatement)
+	if !ok {
+		return false
+	}
+	switch leadingToken(nextStmt.Expression, parser.LOWEST) {
+	case token.MINUS, token.L_PAREN, token.L_BRACKET:
+		return true
+	}
+	return false
+}
+
+// endsWithBlock reports whether expr is printed ending with a block, which
+// needs no semicolon after it.
+func endsWithBlock(expr ast.Expression) bool {
+	switch expr.(type) {
+	case *ast.IfExpression, *ast.TryExpression, *ast.MatchExpression:
+		return true
+	}
+	return false
+}
+
+// leadingToken returns the type of the first token printed for expr.
+func leadingToken(expr ast.Expression, precedence int) token.TokenType {
+	if precedenceOf(expr) < precedence {
+		return token.L_PAREN
+	}
+	switch expr := expr.(type) {
+	case *ast.PrefixExpression:
+		return expr.Token.Type
+	case *ast.InfixExpression:
+		return leadingToken(expr.Left, parser.Precedence(expr.Token.Type))
+	case *ast.PostfixExpression:
+		return leadingToken(expr.Left, parser.CALL)
+	case *ast.CallExpression:
+		return leadingToken(expr.Function, parser.CALL)
+	case *ast.IndexExpression:
+		return leadingToken(expr.Left, parser.CALL)
+	case *ast.MemberExpression:
+		return leadingToken(expr.Object, parser.CALL)
+	case *ast.ArrayLiteral:
+		return token.L_BRACKET
+	default:
+		return token.IDENT
+	}
+}
+
+func (prt *printer) statement(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		prt.out.WriteString("let ")
+		if stmt.Pattern != nil {
+			prt.pattern(stmt.Pattern)
+		} else {
+			prt.out.WriteString(stmt.Name.Value)
+		}
+		prt.out.WriteString(" = ")
+		prt.expression(stmt.Value, parser.LOWEST)
+		prt.out.WriteString(";")
+	case *ast.ReturnStatement:
+		prt.out.WriteString("return ")
+		prt.expression(stmt.ReturnValue, parser.LOWEST)
+		prt.out.WriteString(";")
+	case *ast.ThrowStatement:
+		prt.out.WriteString("throw ")
+		prt.expression(stmt.Value, parser.LOWEST)
+		prt.out.WriteString(";")
+	case *ast.ImportStatement:
+		prt.out.WriteString("import ")
+		if stmt.Alias != nil {
+			prt.out.WriteString(`"` + stmt.Path.Value + `" as ` + stmt.Alias.Value)
+		} else {
+			prt.out.WriteString("{ ")
+			for idx, name := range stmt.Names {
+				if idx > 0 {
+					prt.out.WriteString(", ")
+				}
+				prt.out.WriteString(name.Value)
+			}
+			prt.out.WriteString(` } from "` + stmt.Path.Value + `"`)
+		}
+		prt.out.WriteString(";")
+	case *ast.ExportStatement:
+		prt.out.WriteString("export ")
+		prt.statement(stmt.Let)
+	case *ast.StructStatement, *ast.EnumStatement:
+		prt.out.WriteString(stmt.String())
+	case *ast.ClassStatement:
+		prt.class(stmt)
+	case *ast.DeferStatement:
+		prt.out.WriteString("defer ")
+		prt.expression(stmt.Call, parser.LOWEST)
+		prt.out.WriteString(";")
+	case *ast.AssignStatement:
+		prt.expression(stmt.Target, parser.LOWEST)
+		prt.out.WriteString(" = ")
+		prt.expression(stmt.Value, parser.LOWEST)
+		prt.out.WriteString(";")
+	case *ast.ExpressionStatement:
+		prt.expression(stmt.Expression, parser.LOWEST)
+	case *ast.BlockStatement:
+		prt.block(stmt)
+	}
+}
+
+// expression prints expr, wrapping it in parentheses when it binds less
+// tightly than the surrounding context requires.
+func (prt *printer) expression(expr ast.Expression, precedence int) {
+	grouped := precedenceOf(expr) < precedence
+	if grouped {
+		prt.out.WriteString("(")
+	}
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		prt.out.WriteString(expr.Value)
+	case *ast.IntegerLiteral:
+		prt.out.WriteString(expr.Token.Literal)
+	case *ast.StringLiteral:
+		prt.out.WriteString(`"` + expr.Value + `"`)
+	case *ast.Boolean:
+		prt.out.WriteString(expr.Token.Literal)
+	case *ast.PrefixExpression:
+		prt.out.WriteString(expr.Operator)
+		prt.expression(expr.Right, parser.PREFIX)
+	case *ast.PostfixExpression:
+		prt.expression(expr.Left, parser.CALL)
+		prt.out.WriteString(expr.Operator)
+	case *ast.InfixExpression:
+		pdc := parser.Precedence(expr.Token.Type)
+		prt.expression(expr.Left, pdc)
+		prt.out.WriteString(" " + expr.Operator + " ")
+		prt.expression(expr.Right, pdc+1)
+	case *ast.IfExpression:
+		prt.out.WriteString("if (")
+		prt.expression(expr.Condition, parser.LOWEST)
+		prt.out.WriteString(") ")
+		prt.block(expr.Consequence)
+		if expr.Alternative != nil {
+			prt.out.WriteString(" else ")
+			prt.block(expr.Alternative)
+		}
+	case *ast.TryExpression:
+		prt.out.WriteString("try ")
+		prt.block(expr.Body)
+		if expr.Catch != nil {
+			prt.out.WriteString(" catch ")
+			if expr.Param != nil {
+				prt.out.WriteString("(" + expr.Param.Value + ") ")
+			}
+			prt.block(expr.Catch)
+		}
+		if expr.Finally != nil {
+			prt.out.WriteString(" finally ")
+			prt.block(expr.Finally)
+		}
+	case *ast.MatchExpression:
+		prt.match(expr)
+	case *ast.FunctionLiteral:
+		prt.out.WriteString("func(")
+		prt.parameters(expr, 0)
+		prt.out.WriteString(") ")
+		prt.block(expr.Body)
+	case *ast.CallExpression:
+		prt.expression(expr.Function, parser.CALL)
+		prt.expressionList("(", expr.Arguments, expr.Close.Pos, ")")
+	case *ast.ArrayLiteral:
+		prt.expressionList("[", expr.Elements, expr.Close.Pos, "]")
+	case *ast.IndexExpression:
+		prt.expression(expr.Left, parser.CALL)
+		prt.out.WriteString("[")
+		prt.expression(expr.Index, parser.LOWEST)
+		prt.out.WriteString("]")
+	case *ast.MemberExpression:
+		prt.expression(expr.Object, parser.CALL)
+		prt.out.WriteString("." + expr.Property)
+	case *ast.HashLiteral:
+		keys := expr.Keys()
+		prt.list("{", keys, expr.Close.Pos, "}", func(idx int) {
+			prt.expression(keys[idx], parser.LOWEST)
+			prt.out.WriteString(": ")
+			prt.expression(expr.Pairs[keys[idx]], parser.LOWEST)
+		})
+	}
+	if grouped {
+		prt.out.WriteString(")")
+	}
+}
+
+func (prt *printer) expressionList(open string, list []ast.Expression, end token.Position, close string) {
+	prt.list(open, list, end, close, func(idx int) {
+		prt.expression(list[idx], parser.LOWEST)
+	})
+}
+
+// list prints the elements of an array, hash or call between open and
+// close, print printing the element at an index. A list is kept on one line
+// unless one of its elements started a line in the source or a comment sits
+// between its elements, which is then printed next to the element it
+// follows. Otherwise each element goes on a line of its own, followed by a
+// comma.
+func (prt *printer) list(open string, elements []ast.Expression, end token.Position, close string, print func(idx int)) {
+	limit := func(idx int) token.Position {
+		if idx+1 < len(elements) {
+			return elements[idx+1].Pos()
+		}
+		return end
+	}
+	if !prt.brokenList(elements) {
+		mark, next := prt.out.Len(), prt.next
+		prt.out.WriteString(open)
+		inline := len(elements) > 0 || !prt.hasCommentBefore(end)
+		if len(elements) > 0 && prt.hasCommentBefore(elements[0].Pos()) {
+			inline = false
+		}
+		for idx := 0; inline && idx < len(elements); idx++ {
+			if idx > 0 {
+				prt.out.WriteString(", ")
+			}
+			print(idx)
+			inline = !prt.hasCommentBefore(limit(idx))
+		}
+		if inline {
+			prt.out.WriteString(close)
+			return
+		}
+		out := prt.out.String()[:mark]
+		prt.out.Reset()
+		prt.out.WriteString(out)
+		prt.next = next
+	}
+
+	prt.out.WriteString(open + "\n")
+	prt.indent++
+	first := true
+	for idx := range elements {
+		first = prt.commentsBefore(elements[idx].Pos(), first)
+		prt.writeIndent()
+		print(idx)
+		prt.out.WriteString(",")
+		prt.trailingComment(limit(idx))
+		prt.out.WriteString("\n")
+		first = false
+	}
+	prt.commentsBefore(end, first)
+	prt.indent--
+	prt.writeIndent()
+	prt.out.WriteString(close)
+}
+
+// brokenList reports whether an element of a list starts a line of the
+// source, as the elements of a list written one per line do.
+func (prt *printer) brokenList(elements []ast.Expression) bool {
+	for _, elem := range elements {
+		pos := elem.Pos()
+		if pos.Line < 1 || pos.Line > len(prt.lines) {
+			continue
+		}
+		line := prt.lines[pos.Line-1]
+		if pos.Column-1 <= len(line) && strings.TrimSpace(line[:pos.Column-1]) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// class prints a class declaration with one method per line.
+func (prt *printer) class(stmt *ast.ClassStatement) {
+	prt.out.WriteString("class " + stmt.Name.Value)
+	if stmt.Super != nil {
+		prt.out.WriteString(" extends " + stmt.Super.Value)
+	}
+	if len(stmt.Methods) == 0 && !prt.hasCommentBefore(stmt.Close.Pos) {
+		prt.out.WriteString(" {}")
+		return
+	}
+	prt.out.WriteString(" {\n")
+	prt.indent++
+	first := true
+	for _, method := range stmt.Methods {
+		first = prt.commentsBefore(method.Pos(), first)
+		if !first && prt.blankLineBefore(method.Pos().Line) {
+			prt.out.WriteString("\n")
+		}
+		prt.writeIndent()
+		prt.out.WriteString(method.Name + "(")
+		prt.parameters(method.Function, len(ast.SelfParameters))
+		prt.out.WriteString(") ")
+		prt.block(method.Function.Body)
+		prt.out.WriteString("\n")
+		first = false
+	}
+	prt.commentsBefore(stmt.Close.Pos, first)
+	prt.indent--
+	prt.writeIndent()
+	prt.out.WriteString("}")
+}
+
+// match prints a match expression with one arm per line, each followed by
+// a comma. Matches that were written on a single line without comments stay
+// on a single line.
+func (prt *printer) match(expr *ast.MatchExpression) {
+	prt.out.WriteString("match (")
+	prt.expression(expr.Subject, parser.LOWEST)
+	if !prt.hasCommentBefore(expr.Close.Pos) && expr.Token.Pos.Line == expr.Close.Pos.Line {
+		arms, ok := prt.oneLine(func(inline *printer) {
+			for idx, arm := range expr.Arms {
+				if idx > 0 {
+					inline.out.WriteString(", ")
+				}
+				inline.arm(arm)
+			}
+		})
+		if ok {
+			prt.out.WriteString(") { " + arms + " }")
+			return
+		}
+	}
+	prt.out.WriteString(") {\n")
+	prt.indent++
+	first := true
+	for idx, arm := range expr.Arms {
+		first = prt.commentsBefore(arm.Pos(), first)
+		if !first && prt.blankLineBefore(arm.Pos().Line) {
+			prt.out.WriteString("\n")
+		}
+		limit := expr.Close.Pos
+		if idx+1 < len(expr.Arms) {
+			limit = expr.Arms[idx+1].Pos()
+		}
+		prt.writeIndent()
+		prt.arm(arm)
+		prt.out.WriteString(",")
+		prt.trailingComment(limit)
+		prt.out.WriteString("\n")
+		first = false
+	}
+	prt.commentsBefore(expr.Close.Pos, first)
+	prt.indent--
+	prt.writeIndent()
+	prt.out.WriteString("}")
+}
+
+func (prt *printer) arm(arm *ast.MatchArm) {
+	prt.pattern(arm.Pattern)
+	if arm.Guard != nil {
+		prt.out.WriteString(" if ")
+		prt.expression(arm.Guard, parser.LOWEST)
+	}
+	prt.out.WriteString(" => ")
+	switch {
+	case arm.Body != nil:
+		prt.block(arm.Body)
+	case isHash(arm.Value):
+		// would be parsed as a block otherwise
+		prt.out.WriteString("(")
+		prt.expression(arm.Value, parser.LOWEST)
+		prt.out.WriteString(")")
+	default:
+		prt.expression(arm.Value, parser.LOWEST)
+	}
+}
+
+func isHash(expr ast.Expression) bool {
+	_, ok := expr.(*ast.HashLiteral)
+	return ok
+}
+
+func (prt *printer) pattern(pattern ast.Pattern) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		prt.out.WriteString(pattern.Value)
+	case *ast.LiteralPattern:
+		prt.expression(pattern.Value, parser.LOWEST)
+	case *ast.ArrayPattern:
+		prt.out.WriteString("[")
+		prt.patternList(pattern.Elements)
+		if pattern.Rest != nil {
+			if len(pattern.Elements) > 0 {
+				prt.out.WriteString(", ")
+			}
+			prt.out.WriteString("..." + pattern.Rest.Value)
+		}
+		prt.out.WriteString("]")
+	case *ast.HashPattern:
+		prt.out.WriteString("{")
+		for idx, key := range pattern.Keys {
+			if idx > 0 {
+				prt.out.WriteString(", ")
+			}
+			prt.expression(key, parser.LOWEST)
+			prt.out.WriteString(": ")
+			prt.pattern(pattern.Values[idx])
+		}
+		prt.out.WriteString("}")
+	case *ast.DefaultPattern:
+		prt.pattern(pattern.Pattern)
+		prt.out.WriteString(" = ")
+		prt.expression(pattern.Default, parser.LOWEST)
+	case *ast.VariantPattern:
+		prt.out.WriteString(pattern.Enum.Value + "." + pattern.Variant)
+		if len(pattern.Fields) > 0 {
+			prt.out.WriteString("(")
+			prt.patternList(pattern.Fields)
+			prt.out.WriteString(")")
+		}
+	}
+}
+
+// parameters prints the parameters of fn from index from on, as patterns
+// where they destructure their argument.
+func (prt *printer) parameters(fn *ast.FunctionLiteral, from int) {
+	for idx := from; idx < len(fn.Parameters); idx++ {
+		if idx > from {
+			prt.out.WriteString(", ")
+		}
+		if pattern := fn.Pattern(idx); pattern != nil {
+			prt.pattern(pattern)
+		} else {
+			prt.out.WriteString(fn.Parameters[idx].Value)
+		}
+	}
+}
+
+func (prt *printer) patternList(list []ast.Pattern) {
+	for idx, pattern := range list {
+		if idx > 0 {
+			prt.out.WriteString(", ")
+		}
+		prt.pattern(pattern)
+	}
+}
+
+// block prints a block statement. Blocks that were written on a single line
+// with at most one statement and no comments stay on a single line.
+func (prt *printer) block(block *ast.BlockStatement) {
+	commented := prt.hasCommentBefore(block.Close.Pos)
+	if !commented && len(block.Statements) == 0 {
+		prt.out.WriteString("{}")
+		return
+	}
+	if !commented && len(block.Statements) == 1 && block.Token.Pos.Line == block.Close.Pos.Line {
+		stmt, ok := prt.oneLine(func(inline *printer) {
+			inline.statement(block.Statements[0])
+		})
+		if ok {
+			prt.out.WriteString("{ " + stmt + " }")
+			return
+		}
+	}
+	prt.out.WriteString("{\n")
+	prt.indent++
+	prt.statements(block.Statements, block.Close.Pos)
+	prt.indent--
+	prt.writeIndent()
+	prt.out.WriteString("}")
+}
+
+// oneLine runs print on a printer of its own, which has no comments to
+// print, and returns its output unless it spans lines. Constructs written on
+// one line in the source are kept on one line only when that holds, since
+// they would be laid out differently the next time otherwise.
+func (prt *printer) oneLine(print func(inline *printer)) (string, bool) {
+	inline := &printer{indent: prt.indent, lines: prt.lines}
+	print(inline)
+	out := inline.out.String()
+	return out, !strings.Contains(out, "\n")
+}
+
+func precedenceOf(expr ast.Expression) int {
+	switch expr := expr.(type) {
+	case *ast.InfixExpression:
+		return parser.Precedence(expr.Token.Type)
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.CallExpression:
+		return parser.CALL
+	case *ast.IndexExpression, *ast.PostfixExpression, *ast.MemberExpression:
+		return parser.INDEX
+	default:
+		return parser.INDEX + 1
+	}
+}
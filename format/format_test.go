@@ -0,0 +1,155 @@
+package format
+
+import "testing"
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"let   x=5",
+			"let x = 5;\n",
+		},
+		{
+			"let add = func(x,y){x+y}; add(1,2)",
+			"let add = func(x, y) { x + y };\nadd(1, 2);\n",
+		},
+		{
+			"let f = func(x) {\nreturn x*2\n}",
+			"let f = func(x) {\n\treturn x * 2;\n};\n",
+		},
+		{
+			"(1 + 2) * 3; 1 + (2 * 3); 1 - (2 - 3); (1 - 2) - 3; -(1 + 2); !(true == false)",
+			"(1 + 2) * 3;\n1 + 2 * 3;\n1 - (2 - 3);\n1 - 2 - 3;\n-(1 + 2);\n!(true == false);\n",
+		},
+		{
+			`[1,2][0]; {"b":2,"a":1}["a"]; f(x)[1]`,
+			"[1, 2][0];\n{\"b\": 2, \"a\": 1}[\"a\"];\nf(x)[1];\n",
+		},
+		{
+			"if (x) {\n1\n} else {\n2\n}\nlet y = 1",
+			"if (x) {\n\t1;\n} else {\n\t2;\n}\nlet y = 1;\n",
+		},
+		{
+			`import   "lib.fl"  as lib
+import {a,b}from"lib.fl"
+export   let x=a`,
+			"import \"lib.fl\" as lib;\nimport { a, b } from \"lib.fl\";\nexport let x = a;\n",
+		},
+		{
+			"let f = func() { defer   close( x ) ; 1 }",
+			"let f = func() {\n\tdefer close(x);\n\t1;\n};\n",
+		},
+		{
+			"user . name=req.header( \"X\" ).len ;(-a).b",
+			"user.name = req.header(\"X\").len;\n(-a).b;\n",
+		},
+		{
+			"struct Point {\n\tx,y\n}; struct Unit { }\nPoint(1, 2).x",
+			"struct Point { x, y }\nstruct Unit {}\nPoint(1, 2).x;\n",
+		},
+		{
+			"class Dog extends Animal{init( name ){super.init(name)}\n// loud\nspeak(){ \"woof\" }} class Unit { }\nd instanceof  Dog",
+			"class Dog extends Animal {\n\tinit(name) { super.init(name) }\n\t// loud\n\tspeak() { \"woof\" }\n}\nclass Unit {}\nd instanceof Dog;\n",
+		},
+		{
+			"enum Shape{Circle( r ),\nEmpty,}\nlet a=match(s){Shape.Circle(r)if r>0=>r,[x,...xs]=>{x}_=>({\"k\":-1})}",
+			"enum Shape { Circle(r), Empty }\nlet a = match (s) { Shape.Circle(r) if r > 0 => r, [x, ...xs] => { x }, _ => ({\"k\": -1}) };\n",
+		},
+		{
+			"let [a,b=1,...c]=xs;let {\"n\":n,\"t\":[t]=[]}=h\nlet f=func( [x,y] ,{\"k\":k}){x}",
+			"let [a, b = 1, ...c] = xs;\nlet {\"n\": n, \"t\": [t] = []} = h;\nlet f = func([x, y], {\"k\": k}) { x };\n",
+		},
+		{
+			"class A { m( [x] ) { x } }",
+			"class A {\n\tm([x]) { x }\n}\n",
+		},
+		{
+			"match (x) {\n1 => {puts(1); 2}, // one\n// other\n_ => 3 }",
+			"match (x) {\n\t1 => {\n\t\tputs(1);\n\t\t2;\n\t}, // one\n\t// other\n\t_ => 3,\n}\n",
+		},
+		{
+			"match (x) { 1 => { let y = x; y * 2 }, _ => 0 }",
+			"match (x) {\n\t1 => {\n\t\tlet y = x;\n\t\ty * 2;\n\t},\n\t_ => 0,\n}\n",
+		},
+		{
+			"let f = match (x) { 1 => func() { match (x) { _ => { 1; 2 } } }, _ => 0 };",
+			"let f = match (x) {\n\t1 => func() {\n\t\tmatch (x) {\n\t\t\t_ => {\n\t\t\t\t1;\n\t\t\t\t2;\n\t\t\t},\n\t\t}\n\t},\n\t_ => 0,\n};\n",
+		},
+		{
+			"let n = parse_int( s )? ;(-f(x)?)[0]",
+			"let n = parse_int(s)?;\n(-f(x)?)[0];\n",
+		},
+		{
+			"if (x) { 1 };\n(y)",
+			"if (x) { 1 }\ny;\n",
+		},
+		{
+			"if (x) { 1 };\n[y]; if (x) { 1 }; -y",
+			"if (x) { 1 };\n[y];\nif (x) { 1 };\n-y;\n",
+		},
+		{
+			"try {\nthrow \"x\"\n} catch(e) {\ne\n} finally { 1 };\n[y]",
+			"try {\n\tthrow \"x\";\n} catch (e) {\n\te;\n} finally { 1 };\n[y];\n",
+		},
+		{
+			"let v = try { f() } catch { 0 }",
+			"let v = try { f() } catch { 0 };\n",
+		},
+		{
+			"let f = func() {\n\n}",
+			"let f = func() {};\n",
+		},
+		{
+			"// leading\nlet a = 1;   // trailing\n\n\n\n// before b\nlet b = 2;\n// last",
+			"// leading\nlet a = 1; // trailing\n\n// before b\nlet b = 2;\n// last\n",
+		},
+		{
+			"let f = func() {\n  // first\n  1; // one\n\n  2\n  // end\n};",
+			"let f = func() {\n\t// first\n\t1; // one\n\n\t2;\n\t// end\n};\n",
+		},
+		{
+			"let xs = [\n  1, // one\n  // before two\n  2,\n  3\n];\nlet ys = [1,\n2]",
+			"let xs = [\n\t1, // one\n\t// before two\n\t2,\n\t3,\n];\nlet ys = [\n\t1,\n\t2,\n];\n",
+		},
+		{
+			"let h = {\"a\": 1,\n  \"b\": [4, 5], // bee\n};\nputs(x,\n  h)",
+			"let h = {\n\t\"a\": 1,\n\t\"b\": [4, 5], // bee\n};\nputs(\n\tx,\n\th,\n);\n",
+		},
+		{
+			"let xs = [1, 2, // two\n3]; let e = [\n// none yet\n]",
+			"let xs = [\n\t1,\n\t2, // two\n\t3,\n];\nlet e = [\n\t// none yet\n];\n",
+		},
+		{
+			"f(func(x) {\n  // inside\n  x\n}, [1,\n2])",
+			"f(func(x) {\n\t// inside\n\tx;\n}, [\n\t1,\n\t2,\n]);\n",
+		},
+		{
+			"let xs = [1, 2,]; f(\n\n)",
+			"let xs = [1, 2];\nf();\n",
+		},
+	}
+	for _, tt := range tests {
+		formatted, err := Source([]byte(tt.input))
+		if err != nil {
+			t.Fatalf("Source(%q) returned error: %s", tt.input, err)
+		}
+		if string(formatted) != tt.expected {
+			t.Errorf("Source(%q) wrong.\nexpected=%q\ngot=%q", tt.input, tt.expected, formatted)
+		}
+		again, err := Source(formatted)
+		if err != nil {
+			t.Fatalf("Source(%q) returned error: %s", formatted, err)
+		}
+		if string(again) != string(formatted) {
+			t.Errorf("Source is not idempotent.\nfirst=%q\nsecond=%q", formatted, again)
+		}
+	}
+}
+
+func TestSourceParseError(t *testing.T) {
+	if _, err := Source([]byte("let = 5;")); err == nil {
+		t.Errorf("expected an error for invalid source")
+	}
+}
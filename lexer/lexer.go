@@ -1,6 +1,8 @@
 package lexer
 
 import (
+	"strings"
+
 	"Interpreter_in_Go/token"
 )
 
@@ -9,15 +11,24 @@ type Lexer struct {
 	position     int // current position in input (points to current char)
 	readPosition int // current reading position in input (after reading char)
 	char         byte
+
+	line   int // line of the current char, 1-based
+	column int // column of the current char, 1-based
 }
 
 func NewLexer(input string) *Lexer {
-	lex := &Lexer{input: input}
+	lex := &Lexer{input: input, line: 1}
 	lex.readChar()
 	return lex
 }
 
 func (lex *Lexer) readChar() {
+	if lex.char == '\n' {
+		lex.line++
+		lex.column = 0
+	}
+	lex.column++
+
 	if lex.readPosition >= len(lex.input) {
 		lex.char = 0
 	} else {
@@ -36,12 +47,24 @@ func (lex *Lexer) peekChar() byte {
 }
 
 func (lex *Lexer) NextToken() token.Token {
-	var tokn token.Token
 	lex.skipWhiteSpace()
 
+	pos := token.Position{Line: lex.line, Column: lex.column}
+	tokn := lex.readToken()
+	tokn.Pos = pos
+	return tokn
+}
+
+func (lex *Lexer) readToken() token.Token {
+	var tokn token.Token
+
 	switch lex.char {
 	case '=':
-		tokn = lex.readTwoCharToken('=', token.EQ, token.ASSIGN)
+		if lex.peekChar() == '>' {
+			tokn = lex.readTwoCharToken('>', token.ARROW, token.ASSIGN)
+		} else {
+			tokn = lex.readTwoCharToken('=', token.EQ, token.ASSIGN)
+		}
 	case '+':
 		tokn = newToken(token.PLUS, lex.char)
 	case '-':
@@ -49,6 +72,11 @@ func (lex *Lexer) NextToken() token.Token {
 	case '!':
 		tokn = lex.readTwoCharToken('=', token.NOT_EQ, token.BANG)
 	case '/':
+		if lex.peekChar() == '/' {
+			tokn.Type = token.COMMENT
+			tokn.Literal = lex.readComment()
+			return tokn
+		}
 		tokn = newToken(token.SLASH, lex.char)
 	case '*':
 		tokn = newToken(token.ASTERISK, lex.char)
@@ -56,12 +84,22 @@ func (lex *Lexer) NextToken() token.Token {
 		tokn = newToken(token.LT, lex.char)
 	case '>':
 		tokn = newToken(token.GT, lex.char)
+	case '?':
+		tokn = newToken(token.QUESTION, lex.char)
 	case ';':
 		tokn = newToken(token.SEMICOLON, lex.char)
 	case ',':
 		tokn = newToken(token.COMMA, lex.char)
 	case ':':
 		tokn = newToken(token.COLON, lex.char)
+	case '.':
+		if lex.peekChar() == '.' && lex.readPosition+1 < len(lex.input) && lex.input[lex.readPosition+1] == '.' {
+			lex.readChar()
+			lex.readChar()
+			tokn = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else {
+			tokn = newToken(token.DOT, lex.char)
+		}
 	case '(':
 		tokn = newToken(token.L_PAREN, lex.char)
 	case ')':
@@ -116,6 +154,14 @@ func (lex *Lexer) readString() string {
 	return lex.input[position:lex.position]
 }
 
+func (lex *Lexer) readComment() string {
+	position := lex.position
+	for lex.char != '\n' && lex.char != 0 {
+		lex.readChar()
+	}
+	return strings.TrimRight(lex.input[position:lex.position], " \t\r")
+}
+
 func (lex *Lexer) readDefaultToken() token.Token {
 	var tokn token.Token
 
@@ -28,6 +28,9 @@ if (5 < 10) {
 "foobar"
 "foo bar"
 [1, 2];
+x?;
+user.name;
+[a, ...b] => c.d
 `
 
 	tests := []struct {
@@ -114,6 +117,23 @@ if (5 < 10) {
 		{token.INT, "2"},
 		{token.R_BRACKET, "]"},
 		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.QUESTION, "?"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "user"},
+		{token.DOT, "."},
+		{token.IDENT, "name"},
+		{token.SEMICOLON, ";"},
+		{token.L_BRACKET, "["},
+		{token.IDENT, "a"},
+		{token.COMMA, ","},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "b"},
+		{token.R_BRACKET, "]"},
+		{token.ARROW, "=>"},
+		{token.IDENT, "c"},
+		{token.DOT, "."},
+		{token.IDENT, "d"},
 		{token.EOF, ""},
 	}
 
@@ -131,3 +151,43 @@ if (5 < 10) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := `let x = 5; // five
+x / "a
+b" == 1`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{token.LET, "let", 1, 1},
+		{token.IDENT, "x", 1, 5},
+		{token.ASSIGN, "=", 1, 7},
+		{token.INT, "5", 1, 9},
+		{token.SEMICOLON, ";", 1, 10},
+		{token.COMMENT, "// five", 1, 12},
+		{token.IDENT, "x", 2, 1},
+		{token.SLASH, "/", 2, 3},
+		{token.STRING, "a\nb", 2, 5},
+		{token.EQ, "==", 3, 4},
+		{token.INT, "1", 3, 7},
+		{token.EOF, "", 3, 8},
+	}
+
+	lex := NewLexer(input)
+	for i, test := range tests {
+		tok := lex.NextToken()
+
+		if tok.Type != test.expectedType || tok.Literal != test.expectedLiteral {
+			t.Fatalf("tests[%d] - token wrong. expected=%q %q, got=%q %q",
+				i, test.expectedType, test.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if tok.Pos.Line != test.expectedLine || tok.Pos.Column != test.expectedColumn {
+			t.Fatalf("tests[%d] - position wrong. expected=%d:%d, got=%s",
+				i, test.expectedLine, test.expectedColumn, tok.Pos)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"Interpreter_in_Go/lexer"
+	"Interpreter_in_Go/lint"
+	"Interpreter_in_Go/parser"
+)
+
+// fileDiagnostic is a lint.Diagnostic along with the file it was found in,
+// as printed by 'flint lint -json'.
+type fileDiagnostic struct {
+	File string `json:"file"`
+	lint.Diagnostic
+}
+
+// lintCommand implements 'flint lint [-json] [-severity rule=level,...]
+// [path ...]'. The exit code is 1 when an error level diagnostic was found.
+func lintCommand(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the diagnostics as a JSON array")
+	severities := flags.String("severity", "", "comma separated rule=level overrides, level being off, info, warning or error")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	config, err := lintConfig(*severities)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	names := []string{""}
+	if flags.NArg() > 0 {
+		if names, err = sourceFiles(flags.Args()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	var diagnostics []fileDiagnostic
+	for _, name := range names {
+		name, src, err := readSource(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		psr := parser.NewParser(lexer.NewLexer(string(src)))
+		root := psr.ParseRootStatement()
+
+		for _, msg := range psr.Errors() {
+			diag := lint.Diagnostic{Rule: "syntax", Severity: lint.Error, Message: msg}
+			diagnostics = append(diagnostics, fileDiagnostic{name, diag})
+		}
+		if len(psr.Errors()) != 0 {
+			continue
+		}
+		for _, diag := range lint.Run(root, config) {
+			diagnostics = append(diagnostics, fileDiagnostic{name, diag})
+		}
+	}
+	if *asJSON {
+		printJSONDiagnostics(diagnostics)
+	} else {
+		for _, diag := range diagnostics {
+			fmt.Printf("%s:%s\n", diag.File, diag.Diagnostic)
+		}
+	}
+	for _, diag := range diagnostics {
+		if diag.Severity == lint.Error {
+			return 1
+		}
+	}
+	return 0
+}
+
+func lintConfig(severities string) (lint.Config, error) {
+	config := lint.Config{Severity: make(map[string]lint.Severity)}
+	if severities == "" {
+		return config, nil
+	}
+	for _, setting := range strings.Split(severities, ",") {
+		rule, level, ok := strings.Cut(setting, "=")
+		if !ok {
+			return config, fmt.Errorf("invalid severity setting %q, want rule=level", setting)
+		}
+		severity, err := lint.ParseSeverity(level)
+		if err != nil {
+			return config, err
+		}
+		config.Severity[rule] = severity
+	}
+	return config, nil
+}
+
+func printJSONDiagnostics(diagnostics []fileDiagnostic) {
+	if diagnostics == nil {
+		diagnostics = []fileDiagnostic{}
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(diagnostics)
+}
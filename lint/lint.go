@@ -0,0 +1,218 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/token"
+)
+
+// Severity ranks diagnostics. Off disables a rule entirely.
+type Severity int
+
+const (
+	Off Severity = iota
+	Info
+	Warning
+	Error
+)
+
+var severityNames = map[Severity]string{
+	Off:     "off",
+	Info:    "info",
+	Warning: "warning",
+	Error:   "error",
+}
+
+func (sev Severity) String() string {
+	if name, ok := severityNames[sev]; ok {
+		return name
+	}
+	return fmt.Sprintf("Severity(%d)", int(sev))
+}
+
+func (sev Severity) MarshalText() ([]byte, error) {
+	return []byte(sev.String()), nil
+}
+
+// ParseSeverity is the inverse of Severity.String.
+func ParseSeverity(name string) (Severity, error) {
+	for sev, sevName := range severityNames {
+		if sevName == name {
+			return sev, nil
+		}
+	}
+	return Off, fmt.Errorf("unknown severity %q", name)
+}
+
+// Rule is a single check run over a parsed program.
+type Rule struct {
+	Name     string   // identifier used in configuration and suppressions
+	Doc      string   // one line description
+	Severity Severity // severity unless configured otherwise
+	Run      func(pass *Pass)
+}
+
+// Diagnostic is a problem reported by a rule.
+type Diagnostic struct {
+	Rule     string         `json:"rule"`
+	Severity Severity       `json:"severity"`
+	Pos      token.Position `json:"-"`
+	Line     int            `json:"line"`
+	Column   int            `json:"column"`
+	Message  string         `json:"message"`
+}
+
+func (diag Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", diag.Pos, diag.Severity, diag.Message, diag.Rule)
+}
+
+// Pass is handed to a rule's Run function. It carries the program along with
+// the name resolution shared by all rules.
+type Pass struct {
+	Root       *ast.RootStatement
+	Resolution *Resolution
+
+	rule        *Rule
+	severity    Severity
+	diagnostics []Diagnostic
+}
+
+// Reportf records a diagnostic of the running rule at pos.
+func (pass *Pass) Reportf(pos token.Position, format string, args ...any) {
+	pass.diagnostics = append(pass.diagnostics, Diagnostic{
+		Rule:     pass.rule.Name,
+		Severity: pass.severity,
+		Pos:      pos,
+		Line:     pos.Line,
+		Column:   pos.Column,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Config selects the rules to run and overrides their severities. A nil
+// Rules runs DefaultRules.
+type Config struct {
+	Rules    []*Rule
+	Severity map[string]Severity
+}
+
+// Run checks root with the configured rules and returns the diagnostics that
+// were not suppressed, ordered by position.
+//
+// A comment of the form '// lint:ignore rule[,rule...] [reason]' suppresses
+// the named rules on its own line and, unless it follows code on that line,
+// on the line after it, and
+// '// lint:file-ignore rule[,rule...]' suppresses them for the whole file.
+// The rule name 'all' matches every rule.
+func Run(root *ast.RootStatement, config Config) []Diagnostic {
+	rules := config.Rules
+	if rules == nil {
+		rules = DefaultRules
+	}
+	resolution := Resolve(root)
+	suppressed := suppressions(root)
+
+	var diagnostics []Diagnostic
+	for _, rule := range rules {
+		severity := rule.Severity
+		if sev, ok := config.Severity[rule.Name]; ok {
+			severity = sev
+		}
+		if severity == Off {
+			continue
+		}
+		pass := &Pass{Root: root, Resolution: resolution, rule: rule, severity: severity}
+		rule.Run(pass)
+
+		for _, diag := range pass.diagnostics {
+			if !suppressed.matches(diag) {
+				diagnostics = append(diagnostics, diag)
+			}
+		}
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Pos.Before(diagnostics[j].Pos)
+	})
+	return diagnostics
+}
+
+const (
+	ignoreDirective     = "lint:ignore"
+	fileIgnoreDirective = "lint:file-ignore"
+)
+
+// suppression lists the rules ignored per line; line 0 holds the rules
+// ignored in the whole file.
+type suppression map[int][]string
+
+func suppressions(root *ast.RootStatement) suppression {
+	suppressed := suppression{}
+	starts := codeStarts(root)
+
+	for _, comment := range root.Comments {
+		fields := strings.Fields(strings.TrimPrefix(comment.Text, "//"))
+		if len(fields) < 2 {
+			continue
+		}
+		rules := strings.Split(fields[1], ",")
+		line := comment.Pos().Line
+
+		switch fields[0] {
+		case ignoreDirective:
+			suppressed[line] = append(suppressed[line], rules...)
+			if column, ok := starts[line]; !ok || column > comment.Pos().Column {
+				suppressed[line+1] = append(suppressed[line+1], rules...)
+			}
+		case fileIgnoreDirective:
+			suppressed[0] = append(suppressed[0], rules...)
+		}
+	}
+	return suppressed
+}
+
+// codeStarts maps each line holding code to the column where its first node
+// or closing bracket starts.
+func codeStarts(root *ast.RootStatement) map[int]int {
+	starts := map[int]int{}
+	mark := func(pos token.Position) {
+		if column, ok := starts[pos.Line]; !ok || pos.Column < column {
+			starts[pos.Line] = pos.Column
+		}
+	}
+	ast.Inspect(root, func(node ast.Node) bool {
+		if node == nil {
+			return false
+		}
+		mark(node.Pos())
+		switch node := node.(type) {
+		case *ast.BlockStatement:
+			mark(node.Close.Pos)
+		case *ast.ClassStatement:
+			mark(node.Close.Pos)
+		case *ast.CallExpression:
+			mark(node.Close.Pos)
+		case *ast.ArrayLiteral:
+			mark(node.Close.Pos)
+		case *ast.HashLiteral:
+			mark(node.Close.Pos)
+		case *ast.MatchExpression:
+			mark(node.Close.Pos)
+		}
+		return true
+	})
+	return starts
+}
+
+func (sup suppression) matches(diag Diagnostic) bool {
+	for _, line := range []int{0, diag.Pos.Line} {
+		for _, rule := range sup[line] {
+			if rule == diag.Rule || rule == "all" {
+				return true
+			}
+		}
+	}
+	return false
+}
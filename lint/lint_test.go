@@ -0,0 +1,163 @@
+package lint
+
+import (
+	"fmt"
+	"testing"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/lexer"
+	"Interpreter_in_Go/parser"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{
+			"let x = 1; let y = x; puts(y);",
+			nil,
+		},
+		{
+			"let x = 1; let _y = 2;",
+			[]string{"1:5 unused-binding"},
+		},
+		{
+			`import { a } from "lib.fl"; import "lib.fl" as lib; export let x = 1;`,
+			nil,
+		},
+		{
+			"let len = func(x) { x }; len([1]);",
+			[]string{"1:5 shadowed-builtin"},
+		},
+		{
+			"let f = func() { return 1; puts(2); 3 }; f();",
+			[]string{"1:28 unreachable-code"},
+		},
+		{
+			"let f = func(x) { if (x) { return 1; } else { return 2; } x }; f(1);",
+			[]string{"1:59 unreachable-code"},
+		},
+		{
+			"let f = func() { throw \"x\"; 1 }; try { f() } catch (e) { 2 };",
+			[]string{"1:29 unreachable-code"},
+		},
+		{
+			"let add = func(a, b) { a + b }; add(1); add(1, 2); push([1]);",
+			[]string{"1:33 wrong-arity", "1:52 wrong-arity"},
+		},
+		{
+			"error(\"x\"); error(\"x\", \"K\"); error(); len([], []); puts();",
+			[]string{"1:30 wrong-arity", "1:39 wrong-arity"},
+		},
+		{
+			"puts(x); let f = func() { y }; f(); let x = 1;",
+			[]string{"1:6 undefined-name", "1:27 undefined-name", "1:41 unused-binding"},
+		},
+		{
+			"let even = func(n) { odd(n) }; let odd = func(n) { even(n) }; odd(1);",
+			nil,
+		},
+		{
+			"let x = x;",
+			[]string{"1:5 unused-binding", "1:9 undefined-name"},
+		},
+		{
+			"let x = 1; // lint:ignore unused-binding\n// lint:ignore all\nlet y = z;",
+			nil,
+		},
+		{
+			"let x = 1; // lint:ignore unused-binding\nlet y = 2;",
+			[]string{"2:5 unused-binding"},
+		},
+		{
+			"let f = func() { 1 } // lint:ignore unused-binding\nlet y = 2;",
+			[]string{"2:5 unused-binding"},
+		},
+		{
+			"// lint:file-ignore undefined-name,unused-binding\nlet y = z;",
+			nil,
+		},
+		{
+			"let [a, b = c] = [1]; let f = func([x], {1: y}) { a + b + x + y + z }; f([1], {});",
+			[]string{"1:13 undefined-name", "1:67 undefined-name"},
+		},
+		{
+			"enum E { A, B(x) } let f = func(e) { match (e) { E.A => 1, E.B(1) => 2 } }; f(E.A);",
+			[]string{"1:38 non-exhaustive-match"},
+		},
+		{
+			"enum E { A, B(x) } let f = func(e) { match (e) { E.A => 1, E.B(x) if x > 0 => x } }; f(E.A);",
+			[]string{"1:38 non-exhaustive-match"},
+		},
+		{
+			"enum E { A, B(x) } let f = func(e) { match (e) { E.A => 1, E.B(x) => x } }; f(E.A);",
+			nil,
+		},
+		{
+			"enum E { A, B(x) } let f = func(e) { match (e) { E.A => 1, _ => 2 } }; f(E.A);",
+			nil,
+		},
+		{
+			"let f = func(v) { match (v) { [first, ...rest] => rest, _ => first(v) } }; f([]); let len = 1;",
+			[]string{"1:32 shadowed-builtin", "1:42 shadowed-builtin", "1:87 unused-binding", "1:87 shadowed-builtin"},
+		},
+		{
+			"let f = func(v) { match (v) { [x] => x, _ => x } }; f(1);",
+			[]string{"1:46 undefined-name"},
+		},
+	}
+	for _, tt := range tests {
+		diagnostics := Run(parse(t, tt.input), Config{})
+
+		var got []string
+		for _, diag := range diagnostics {
+			got = append(got, fmt.Sprintf("%s %s", diag.Pos, diag.Rule))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+			t.Errorf("wrong diagnostics for %q.\nexpected=%v\ngot=%v", tt.input, tt.expected, diagnostics)
+		}
+	}
+}
+
+func TestConfigSeverity(t *testing.T) {
+	root := parse(t, "let x = y;")
+	config := Config{Severity: map[string]Severity{"unused-binding": Off, "undefined-name": Info}}
+
+	diagnostics := Run(root, config)
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic. got=%v", diagnostics)
+	}
+	if diagnostics[0].Rule != "undefined-name" || diagnostics[0].Severity != Info {
+		t.Errorf("wrong diagnostic. got=%s", diagnostics[0])
+	}
+}
+
+func TestCustomRule(t *testing.T) {
+	noStrings := &Rule{
+		Name:     "no-strings",
+		Severity: Error,
+		Run: func(pass *Pass) {
+			ast.Inspect(pass.Root, func(node ast.Node) bool {
+				if str, ok := node.(*ast.StringLiteral); ok {
+					pass.Reportf(str.Pos(), "string %q", str.Value)
+				}
+				return true
+			})
+		},
+	}
+	diagnostics := Run(parse(t, `puts("a", 1, "b");`), Config{Rules: []*Rule{noStrings}})
+	if len(diagnostics) != 2 || diagnostics[1].String() != `1:14: error: string "b" (no-strings)` {
+		t.Errorf("wrong diagnostics. got=%v", diagnostics)
+	}
+}
+
+func parse(t *testing.T, input string) *ast.RootStatement {
+	t.Helper()
+	psr := parser.NewParser(lexer.NewLexer(input))
+	root := psr.ParseRootStatement()
+	if len(psr.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", psr.Errors())
+	}
+	return root
+}
@@ -0,0 +1,89 @@
+package lint
+
+import (
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/evaluator"
+	"Interpreter_in_Go/scope"
+)
+
+// Binding is a name introduced by a let statement, a function parameter, a
+// catch block or an import.
+type Binding struct {
+	Name  *ast.Identifier
+	Value ast.Expression // the bound expression; nil for parameters, caught errors and imports
+	Uses  []*ast.Identifier
+
+	// Exported is set for the let of an export statement, which may be used
+	// by the importers of the module.
+	Exported bool
+
+	// Redeclared is set when another binding of the same name follows in
+	// the same scope, so uses may refer to either of them.
+	Redeclared bool
+}
+
+// Resolution is the result of resolving the identifiers of a program.
+type Resolution struct {
+	Bindings  []*Binding                   // in declaration order
+	Uses      map[*ast.Identifier]*Binding // identifier uses bound to a name
+	BuiltIns  map[*ast.Identifier]string   // identifier uses of builtins
+	Undefined []*ast.Identifier            // identifier uses bound to nothing
+}
+
+// Resolve binds every identifier use in root to its declaration the way the
+// evaluator would look it up. Statements are resolved in order, so a use
+// only sees names declared before it, except inside function bodies: those
+// run after the enclosing scope has been set up and see all of its names.
+func Resolve(root *ast.RootStatement) *Resolution {
+	res := &Resolution{
+		Uses:     make(map[*ast.Identifier]*Binding),
+		BuiltIns: make(map[*ast.Identifier]string),
+	}
+	scope.Walk[map[string]*Binding](root, resolver{res})
+	return res
+}
+
+// resolver fills in a Resolution as the scope.Handler of a walk. The Data
+// of each scope holds the bindings declared in it by name.
+type resolver struct {
+	res *Resolution
+}
+
+func (rsv resolver) Open(sc *scope.Scope[map[string]*Binding]) {
+	sc.Data = make(map[string]*Binding)
+}
+
+func (rsv resolver) Close(sc *scope.Scope[map[string]*Binding]) {}
+
+func (rsv resolver) Declare(sc *scope.Scope[map[string]*Binding], decl scope.Declaration) {
+	if decl.Repeated {
+		return
+	}
+	if previous, ok := sc.Data[decl.Name.Value]; ok {
+		previous.Redeclared = true
+	}
+	binding := &Binding{
+		Name:     decl.Name,
+		Value:    decl.Value,
+		Exported: decl.Kind == scope.Export,
+	}
+	sc.Data[decl.Name.Value] = binding
+	rsv.res.Bindings = append(rsv.res.Bindings, binding)
+}
+
+func (rsv resolver) Use(sc *scope.Scope[map[string]*Binding], ident *ast.Identifier) {
+	for ; sc != nil; sc = sc.Outer {
+		binding, ok := sc.Data[ident.Value]
+		if !ok {
+			continue
+		}
+		binding.Uses = append(binding.Uses, ident)
+		rsv.res.Uses[ident] = binding
+		return
+	}
+	if evaluator.IsBuiltIn(ident.Value) {
+		rsv.res.BuiltIns[ident] = ident.Value
+		return
+	}
+	rsv.res.Undefined = append(rsv.res.Undefined, ident)
+}
@@ -0,0 +1,234 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/evaluator"
+	"Interpreter_in_Go/object"
+)
+
+// DefaultRules are the rules run when no others are configured.
+var DefaultRules = []*Rule{
+	UnusedBinding,
+	ShadowedBuiltIn,
+	UnreachableCode,
+	WrongArity,
+	UndefinedName,
+	NonExhaustiveMatch,
+}
+
+var UnusedBinding = &Rule{
+	Name:     "unused-binding",
+	Doc:      "reports let bindings that are never used; exported names and names starting with '_' are exempt",
+	Severity: Warning,
+	Run: func(pass *Pass) {
+		for _, binding := range pass.Resolution.Bindings {
+			name := binding.Name.Value
+			if binding.Value == nil || len(binding.Uses) > 0 || binding.Exported || strings.HasPrefix(name, "_") {
+				continue
+			}
+			pass.Reportf(binding.Name.Pos(), "%s is declared but never used", name)
+		}
+	},
+}
+
+var ShadowedBuiltIn = &Rule{
+	Name:     "shadowed-builtin",
+	Doc:      "reports bindings named after a builtin, which hide the builtin where they are visible",
+	Severity: Warning,
+	Run: func(pass *Pass) {
+		for _, binding := range pass.Resolution.Bindings {
+			if name := binding.Name.Value; evaluator.IsBuiltIn(name) {
+				pass.Reportf(binding.Name.Pos(), "%s shadows the builtin of the same name", name)
+			}
+		}
+	},
+}
+
+var UnreachableCode = &Rule{
+	Name:     "unreachable-code",
+	Doc:      "reports statements following a return or throw in the same block",
+	Severity: Warning,
+	Run: func(pass *Pass) {
+		ast.Inspect(pass.Root, func(node ast.Node) bool {
+			var stmts []ast.Statement
+			switch node := node.(type) {
+			case *ast.RootStatement:
+				stmts = node.Statements
+			case *ast.BlockStatement:
+				stmts = node.Statements
+			}
+			for idx, stmt := range stmts {
+				if terminates(stmt) && idx+1 < len(stmts) {
+					pass.Reportf(stmts[idx+1].Pos(), "unreachable code")
+					break
+				}
+			}
+			return true
+		})
+	},
+}
+
+// terminates reports whether stmt always returns or throws, either directly
+// or through both branches of an if expression.
+func terminates(stmt ast.Statement) bool {
+	switch stmt := stmt.(type) {
+	case *ast.ReturnStatement, *ast.ThrowStatement:
+		return true
+	case *ast.ExpressionStatement:
+		ifExpr, ok := stmt.Expression.(*ast.IfExpression)
+		if !ok || ifExpr.Alternative == nil {
+			return false
+		}
+		return blockTerminates(ifExpr.Consequence) && blockTerminates(ifExpr.Alternative)
+	}
+	return false
+}
+
+func blockTerminates(block *ast.BlockStatement) bool {
+	for _, stmt := range block.Statements {
+		if terminates(stmt) {
+			return true
+		}
+	}
+	return false
+}
+
+var WrongArity = &Rule{
+	Name:     "wrong-arity",
+	Doc:      "reports calls whose argument count does not match the called builtin or let-bound function",
+	Severity: Error,
+	Run: func(pass *Pass) {
+		ast.Inspect(pass.Root, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpression)
+			if !ok {
+				return true
+			}
+			ident, ok := call.Function.(*ast.Identifier)
+			if !ok {
+				return true
+			}
+			least, most := 0, -1
+			if name, ok := pass.Resolution.BuiltIns[ident]; ok {
+				if builtIn := object.GetBuiltInByName(name); builtIn != nil {
+					least, most = builtIn.Arity()
+				}
+			} else if binding := pass.Resolution.Uses[ident]; binding != nil && !binding.Redeclared {
+				if fn, ok := binding.Value.(*ast.FunctionLiteral); ok {
+					least, most = len(fn.Parameters), len(fn.Parameters)
+				}
+			}
+			if got := len(call.Arguments); got < least || most >= 0 && got > most {
+				pass.Reportf(call.Pos(), "%s called with %d %s, want %s",
+					ident.Value, got, plural(got, "argument"), describeArity(least, most))
+			}
+			return true
+		})
+	},
+}
+
+var UndefinedName = &Rule{
+	Name:     "undefined-name",
+	Doc:      "reports identifiers that are not bound anywhere in scope",
+	Severity: Error,
+	Run: func(pass *Pass) {
+		for _, ident := range pass.Resolution.Undefined {
+			pass.Reportf(ident.Pos(), "%s is not defined", ident.Value)
+		}
+	},
+}
+
+// describeArity describes a number of arguments from least to most, most
+// being -1 when there is no limit.
+func describeArity(least, most int) string {
+	switch {
+	case most < 0:
+		return fmt.Sprintf("at least %d", least)
+	case least == most:
+		return fmt.Sprint(least)
+	case least+1 == most:
+		return fmt.Sprintf("%d or %d", least, most)
+	}
+	return fmt.Sprintf("%d to %d", least, most)
+}
+
+func plural(count int, word string) string {
+	if count == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+var NonExhaustiveMatch = &Rule{
+	Name:     "non-exhaustive-match",
+	Doc:      "reports matches on the variants of an enum that leave some variants out and have no catch-all arm",
+	Severity: Warning,
+	Run: func(pass *Pass) {
+		enums := map[*ast.Identifier]*ast.EnumStatement{}
+		ast.Inspect(pass.Root, func(node ast.Node) bool {
+			if stmt, ok := node.(*ast.EnumStatement); ok {
+				enums[stmt.Name] = stmt
+			}
+			return true
+		})
+		ast.Inspect(pass.Root, func(node ast.Node) bool {
+			match, ok := node.(*ast.MatchExpression)
+			if !ok {
+				return true
+			}
+			enum, covered := matchedVariants(pass.Resolution, enums, match)
+			if enum == nil {
+				return true
+			}
+			var missing []string
+			for _, variant := range enum.Variants {
+				if !covered[variant.Name] {
+					missing = append(missing, enum.Name.Value+"."+variant.Name)
+				}
+			}
+			if len(missing) > 0 {
+				pass.Reportf(match.Pos(), "match does not cover %s", strings.Join(missing, ", "))
+			}
+			return true
+		})
+	},
+}
+
+// matchedVariants returns the enum every arm of match matches a variant of,
+// along with the variants matched whatever their payload, or nil when the
+// arms do not all match variants of one known enum.
+func matchedVariants(res *Resolution, enums map[*ast.Identifier]*ast.EnumStatement,
+	match *ast.MatchExpression) (*ast.EnumStatement, map[string]bool) {
+	var enum *ast.EnumStatement
+	covered := map[string]bool{}
+	for _, arm := range match.Arms {
+		pattern, ok := arm.Pattern.(*ast.VariantPattern)
+		if !ok {
+			return nil, nil
+		}
+		binding := res.Uses[pattern.Enum]
+		if binding == nil || binding.Redeclared || enums[binding.Name] == nil {
+			return nil, nil
+		}
+		if enum != nil && enums[binding.Name] != enum {
+			return nil, nil
+		}
+		enum = enums[binding.Name]
+		if arm.Guard == nil && irrefutable(pattern.Fields) {
+			covered[pattern.Variant] = true
+		}
+	}
+	return enum, covered
+}
+
+// irrefutable reports whether patterns are all names, which match anything.
+func irrefutable(patterns []ast.Pattern) bool {
+	for _, pattern := range patterns {
+		if _, ok := pattern.(*ast.Identifier); !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"os/user"
@@ -8,12 +9,33 @@ import (
 	"Interpreter_in_Go/repl"
 )
 
+// commands maps a subcommand name to its implementation, which receives the
+// remaining arguments and returns the process exit code.
+var commands = map[string]func(args []string) int{
+	"build": buildCommand,
+	"fmt":   formatCommand,
+	"lint":  lintCommand,
+	"parse": parseCommand,
+	"run":   runCommand,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+	flags := flag.NewFlagSet("flint", flag.ExitOnError)
+	engine := engineFlag(flags)
+	_ = flags.Parse(os.Args[1:])
+	if !validEngine(*engine) {
+		os.Exit(2)
+	}
 	usr, err := user.Current()
 	if err != nil {
 		panic(err)
 	}
 	fmt.Printf("Hello %s! This is the monkey programming langauge!\n", usr.Username)
 	fmt.Printf("Feel free to type in commands\n")
-	repl.Start(os.Stdin, os.Stdout)
+	repl.Start(os.Stdin, os.Stdout, *engine)
 }
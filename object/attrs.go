@@ -0,0 +1,38 @@
+package object
+
+import "errors"
+
+// The interfaces below are implemented by host objects that scripts use
+// through the '.' operator. An object may implement any of them; the others
+// fail with an AttributeError.
+
+// AttrGetter is implemented by objects with attributes read as ob.name.
+// GetAttr reports false when the object has no attribute called name; a
+// returned *Error is raised.
+type AttrGetter interface {
+	Object
+	GetAttr(name string) (Object, bool)
+}
+
+// AttrSetter is implemented by objects with attributes assigned as
+// ob.name = value. SetAttr returns ErrNoAttribute when the object has no
+// attribute called name that can be set, or an error wrapping it to
+// describe that in its own words; other errors are raised with their
+// message.
+type AttrSetter interface {
+	Object
+	SetAttr(name string, value Object) error
+}
+
+// MethodCaller is implemented by objects with methods called as
+// ob.name(args). CallMethod reports false when the object has no method
+// called name; a returned *Error is raised. Calls on objects that are not
+// MethodCallers call the attribute of that name instead.
+type MethodCaller interface {
+	Object
+	CallMethod(name string, args ...Object) (Object, bool)
+}
+
+// ErrNoAttribute is returned by SetAttr for attributes an object does not
+// have.
+var ErrNoAttribute = errors.New("no such attribute")
@@ -0,0 +1,135 @@
+package object
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// BuiltIns are the builtin functions in a fixed order; compiled code refers
+// to them by their index.
+var BuiltIns = []struct {
+	Name    string
+	BuiltIn *BuiltIn
+}{
+	{"puts", Puts(nil)},
+	{"len", Define("len", func(arg Object) Object {
+		switch arg := arg.(type) {
+		case *Array:
+			return &Integer{Value: int64(len(arg.Elements))}
+		case *String:
+			return &Integer{Value: int64(len(arg.Value))}
+		default:
+			return newError(TypeErrorKind, "argument to `len` not supported, got %s", arg.Type())
+		}
+	})},
+	{"first", Define("first", func(array *Array) Object {
+		if len(array.Elements) > 0 {
+			return array.Elements[0]
+		}
+		return NULL
+	})},
+	{"last", Define("last", func(array *Array) Object {
+		if len(array.Elements) > 0 {
+			return array.Elements[len(array.Elements)-1]
+		}
+		return NULL
+	})},
+	{"rest", Define("rest", func(array *Array) Object {
+		length := len(array.Elements)
+		if len(array.Elements) > 0 {
+			copied := make([]Object, length-1)
+			copy(copied, array.Elements[1:length])
+			return &Array{Elements: copied}
+		}
+		return NULL
+	})},
+	{"push", Define("push", func(array *Array, value Object) *Array {
+		length := len(array.Elements)
+
+		copied := make([]Object, length+1)
+		copy(copied, array.Elements)
+
+		copied[length] = value
+		return &Array{Elements: copied}
+	})},
+	{"error", Define("error", func(message, kind string) *Exception {
+		return &Exception{Err: &Error{Message: message, Kind: kind}}
+	}, ErrorKind)},
+	{"ok", Define("ok", func(value Object) *Result {
+		return &Result{Ok: true, Value: value}
+	})},
+	{"err", Define("err", func(value Object) *Result {
+		return &Result{Ok: false, Value: value}
+	})},
+	{"is_ok", Define("is_ok", func(result *Result) bool {
+		return result.Ok
+	})},
+	{"is_err", Define("is_err", func(result *Result) bool {
+		return !result.Ok
+	})},
+	{"unwrap", Define("unwrap", func(result *Result) Object {
+		if result.Ok {
+			return result.Value
+		}
+		if exception, ok := result.Value.(*Exception); ok {
+			return &Error{Message: exception.Err.Message, Kind: exception.Err.Kind}
+		}
+		return newError(ErrorKind, "called `unwrap` on %s", result.Inspect())
+	})},
+	{"unwrap_or", Define("unwrap_or", func(result *Result, fallback Object) Object {
+		if result.Ok {
+			return result.Value
+		}
+		return fallback
+	})},
+	{"unwrap_err", Define("unwrap_err", func(result *Result) Object {
+		if result.Ok {
+			return newError(ErrorKind, "called `unwrap_err` on %s", result.Inspect())
+		}
+		return result.Value
+	})},
+	{"parse_int", Define("parse_int", func(str string) *Result {
+		value, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return &Result{Ok: false, Value: &String{Value: fmt.Sprintf("invalid integer %q", str)}}
+		}
+		return &Result{Ok: true, Value: &Integer{Value: value}}
+	})},
+}
+
+// Puts returns the puts builtin writing to out, or to os.Stdout when out is
+// nil.
+func Puts(out io.Writer) *BuiltIn {
+	return Define("puts", func(args ...Object) {
+		w := out
+		if w == nil {
+			w = os.Stdout
+		}
+		for _, arg := range args {
+			fmt.Fprintln(w, arg.Inspect())
+		}
+	})
+}
+
+func nativeToBoolean(value bool) *Boolean {
+	if value {
+		return TRUE
+	}
+	return FALSE
+}
+
+// GetBuiltInByName returns the builtin called name, or nil if there is none.
+func GetBuiltInByName(name string) *BuiltIn {
+	for _, def := range BuiltIns {
+		if def.Name == name {
+			return def.BuiltIn
+		}
+	}
+	return nil
+}
+
+func newError(kind, format string, args ...any) *Error {
+	return &Error{Message: fmt.Sprintf(format, args...), Kind: kind}
+}
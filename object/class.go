@@ -0,0 +1,140 @@
+package object
+
+import "strings"
+
+// Class is a class declared by a class statement. Calling it makes an
+// Instance and calls its init method, if it has one, with the arguments.
+type Class struct {
+	Name    string
+	Super   *Class // the class it extends, if any
+	Methods map[string]*Function
+}
+
+func (cl *Class) Type() ObjectType { return CLASS_OBJ }
+
+func (cl *Class) Inspect() string { return "<class " + cl.Name + ">" }
+
+// Method returns the method called name of the class or, failing that, of
+// the classes it extends, along with the class that defines it.
+func (cl *Class) Method(name string) (*Function, *Class, bool) {
+	for class := cl; class != nil; class = class.Super {
+		if method, ok := class.Methods[name]; ok {
+			return method, class, true
+		}
+	}
+	return nil, nil, false
+}
+
+// Extends reports whether the class is other or one of the classes it
+// extends, directly or not.
+func (cl *Class) Extends(other *Class) bool {
+	for class := cl; class != nil; class = class.Super {
+		if class == other {
+			return true
+		}
+	}
+	return false
+}
+
+// Instance is an object of a Class. Its fields are made by assigning to
+// them, usually as self.name = value in init.
+type Instance struct {
+	Class  *Class
+	Fields map[string]Object
+	names  []string // of the fields in the order they were set first
+
+	inspecting bool // set while Inspect runs, to print an instance within itself as ...
+}
+
+func NewInstance(class *Class) *Instance {
+	return &Instance{Class: class, Fields: make(map[string]Object)}
+}
+
+func (in *Instance) Type() ObjectType { return INSTANCE_OBJ }
+
+func (in *Instance) Inspect() string {
+	if in.inspecting {
+		return "..."
+	}
+	in.inspecting = true
+	defer func() { in.inspecting = false }()
+
+	var out strings.Builder
+
+	out.WriteString(in.Class.Name)
+	out.WriteString("{")
+	for idx, name := range in.names {
+		if idx > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(name + ": " + in.Fields[name].Inspect())
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// GetAttr returns the field called name or, when there is none, the method
+// of that name bound to the instance. Reading anything else raises an
+// AttributeError naming the class.
+func (in *Instance) GetAttr(name string) (Object, bool) {
+	if value, ok := in.Fields[name]; ok {
+		return value, true
+	}
+	if method, class, ok := in.Class.Method(name); ok {
+		return &BoundMethod{Self: in, Method: method, Class: class}, true
+	}
+	return newError(AttributeErrorKind, "%s has no attribute %s", in.Class.Name, name), true
+}
+
+// SetAttr sets the field called name, adding it when the instance does not
+// have it yet.
+func (in *Instance) SetAttr(name string, value Object) error {
+	if _, ok := in.Fields[name]; !ok {
+		in.names = append(in.names, name)
+	}
+	in.Fields[name] = value
+	return nil
+}
+
+// BoundMethod is a method read from an instance, which is passed to it as
+// self when it is called.
+type BoundMethod struct {
+	Self   *Instance
+	Method *Function
+	Class  *Class // that defines the method, whose superclass super refers to
+}
+
+func (bm *BoundMethod) Type() ObjectType { return FUNCTION_OBJ }
+
+func (bm *BoundMethod) Inspect() string { return "<method " + bm.Method.Name + ">" }
+
+// Args returns the arguments of the method's function for a call with
+// args: the values of self and super followed by args.
+func (bm *BoundMethod) Args(args []Object) []Object {
+	super := &Super{Self: bm.Self, Class: bm.Class}
+	return append([]Object{bm.Self, super}, args...)
+}
+
+// Super is the value of super within a method, through which the methods of
+// the superclass of the method's class are called on self.
+type Super struct {
+	Self  *Instance
+	Class *Class // the class of the method
+}
+
+func (sp *Super) Type() ObjectType { return SUPER_OBJ }
+
+func (sp *Super) Inspect() string { return "<super of " + sp.Class.Name + ">" }
+
+// GetAttr returns the method called name of the superclass bound to self.
+func (sp *Super) GetAttr(name string) (Object, bool) {
+	if sp.Class.Super == nil {
+		return newError(AttributeErrorKind, "%s has no superclass", sp.Class.Name), true
+	}
+	method, class, ok := sp.Class.Super.Method(name)
+	if !ok {
+		return newError(AttributeErrorKind, "%s has no method %s", sp.Class.Super.Name, name), true
+	}
+	return &BoundMethod{Self: sp.Self, Method: method, Class: class}, true
+}
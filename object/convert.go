@@ -0,0 +1,389 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// tagName is the struct tag naming the hash key of a field, as in
+// `flint:"name"`. Fields tagged `flint:"-"` and unexported fields are left
+// out; the others are keyed by their Go name.
+const tagName = "flint"
+
+var (
+	objectType = reflect.TypeOf((*Object)(nil)).Elem()
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// FromGo converts a Go value to the Flint object holding the same data:
+//
+//   - nil and nil pointers, slices, maps and funcs become NULL
+//   - bools, integers, floats and strings become BOOLEAN, INTEGER, FLOAT and
+//     STRING; unsigned integers above math.MaxInt64 are an error
+//   - slices and arrays become ARRAYs, maps HASHes keyed by the converted
+//     keys, and structs HASHes keyed by field name (see tagName)
+//   - funcs become BUILTINs converting their arguments with ToGo and their
+//     results with FromGo (see Define); a final error result is raised as an
+//     error, and several other results are returned as an ARRAY
+//   - Objects are returned unchanged, and pointers are followed
+//
+// Other values, such as channels and complex numbers, and values that refer
+// to themselves are an error.
+func FromGo(value any) (Object, error) {
+	ob, err := fromGo(reflect.ValueOf(value))
+	if err != nil {
+		return nil, fmt.Errorf("object: %w", err)
+	}
+	return ob, nil
+}
+
+func fromGo(val reflect.Value) (Object, error) {
+	return fromGoValue(val, make(map[reference]bool))
+}
+
+// reference identifies the pointer, map or slice a value refers to, so that
+// fromGoValue can tell when it comes back to one it is converting.
+type reference struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+// fromGoValue converts val, visiting holding the references of the values
+// val is part of.
+func fromGoValue(val reflect.Value, visiting map[reference]bool) (Object, error) {
+	if !val.IsValid() {
+		return NULL, nil
+	}
+	if val.Type().Implements(objectType) {
+		if val.Kind() == reflect.Interface || val.Kind() == reflect.Pointer {
+			if val.IsNil() {
+				return NULL, nil
+			}
+		}
+		return val.Interface().(Object), nil
+	}
+	switch val.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if !val.IsNil() {
+			ref := reference{typ: val.Type(), ptr: val.Pointer()}
+			if val.Kind() == reflect.Slice {
+				ref.len = val.Len()
+			}
+			if visiting[ref] {
+				return nil, fmt.Errorf("cannot convert %s referring to itself", val.Type())
+			}
+			visiting[ref] = true
+			defer delete(visiting, ref)
+		}
+	}
+	switch val.Kind() {
+	case reflect.Bool:
+		return nativeToBoolean(val.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Integer{Value: val.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if val.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%d overflows INTEGER", val.Uint())
+		}
+		return &Integer{Value: int64(val.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Float{Value: val.Float()}, nil
+	case reflect.String:
+		return &String{Value: val.String()}, nil
+	case reflect.Pointer, reflect.Interface:
+		if val.IsNil() {
+			return NULL, nil
+		}
+		return fromGoValue(val.Elem(), visiting)
+	case reflect.Slice, reflect.Array:
+		if val.Kind() == reflect.Slice && val.IsNil() {
+			return NULL, nil
+		}
+		elements := make([]Object, val.Len())
+		for idx := range elements {
+			elem, err := fromGoValue(val.Index(idx), visiting)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", idx, err)
+			}
+			elements[idx] = elem
+		}
+		return &Array{Elements: elements}, nil
+	case reflect.Map:
+		if val.IsNil() {
+			return NULL, nil
+		}
+		hash := &Hash{Pairs: make(map[HashKey]HashPair, val.Len())}
+		iter := val.MapRange()
+		for iter.Next() {
+			key, err := fromGoValue(iter.Key(), visiting)
+			if err != nil {
+				return nil, fmt.Errorf("key %v: %w", iter.Key(), err)
+			}
+			hashable, ok := key.(Hashable)
+			if !ok {
+				return nil, fmt.Errorf("key %v: %s cannot be a hash key", iter.Key(), key.Type())
+			}
+			value, err := fromGoValue(iter.Value(), visiting)
+			if err != nil {
+				return nil, fmt.Errorf("key %v: %w", iter.Key(), err)
+			}
+			hash.Pairs[hashable.HashKey()] = HashPair{Key: key, Value: value}
+		}
+		return hash, nil
+	case reflect.Struct:
+		hash := &Hash{Pairs: make(map[HashKey]HashPair)}
+		for _, field := range fields(val.Type()) {
+			fieldVal, err := val.FieldByIndexErr(field.index)
+			if err != nil {
+				continue // promoted through a nil embedded pointer
+			}
+			value, err := fromGoValue(fieldVal, visiting)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.name, err)
+			}
+			key := &String{Value: field.name}
+			hash.Pairs[key.HashKey()] = HashPair{Key: key, Value: value}
+		}
+		return hash, nil
+	case reflect.Func:
+		if val.IsNil() {
+			return NULL, nil
+		}
+		return wrapFunc(val), nil
+	}
+	return nil, fmt.Errorf("cannot convert %s to an object", val.Type())
+}
+
+// field is a struct field converted to and from a hash pair.
+type field struct {
+	name  string
+	index []int
+}
+
+func fields(typ reflect.Type) []field {
+	var fields []field
+	for _, sf := range reflect.VisibleFields(typ) {
+		if !sf.IsExported() || sf.Anonymous {
+			continue
+		}
+		name := sf.Name
+		if tag, ok := sf.Tag.Lookup(tagName); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		fields = append(fields, field{name: name, index: sf.Index})
+	}
+	return fields
+}
+
+// wrapFunc makes a builtin calling fn, as Define does for a func without a
+// name or defaults.
+func wrapFunc(fn reflect.Value) *BuiltIn {
+	def := newDefinition("", fn)
+	return &BuiltIn{Func: def.call, def: def}
+}
+
+// ToGo stores the data of ob in the value target points to, converting it
+// to the target's type: the reverse of FromGo. Hashes are stored in maps and
+// in structs, whose fields missing from the hash are left as they are. An
+// interface target receives the natural Go value of ob: int64, float64,
+// string, bool, nil, []any, map[string]any for hashes keyed by strings only
+// and map[any]any for others, and the object itself for the rest, such as
+// functions. Objects that do not fit the target's type, and a nil ob, are an
+// error.
+func ToGo(ob Object, target any) error {
+	ptr := reflect.ValueOf(target)
+	if ptr.Kind() != reflect.Pointer || ptr.IsNil() {
+		return errors.New("object: ToGo target must be a non-nil pointer")
+	}
+	if err := toGo(ob, ptr.Elem()); err != nil {
+		return fmt.Errorf("object: %w", err)
+	}
+	return nil
+}
+
+func toGo(ob Object, val reflect.Value) error {
+	typ := val.Type()
+	if ob == nil {
+		return fmt.Errorf("cannot convert a nil Object to %s", typ)
+	}
+	if reflect.TypeOf(ob).AssignableTo(typ) && typ != reflect.TypeOf((*any)(nil)).Elem() {
+		val.Set(reflect.ValueOf(ob))
+		return nil
+	}
+	if ob == NULL {
+		switch val.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func:
+			val.Set(reflect.Zero(typ))
+			return nil
+		}
+		return mismatch(ob, typ)
+	}
+	switch val.Kind() {
+	case reflect.Interface:
+		natural, err := natural(ob)
+		if err != nil {
+			return err
+		}
+		if natural == nil {
+			val.Set(reflect.Zero(typ))
+			return nil
+		}
+		if !reflect.TypeOf(natural).AssignableTo(typ) {
+			return mismatch(ob, typ)
+		}
+		val.Set(reflect.ValueOf(natural))
+		return nil
+	case reflect.Pointer:
+		elem := reflect.New(typ.Elem())
+		if err := toGo(ob, elem.Elem()); err != nil {
+			return err
+		}
+		val.Set(elem)
+		return nil
+	case reflect.Bool:
+		bl, ok := ob.(*Boolean)
+		if !ok {
+			return mismatch(ob, typ)
+		}
+		val.SetBool(bl.Value)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		ig, ok := ob.(*Integer)
+		if !ok {
+			return mismatch(ob, typ)
+		}
+		if val.OverflowInt(ig.Value) {
+			return fmt.Errorf("%d overflows %s", ig.Value, typ)
+		}
+		val.SetInt(ig.Value)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		ig, ok := ob.(*Integer)
+		if !ok {
+			return mismatch(ob, typ)
+		}
+		if ig.Value < 0 || val.OverflowUint(uint64(ig.Value)) {
+			return fmt.Errorf("%d overflows %s", ig.Value, typ)
+		}
+		val.SetUint(uint64(ig.Value))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		switch num := ob.(type) {
+		case *Float:
+			val.SetFloat(num.Value)
+		case *Integer:
+			val.SetFloat(float64(num.Value))
+		default:
+			return mismatch(ob, typ)
+		}
+		return nil
+	case reflect.String:
+		str, ok := ob.(*String)
+		if !ok {
+			return mismatch(ob, typ)
+		}
+		val.SetString(str.Value)
+		return nil
+	case reflect.Slice, reflect.Array:
+		arr, ok := ob.(*Array)
+		if !ok {
+			return mismatch(ob, typ)
+		}
+		if val.Kind() == reflect.Slice {
+			val.Set(reflect.MakeSlice(typ, len(arr.Elements), len(arr.Elements)))
+		} else if val.Len() != len(arr.Elements) {
+			return fmt.Errorf("cannot convert ARRAY of %d elements to %s", len(arr.Elements), typ)
+		}
+		for idx, elem := range arr.Elements {
+			if err := toGo(elem, val.Index(idx)); err != nil {
+				return fmt.Errorf("element %d: %w", idx, err)
+			}
+		}
+		return nil
+	case reflect.Map:
+		hash, ok := ob.(*Hash)
+		if !ok {
+			return mismatch(ob, typ)
+		}
+		val.Set(reflect.MakeMapWithSize(typ, len(hash.Pairs)))
+		for _, pair := range hash.Pairs {
+			key := reflect.New(typ.Key()).Elem()
+			if err := toGo(pair.Key, key); err != nil {
+				return fmt.Errorf("key %s: %w", pair.Key.Inspect(), err)
+			}
+			value := reflect.New(typ.Elem()).Elem()
+			if err := toGo(pair.Value, value); err != nil {
+				return fmt.Errorf("key %s: %w", pair.Key.Inspect(), err)
+			}
+			val.SetMapIndex(key, value)
+		}
+		return nil
+	case reflect.Struct:
+		hash, ok := ob.(*Hash)
+		if !ok {
+			return mismatch(ob, typ)
+		}
+		for _, field := range fields(typ) {
+			key := &String{Value: field.name}
+			pair, ok := hash.Pairs[key.HashKey()]
+			if !ok {
+				continue
+			}
+			fieldVal, err := val.FieldByIndexErr(field.index)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", field.name, err)
+			}
+			if err := toGo(pair.Value, fieldVal); err != nil {
+				return fmt.Errorf("field %s: %w", field.name, err)
+			}
+		}
+		return nil
+	}
+	return mismatch(ob, typ)
+}
+
+// natural returns the Go value ToGo stores for ob in an interface.
+func natural(ob Object) (any, error) {
+	switch ob := ob.(type) {
+	case *Null:
+		return nil, nil
+	case *Boolean:
+		return ob.Value, nil
+	case *Integer:
+		return ob.Value, nil
+	case *Float:
+		return ob.Value, nil
+	case *String:
+		return ob.Value, nil
+	case *Array:
+		var elements []any
+		if err := toGo(ob, reflect.ValueOf(&elements).Elem()); err != nil {
+			return nil, err
+		}
+		return elements, nil
+	case *Hash:
+		for _, pair := range ob.Pairs {
+			if pair.Key.Type() != STRING_OBJ {
+				var pairs map[any]any
+				err := toGo(ob, reflect.ValueOf(&pairs).Elem())
+				return pairs, err
+			}
+		}
+		var pairs map[string]any
+		err := toGo(ob, reflect.ValueOf(&pairs).Elem())
+		return pairs, err
+	}
+	return ob, nil
+}
+
+func mismatch(ob Object, typ reflect.Type) error {
+	return fmt.Errorf("cannot convert %s to %s", ob.Type(), typ)
+}
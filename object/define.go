@@ -0,0 +1,218 @@
+package object
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Define makes the builtin called name from the Go function fn, generating
+// the checks every builtin would otherwise repeat:
+//
+//   - a call with too few or too many arguments is an ArgumentError
+//   - arguments are converted to the types of the parameters with ToGo, and
+//     one that does not fit is a TypeError such as "argument 2 to `repeat`
+//     must be INTEGER, got STRING"; parameters of type Object accept any
+//     argument, and those of a type such as *Array only that type of object
+//   - the last len(defaults) parameters before any variadic one are
+//     optional, taking the default when their argument is left out
+//   - a variadic fn takes any number of further arguments
+//   - results are converted like those of the funcs given to FromGo, so a
+//     final error result is raised as an Error, and an *Error returned as an
+//     Object is raised as it is
+//
+// For example, Define("repeat", strings.Repeat) makes a builtin taking a
+// string and an integer, and
+//
+//	Define("pad", func(s string, width int, fill string) string { ... }, " ")
+//
+// one whose fill argument defaults to a space. Define panics if fn is not a
+// func or a default does not convert to the type of its parameter, as these
+// are mistakes in the host program.
+func Define(name string, fn any, defaults ...any) *BuiltIn {
+	val := reflect.ValueOf(fn)
+	if val.Kind() != reflect.Func || val.IsNil() {
+		panic(fmt.Sprintf("object: Define %s: %T is not a func", name, fn))
+	}
+	def := newDefinition(name, val)
+	if len(defaults) > len(def.params) {
+		panic(fmt.Sprintf("object: Define %s: %d defaults for %d parameters", name, len(defaults), len(def.params)))
+	}
+	def.defaults = make([]reflect.Value, len(defaults))
+	first := len(def.params) - len(defaults)
+	for idx, value := range defaults {
+		ob, err := fromGo(reflect.ValueOf(value))
+		if err == nil {
+			def.defaults[idx], err = def.convert(first+idx, ob)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("object: Define %s: default %d: %s", name, idx+1, err))
+		}
+	}
+	return &BuiltIn{Func: def.call, def: def}
+}
+
+// Arity returns the least and the most number of arguments bl takes, most
+// being -1 when there is no limit, as for builtins not made by Define.
+func (bl *BuiltIn) Arity() (least, most int) {
+	if bl.def == nil {
+		return 0, -1
+	}
+	least = len(bl.def.params) - len(bl.def.defaults)
+	if bl.def.variadic != nil {
+		return least, -1
+	}
+	return least, len(bl.def.params)
+}
+
+// definition is a Go func called as a builtin.
+type definition struct {
+	name     string
+	fn       reflect.Value
+	params   []reflect.Type  // the parameters before any variadic one
+	variadic reflect.Type    // the element type of the variadic parameter, or nil
+	defaults []reflect.Value // the values of the last parameters
+}
+
+func newDefinition(name string, fn reflect.Value) *definition {
+	typ := fn.Type()
+	def := &definition{name: name, fn: fn}
+	numIn := typ.NumIn()
+	if typ.IsVariadic() {
+		numIn--
+		def.variadic = typ.In(numIn).Elem()
+	}
+	for idx := 0; idx < numIn; idx++ {
+		def.params = append(def.params, typ.In(idx))
+	}
+	return def
+}
+
+func (def *definition) call(args ...Object) Object {
+	least := len(def.params) - len(def.defaults)
+	if len(args) < least || def.variadic == nil && len(args) > len(def.params) {
+		return newError(ArgumentErrorKind, "wrong number of arguments. got=%d, want%s", len(args), def.arity())
+	}
+	in := make([]reflect.Value, max(len(args), len(def.params)))
+	for idx, arg := range args {
+		val, err := def.convert(idx, arg)
+		if err != nil {
+			return newError(TypeErrorKind, "%s", err)
+		}
+		in[idx] = val
+	}
+	for idx := len(args); idx < len(def.params); idx++ {
+		in[idx] = def.defaults[idx-least]
+	}
+	out := def.fn.Call(in)
+	if last := len(out) - 1; last >= 0 && def.fn.Type().Out(last) == errorType {
+		if err, _ := out[last].Interface().(error); err != nil {
+			return newError(ErrorKind, "%s", err)
+		}
+		out = out[:last]
+	}
+	results := make([]Object, len(out))
+	for idx, val := range out {
+		result, err := fromGo(val)
+		if err != nil {
+			return newError(TypeErrorKind, "result %d: %s", idx+1, err)
+		}
+		results[idx] = result
+	}
+	switch len(results) {
+	case 0:
+		return NULL
+	case 1:
+		return results[0]
+	}
+	return &Array{Elements: results}
+}
+
+// arity describes the number of arguments the definition takes, as in
+// "=1 or 2" or " at least 1".
+func (def *definition) arity() string {
+	least := len(def.params) - len(def.defaults)
+	switch {
+	case def.variadic != nil:
+		return fmt.Sprintf(" at least %d", least)
+	case least == len(def.params):
+		return fmt.Sprintf("=%d", least)
+	case least+1 == len(def.params):
+		return fmt.Sprintf("=%d or %d", least, len(def.params))
+	}
+	return fmt.Sprintf("=%d to %d", least, len(def.params))
+}
+
+// convert converts the argument at idx to the type of its parameter.
+func (def *definition) convert(idx int, arg Object) (reflect.Value, error) {
+	param := def.variadic
+	if idx < len(def.params) {
+		param = def.params[idx]
+	}
+	val := reflect.New(param).Elem()
+	if param.Implements(objectType) {
+		// Objects are not converted, and NULL does not stand for a nil
+		// *Array or *Hash.
+		if !reflect.TypeOf(arg).AssignableTo(param) {
+			return val, def.mismatch(idx, arg, param)
+		}
+		val.Set(reflect.ValueOf(arg))
+		return val, nil
+	}
+	if err := toGo(arg, val); err != nil {
+		if want := typeName(param); want != "" && !accepts(want, arg) {
+			return val, def.mismatch(idx, arg, param)
+		}
+		return val, fmt.Errorf("%s: %w", def.argument(idx), err)
+	}
+	return val, nil
+}
+
+func (def *definition) mismatch(idx int, arg Object, param reflect.Type) error {
+	return fmt.Errorf("%s must be %s, got %s", def.argument(idx), typeName(param), arg.Type())
+}
+
+// argument names the argument at idx in error messages.
+func (def *definition) argument(idx int) string {
+	switch {
+	case def.name == "":
+		return fmt.Sprintf("argument %d", idx+1)
+	case idx == 0:
+		return fmt.Sprintf("argument to `%s`", def.name)
+	}
+	return fmt.Sprintf("argument %d to `%s`", idx+1, def.name)
+}
+
+// typeName returns the type of object converted to typ by ToGo, or "" when
+// objects of several types are.
+func typeName(typ reflect.Type) string {
+	if typ.Implements(objectType) {
+		if typ.Kind() != reflect.Pointer {
+			return ""
+		}
+		return string(reflect.New(typ.Elem()).Interface().(Object).Type())
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return string(BOOLEAN_OBJ)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return string(INTEGER_OBJ)
+	case reflect.Float32, reflect.Float64:
+		return string(FLOAT_OBJ)
+	case reflect.String:
+		return string(STRING_OBJ)
+	case reflect.Slice, reflect.Array:
+		return string(ARRAY_OBJ)
+	case reflect.Map, reflect.Struct:
+		return string(HASH_OBJ)
+	case reflect.Pointer:
+		return typeName(typ.Elem())
+	}
+	return ""
+}
+
+// accepts reports whether ToGo converts arg to the type of object called
+// want.
+func accepts(want string, arg Object) bool {
+	return string(arg.Type()) == want || want == string(FLOAT_OBJ) && arg.Type() == INTEGER_OBJ
+}
@@ -0,0 +1,108 @@
+package object
+
+import "strings"
+
+// Enum is a type declared by an enum statement, as in 'enum Shape {
+// Circle(r), Empty }'. Its variants are read as its attributes: those with
+// a payload are called to make a value, the others are values themselves.
+type Enum struct {
+	Name     string
+	Variants []*Variant
+	index    map[string]*Variant // of each variant by name
+}
+
+func NewEnum(name string) *Enum {
+	return &Enum{Name: name, index: make(map[string]*Variant)}
+}
+
+// Add adds a variant with a payload of the given fields, none for a plain
+// value.
+func (en *Enum) Add(name string, fields []string) *Variant {
+	variant := &Variant{Enum: en, Name: name, Fields: fields}
+	if len(fields) == 0 {
+		variant.value = &EnumValue{Variant: variant}
+	}
+	en.Variants = append(en.Variants, variant)
+	en.index[name] = variant
+	return variant
+}
+
+// Variant returns the variant called name.
+func (en *Enum) Variant(name string) (*Variant, bool) {
+	variant, ok := en.index[name]
+	return variant, ok
+}
+
+func (en *Enum) Type() ObjectType { return ENUM_OBJ }
+
+func (en *Enum) Inspect() string { return "<enum " + en.Name + ">" }
+
+// GetAttr returns the variant called name, or its only value when it has
+// no payload.
+func (en *Enum) GetAttr(name string) (Object, bool) {
+	variant, ok := en.index[name]
+	if !ok {
+		return newError(AttributeErrorKind, "%s has no variant %s", en.Name, name), true
+	}
+	if variant.value != nil {
+		return variant.value, true
+	}
+	return variant, true
+}
+
+// Variant is a variant of an enum. Calling it with a value for each field
+// of its payload makes an EnumValue.
+type Variant struct {
+	Enum   *Enum
+	Name   string
+	Fields []string
+	value  *EnumValue // the only value of a variant without payload
+}
+
+func (vr *Variant) Type() ObjectType { return VARIANT_OBJ }
+
+func (vr *Variant) Inspect() string { return "<variant " + vr.Enum.Name + "." + vr.Name + ">" }
+
+// New makes a value of the variant with its payload set to values.
+func (vr *Variant) New(values ...Object) Object {
+	if len(values) != len(vr.Fields) {
+		return newError(ArgumentErrorKind, "wrong number of arguments. got=%d, want=%d", len(values), len(vr.Fields))
+	}
+	if vr.value != nil {
+		return vr.value
+	}
+	return &EnumValue{Variant: vr, Values: append([]Object(nil), values...)}
+}
+
+// EnumValue is a value of an enum: one of its variants along with its
+// payload, whose fields are read with '.' like attributes.
+type EnumValue struct {
+	Variant *Variant
+	Values  []Object // by index of the field in Variant.Fields
+}
+
+func (ev *EnumValue) Type() ObjectType { return ENUM_VALUE_OBJ }
+
+func (ev *EnumValue) Inspect() string {
+	var out strings.Builder
+
+	out.WriteString(ev.Variant.Enum.Name + "." + ev.Variant.Name)
+	if len(ev.Values) > 0 {
+		var values []string
+		for _, value := range ev.Values {
+			values = append(values, value.Inspect())
+		}
+		out.WriteString("(" + strings.Join(values, ", ") + ")")
+	}
+
+	return out.String()
+}
+
+func (ev *EnumValue) GetAttr(name string) (Object, bool) {
+	for idx, field := range ev.Variant.Fields {
+		if field == name {
+			return ev.Values[idx], true
+		}
+	}
+	return newError(AttributeErrorKind, "%s.%s has no field %s", ev.Variant.Enum.Name, ev.Variant.Name, name), true
+}
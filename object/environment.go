@@ -2,6 +2,7 @@ package object
 
 type Environment struct {
 	store map[string]Object
+	slots []Object // locals of resolved functions, indexed by slot
 	outer *Environment
 }
 
@@ -27,3 +28,26 @@ func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env.outer = outer
 	return env
 }
+
+// NewFunctionEnvironment creates the environment of a call to a resolved
+// function, holding its parameters and lets in slots instead of by name.
+func NewFunctionEnvironment(outer *Environment, slots int) *Environment {
+	env := NewEnclosedEnvironment(outer)
+	env.slots = make([]Object, slots)
+	return env
+}
+
+// GetSlot reads the slot of the environment depth levels out from env. It
+// reports false when the slot has not been assigned yet.
+func (env *Environment) GetSlot(depth, slot int) (Object, bool) {
+	for ; depth > 0; depth-- {
+		env = env.outer
+	}
+	ob := env.slots[slot]
+	return ob, ob != nil
+}
+
+func (env *Environment) SetSlot(slot int, val Object) Object {
+	env.slots[slot] = val
+	return val
+}
@@ -2,8 +2,11 @@ package object
 
 import (
 	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/code"
+	"Interpreter_in_Go/token"
 	"fmt"
 	"hash/fnv"
+	"strconv"
 	"strings"
 )
 
@@ -19,6 +22,7 @@ const (
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
@@ -28,6 +32,20 @@ const (
 	BUILTIN_OBJ      = "BUILTIN"
 	HASH_OBJ         = "HASH"
 	ARRAY_OBJ        = "ARRAY"
+	EXCEPTION_OBJ    = "EXCEPTION"
+	RESULT_OBJ       = "RESULT"
+	MODULE_OBJ       = "MODULE"
+	STRUCT_TYPE_OBJ  = "STRUCT_TYPE"
+	STRUCT_OBJ       = "STRUCT"
+	CLASS_OBJ        = "CLASS"
+	INSTANCE_OBJ     = "INSTANCE"
+	SUPER_OBJ        = "SUPER"
+	ENUM_OBJ         = "ENUM"
+	VARIANT_OBJ      = "VARIANT"
+	ENUM_VALUE_OBJ   = "ENUM_VALUE"
+
+	COMPILED_FUNCTION_OBJ = "COMPILED_FUNCTION"
+	CELL_OBJ              = "CELL"
 )
 
 type Object interface {
@@ -35,6 +53,14 @@ type Object interface {
 	Inspect() string
 }
 
+// Singletons shared by every engine, so that comparisons by identity, as `==`
+// does for everything but integers and strings, agree between them.
+var (
+	NULL  = &Null{}
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
+)
+
 type Integer struct {
 	Value int64
 }
@@ -43,6 +69,17 @@ func (ig *Integer) Type() ObjectType { return INTEGER_OBJ }
 
 func (ig *Integer) Inspect() string { return fmt.Sprintf("%d", ig.Value) }
 
+// Float is a floating-point number handed over by a host through FromGo.
+// Programs can pass floats around and compare them by identity, but no
+// operator supports them yet.
+type Float struct {
+	Value float64
+}
+
+func (fl *Float) Type() ObjectType { return FLOAT_OBJ }
+
+func (fl *Float) Inspect() string { return strconv.FormatFloat(fl.Value, 'g', -1, 64) }
+
 type String struct {
 	Value string
 }
@@ -73,8 +110,27 @@ func (rv *Return) Type() ObjectType { return RETURN_VALUE_OBJ }
 
 func (rv *Return) Inspect() string { return rv.Value.Inspect() }
 
+// Kinds of errors. The interpreter raises errors of the kinds below; errors
+// thrown by scripts may have any kind.
+const (
+	ErrorKind             = "Error"             // errors of no more specific kind
+	TypeErrorKind         = "TypeError"         // values of the wrong type for an operation
+	NameErrorKind         = "NameError"         // identifiers that are not bound
+	ArgumentErrorKind     = "ArgumentError"     // calls with the wrong number of arguments
+	RecursionErrorKind    = "RecursionError"    // calls nested beyond the call depth limit
+	ImportErrorKind       = "ImportError"       // modules that cannot be found or loaded
+	AttributeErrorKind    = "AttributeError"    // attributes and methods objects do not have
+	MatchErrorKind        = "MatchError"        // values no match arm or destructuring pattern matches
+	ZeroDivisionErrorKind = "ZeroDivisionError" // integer division by zero
+)
+
+// Error is an error being raised: it aborts evaluation until a try
+// expression catches it.
 type Error struct {
 	Message string
+	Kind    string         // one of the kinds above or a script's own; empty means ErrorKind
+	Pos     token.Position // where the error was raised, if known
+	Stack   []Frame        // the calls in progress then, innermost first
 }
 
 func (er *Error) Type() ObjectType { return ERROR_OBJ }
@@ -83,10 +139,130 @@ func (er *Error) Inspect() string {
 	return fmt.Sprintf("%sERROR::%s %s", COLOR_RED, COLOR_RESET, er.Message)
 }
 
+func (er *Error) kind() string {
+	if er.Kind == "" {
+		return ErrorKind
+	}
+	return er.Kind
+}
+
+// maxTracebackFrames is the number of innermost calls Traceback lists.
+const maxTracebackFrames = 20
+
+// Traceback renders the error with the position it was raised at and the
+// calls that led there, one per line, innermost first.
+func (er *Error) Traceback() string {
+	var out strings.Builder
+	out.WriteString(er.Inspect())
+	if er.Pos != (token.Position{}) {
+		fmt.Fprintf(&out, " (%s)", er.Pos)
+	}
+	for idx, fr := range er.Stack {
+		if idx == maxTracebackFrames {
+			fmt.Fprintf(&out, "\n\t... %d more", len(er.Stack)-idx)
+			break
+		}
+		fmt.Fprintf(&out, "\n\tat %s", fr)
+	}
+	return out.String()
+}
+
+// Frame is a function call in progress.
+type Frame struct {
+	Function string         // the function's name, if it was bound by a let
+	Pos      token.Position // of the call
+	Args     int            // the number of arguments passed
+}
+
+func (fr Frame) String() string {
+	name := fr.Function
+	if name == "" {
+		name = "<anonymous>"
+	}
+	if fr.Args == 1 {
+		return fmt.Sprintf("%s (%s) with 1 argument", name, fr.Pos)
+	}
+	return fmt.Sprintf("%s (%s) with %d arguments", name, fr.Pos, fr.Args)
+}
+
+// Exception is an error as a value: caught by a try expression or made by
+// the error builtin. Unlike an Error it does not abort evaluation, and
+// throwing it raises its Err.
+type Exception struct {
+	Err *Error
+}
+
+func (ex *Exception) Type() ObjectType { return EXCEPTION_OBJ }
+
+func (ex *Exception) Inspect() string {
+	return ex.Err.kind() + ": " + ex.Err.Message
+}
+
+// Field returns the "message", "kind" or "stack" of the error, the stack as
+// an array of strings describing the calls, innermost first.
+func (ex *Exception) Field(name string) (Object, bool) {
+	switch name {
+	case "message":
+		return &String{Value: ex.Err.Message}, true
+	case "kind":
+		return &String{Value: ex.Err.kind()}, true
+	case "stack":
+		stack := make([]Object, len(ex.Err.Stack))
+		for idx, fr := range ex.Err.Stack {
+			stack[idx] = &String{Value: fr.String()}
+		}
+		return &Array{Elements: stack}, true
+	}
+	return nil, false
+}
+
+// GetAttr returns the fields of Field, so that scripts can write e.message
+// for e["message"].
+func (ex *Exception) GetAttr(name string) (Object, bool) {
+	return ex.Field(name)
+}
+
+// Result is the outcome of something that can fail, made by the ok and err
+// builtins or returned by fallible builtins such as parse_int. Value is the
+// outcome when Ok is set and the failure otherwise.
+type Result struct {
+	Ok    bool
+	Value Object
+}
+
+func (rs *Result) Type() ObjectType { return RESULT_OBJ }
+
+func (rs *Result) Inspect() string {
+	if rs.Ok {
+		return "ok(" + rs.Value.Inspect() + ")"
+	}
+	return "err(" + rs.Value.Inspect() + ")"
+}
+
+// Module is an imported file, holding the values of its exported bindings.
+type Module struct {
+	Name    string // the path it was imported by
+	Exports map[string]Object
+}
+
+func (md *Module) Type() ObjectType { return MODULE_OBJ }
+
+func (md *Module) Inspect() string { return "<module " + md.Name + ">" }
+
+// GetAttr returns the export called name, so that scripts can write
+// lib.name for lib["name"].
+func (md *Module) GetAttr(name string) (Object, bool) {
+	value, ok := md.Exports[name]
+	return value, ok
+}
+
 type Function struct {
+	Name       string // the name of the let it was defined in, if any
 	Parameters []*ast.Identifier
+	Patterns   []ast.Pattern // destructuring the arguments, as in ast.FunctionLiteral
 	Env        *Environment
 	Body       *ast.BlockStatement
+	Locals     int // slots of a resolved function, 0 when unresolved
 }
 
 func (fn *Function) Type() ObjectType { return FUNCTION_OBJ }
@@ -95,8 +271,12 @@ func (fn *Function) Inspect() string {
 	var output strings.Builder
 	var params []string
 
-	for _, pr := range fn.Parameters {
-		params = append(params, pr.String())
+	for idx, pr := range fn.Parameters {
+		if idx < len(fn.Patterns) && fn.Patterns[idx] != nil {
+			params = append(params, fn.Patterns[idx].String())
+		} else {
+			params = append(params, pr.String())
+		}
 	}
 	output.WriteString("func(")
 	output.WriteString(strings.Join(params, ", "))
@@ -106,8 +286,62 @@ func (fn *Function) Inspect() string {
 	return output.String()
 }
 
+// CompiledFunction is the bytecode of a function literal. It only lives in
+// the constant pool; at run time functions are Closures over it.
+type CompiledFunction struct {
+	Name          string // the name it was bound to by a let, if any
+	Instructions  code.Instructions
+	Lines         code.LineTable
+	NumLocals     int
+	NumParameters int
+	Cells         []int    // local slots captured by inner functions, held in Cells
+	LocalNames    []string // name of each local slot, for error messages
+	FreeNames     []string // name of each free variable, for error messages
+}
+
+func (cf *CompiledFunction) Type() ObjectType { return COMPILED_FUNCTION_OBJ }
+
+func (cf *CompiledFunction) Inspect() string {
+	if cf.Name != "" {
+		return fmt.Sprintf("CompiledFunction[%s]", cf.Name)
+	}
+	return fmt.Sprintf("CompiledFunction[%p]", cf)
+}
+
+// Closure is a compiled function together with the variables it captured
+// from the functions enclosing it. It is the virtual machine's counterpart of
+// Function and has the same type.
+type Closure struct {
+	Fn   *CompiledFunction
+	Free []*Cell
+}
+
+func (cl *Closure) Type() ObjectType { return FUNCTION_OBJ }
+
+func (cl *Closure) Inspect() string {
+	return fmt.Sprintf("Closure[%p]", cl)
+}
+
+// Cell holds a local variable shared between a function and the closures
+// created inside it, so that they all see later assignments to it. Value is
+// nil until the variable is bound.
+type Cell struct {
+	Value Object
+}
+
+func (cl *Cell) Type() ObjectType { return CELL_OBJ }
+
+func (cl *Cell) Inspect() string {
+	if cl.Value == nil {
+		return "Cell[]"
+	}
+	return fmt.Sprintf("Cell[%s]", cl.Value.Inspect())
+}
+
 type BuiltIn struct {
 	Func BuiltInFunction
+
+	def *definition // the parameters of a builtin made by Define
 }
 
 func (bl *BuiltIn) Type() ObjectType { return BUILTIN_OBJ }
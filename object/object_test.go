@@ -1,6 +1,11 @@
 package object
 
-import "testing"
+import (
+	"Interpreter_in_Go/token"
+	"errors"
+	"strings"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -15,3 +20,257 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with same content have different hash keys")
 	}
 }
+
+func TestErrorTraceback(t *testing.T) {
+	errOb := &Error{
+		Message: "type mismatch: INTEGER + BOOLEAN",
+		Pos:     token.Position{Line: 2, Column: 3},
+		Stack: []Frame{
+			{Function: "add", Pos: token.Position{Line: 4, Column: 9}, Args: 2},
+			{Pos: token.Position{Line: 5, Column: 1}, Args: 1},
+		},
+	}
+	expected := errOb.Inspect() + ` (2:3)
+	at add (4:9) with 2 arguments
+	at <anonymous> (5:1) with 1 argument`
+	if errOb.Traceback() != expected {
+		t.Errorf("wrong traceback. expected=%q, got=%q", expected, errOb.Traceback())
+	}
+
+	errOb.Stack = make([]Frame, maxTracebackFrames+3)
+	lines := strings.Split(errOb.Traceback(), "\n")
+	if len(lines) != maxTracebackFrames+2 || lines[len(lines)-1] != "\t... 3 more" {
+		t.Errorf("long stack not cut short. got %d lines ending in %q", len(lines), lines[len(lines)-1])
+	}
+}
+
+func TestFromGo(t *testing.T) {
+	type point struct {
+		X      int    `flint:"x"`
+		Y      int    `flint:"y"`
+		Label  string // keyed by its Go name
+		Hidden bool   `flint:"-"`
+		secret int
+	}
+	var nilSlice []int
+	tests := []struct {
+		value    any
+		expected string
+	}{
+		{nil, "nil"},
+		{42, "42"},
+		{uint8(7), "7"},
+		{2.5, "2.5"},
+		{"hi", "hi"},
+		{true, "true"},
+		{nilSlice, "nil"},
+		{[]any{1, "two", []bool{false}}, "[1, two, [false]]"},
+		{[2]int{1, 2}, "[1, 2]"},
+		{map[string]int{"a": 1}, "{a:1}"},
+		{&point{X: 1, Y: 2}, ""},
+		{&Integer{Value: 3}, "3"},
+	}
+	for _, tt := range tests {
+		ob, err := FromGo(tt.value)
+		if err != nil {
+			t.Errorf("FromGo(%#v) returned error: %s", tt.value, err)
+			continue
+		}
+		if tt.expected != "" && ob.Inspect() != tt.expected {
+			t.Errorf("wrong object for %#v. expected=%q, got=%q", tt.value, tt.expected, ob.Inspect())
+		}
+	}
+
+	ob, err := FromGo(point{X: 1, Y: 2, Label: "p", Hidden: true, secret: 3})
+	if err != nil {
+		t.Fatalf("FromGo returned error: %s", err)
+	}
+	hash, ok := ob.(*Hash)
+	if !ok || len(hash.Pairs) != 3 {
+		t.Fatalf("wrong hash for a struct. got=%s", ob.Inspect())
+	}
+	for key, expected := range map[string]string{"x": "1", "y": "2", "Label": "p"} {
+		pair, ok := hash.Pairs[(&String{Value: key}).HashKey()]
+		if !ok || pair.Value.Inspect() != expected {
+			t.Errorf("wrong field %s. expected=%s, got=%v", key, expected, pair.Value)
+		}
+	}
+
+	for _, value := range []any{make(chan int), complex(1, 2), uint64(1 << 63), []any{1, make(chan int)}} {
+		if _, err := FromGo(value); err == nil {
+			t.Errorf("FromGo(%#v) did not return an error", value)
+		}
+	}
+
+	type node struct{ Next *node }
+	cyclic := &node{}
+	cyclic.Next = cyclic
+	if _, err := FromGo(cyclic); err == nil || err.Error() != "object: field Next: cannot convert *object.node referring to itself" {
+		t.Errorf("wrong error for a pointer cycle. got=%v", err)
+	}
+	loop := map[string]any{}
+	loop["self"] = loop
+	if _, err := FromGo(loop); err == nil {
+		t.Errorf("FromGo of a map holding itself did not return an error")
+	}
+	shared := &node{}
+	if ob, err := FromGo([]*node{shared, shared}); err != nil || ob.Inspect() != "[{Next:nil}, {Next:nil}]" {
+		t.Errorf("wrong array for a shared pointer. got=%v, %v", ob, err)
+	}
+}
+
+func TestToGo(t *testing.T) {
+	ob, _ := FromGo(map[string]any{"x": 1, "y": 2, "tags": []string{"a", "b"}})
+
+	var pt struct {
+		X    int64    `flint:"x"`
+		Y    float64  `flint:"y"`
+		Tags []string `flint:"tags"`
+		Z    int      `flint:"z"`
+	}
+	pt.Z = 9
+	if err := ToGo(ob, &pt); err != nil {
+		t.Fatalf("ToGo returned error: %s", err)
+	}
+	if pt.X != 1 || pt.Y != 2 || strings.Join(pt.Tags, ",") != "a,b" || pt.Z != 9 {
+		t.Errorf("wrong struct. got=%+v", pt)
+	}
+
+	var natural any
+	if err := ToGo(ob, &natural); err != nil {
+		t.Fatalf("ToGo returned error: %s", err)
+	}
+	if m, ok := natural.(map[string]any); !ok || m["x"] != int64(1) || len(m["tags"].([]any)) != 2 {
+		t.Errorf("wrong natural value. got=%#v", natural)
+	}
+
+	var ptr *int
+	if err := ToGo(&Integer{Value: 5}, &ptr); err != nil || *ptr != 5 {
+		t.Errorf("wrong pointer. got=%v, %v", ptr, err)
+	}
+	var same Object
+	if err := ToGo(TRUE, &same); err != nil || same != TRUE {
+		t.Errorf("wrong object. got=%v, %v", same, err)
+	}
+
+	tests := []struct {
+		ob       Object
+		target   any
+		expected string
+	}{
+		{&String{Value: "x"}, new(int), "object: cannot convert STRING to int"},
+		{&Integer{Value: 300}, new(int8), "object: 300 overflows int8"},
+		{&Integer{Value: -1}, new(uint), "object: -1 overflows uint"},
+		{NULL, new(string), "object: cannot convert NULL to string"},
+		{&Array{Elements: []Object{&Integer{Value: 1}, TRUE}}, new([]int), "object: element 1: cannot convert BOOLEAN to int"},
+		{&Integer{Value: 1}, 0, "object: ToGo target must be a non-nil pointer"},
+	}
+	for _, tt := range tests {
+		if err := ToGo(tt.ob, tt.target); err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error for %s. expected=%q, got=%v", tt.ob.Inspect(), tt.expected, err)
+		}
+	}
+	var x int
+	if err := ToGo(nil, &x); err == nil || err.Error() != "object: cannot convert a nil Object to int" {
+		t.Errorf("wrong error for a nil object. got=%v", err)
+	}
+}
+
+func TestFromGoFunc(t *testing.T) {
+	divide := func(a, b int) (int, error) {
+		if b == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return a / b, nil
+	}
+	join := func(sep string, parts ...string) string { return strings.Join(parts, sep) }
+
+	tests := []struct {
+		fn       any
+		args     []Object
+		expected string
+	}{
+		{divide, []Object{&Integer{Value: 7}, &Integer{Value: 2}}, "3"},
+		{divide, []Object{&Integer{Value: 7}, &Integer{Value: 0}}, "ERROR: division by zero"},
+		{divide, []Object{&Integer{Value: 7}}, "ERROR: wrong number of arguments. got=1, want=2"},
+		{divide, []Object{&String{Value: "7"}, &Integer{Value: 1}}, "ERROR: argument 1 must be INTEGER, got STRING"},
+		{join, []Object{&String{Value: "-"}, &String{Value: "a"}, &String{Value: "b"}}, "a-b"},
+		{join, []Object{&String{Value: "-"}}, ""},
+		{join, nil, "ERROR: wrong number of arguments. got=0, want at least 1"},
+		{func() {}, nil, "nil"},
+		{func() (int, string) { return 1, "a" }, nil, "[1, a]"},
+	}
+	for _, tt := range tests {
+		ob, err := FromGo(tt.fn)
+		if err != nil {
+			t.Fatalf("FromGo returned error: %s", err)
+		}
+		builtIn, ok := ob.(*BuiltIn)
+		if !ok {
+			t.Fatalf("FromGo did not return a builtin. got=%T", ob)
+		}
+		result := builtIn.Func(tt.args...)
+		got := result.Inspect()
+		if errOb, ok := result.(*Error); ok {
+			got = "ERROR: " + errOb.Message
+		}
+		if got != tt.expected {
+			t.Errorf("wrong result. expected=%q, got=%q", tt.expected, got)
+		}
+	}
+}
+
+func TestDefine(t *testing.T) {
+	repeat := Define("repeat", strings.Repeat)
+	pad := Define("pad", func(s string, width int, fill string) string {
+		for len(s) < width {
+			s = fill + s
+		}
+		return s
+	}, 4, " ")
+	sum := Define("sum", func(first *Integer, rest ...int64) int64 {
+		for _, n := range rest {
+			first = &Integer{Value: first.Value + n}
+		}
+		return first.Value
+	})
+	str := func(s string) Object { return &String{Value: s} }
+	num := func(n int64) Object { return &Integer{Value: n} }
+
+	tests := []struct {
+		builtIn  *BuiltIn
+		args     []Object
+		expected string
+		kind     string
+	}{
+		{repeat, []Object{str("ab"), num(2)}, "abab", ""},
+		{repeat, []Object{str("ab")}, "wrong number of arguments. got=1, want=2", ArgumentErrorKind},
+		{repeat, []Object{num(2), num(2)}, "argument to `repeat` must be STRING, got INTEGER", TypeErrorKind},
+		{repeat, []Object{str("ab"), str("2")}, "argument 2 to `repeat` must be INTEGER, got STRING", TypeErrorKind},
+		{pad, []Object{str("7")}, "   7", ""},
+		{pad, []Object{str("7"), num(3)}, "  7", ""},
+		{pad, []Object{str("7"), num(3), str("0")}, "007", ""},
+		{pad, nil, "wrong number of arguments. got=0, want=1 to 3", ArgumentErrorKind},
+		{sum, []Object{num(1), num(2), num(3)}, "6", ""},
+		{sum, []Object{num(1), num(2), NULL}, "argument 3 to `sum` must be INTEGER, got NULL", TypeErrorKind},
+		{sum, []Object{NULL}, "argument to `sum` must be INTEGER, got NULL", TypeErrorKind},
+		{sum, nil, "wrong number of arguments. got=0, want at least 1", ArgumentErrorKind},
+	}
+	for _, tt := range tests {
+		result := tt.builtIn.Func(tt.args...)
+		got, kind := result.Inspect(), ""
+		if errOb, ok := result.(*Error); ok {
+			got, kind = errOb.Message, errOb.Kind
+		}
+		if got != tt.expected || kind != tt.kind {
+			t.Errorf("wrong result. expected=%q (%q), got=%q (%q)", tt.expected, tt.kind, got, kind)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Define did not panic for a default of the wrong type")
+		}
+	}()
+	Define("bad", func(n int) int { return n }, "one")
+}
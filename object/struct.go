@@ -0,0 +1,98 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StructType is a type declared by a struct statement, as in 'struct Point
+// { x, y }'. Calling it with a value for each field makes a Struct.
+type StructType struct {
+	Name   string
+	Fields []string
+	index  map[string]int // of each field in Fields
+}
+
+func NewStructType(name string, fields []string) *StructType {
+	index := make(map[string]int, len(fields))
+	for idx, field := range fields {
+		index[field] = idx
+	}
+	return &StructType{Name: name, Fields: fields, index: index}
+}
+
+func (st *StructType) Type() ObjectType { return STRUCT_TYPE_OBJ }
+
+func (st *StructType) Inspect() string { return "<struct " + st.Name + ">" }
+
+// New makes a struct of this type with the fields set to values, in the
+// order they were declared in.
+func (st *StructType) New(values ...Object) Object {
+	if len(values) != len(st.Fields) {
+		return newError(ArgumentErrorKind, "wrong number of arguments. got=%d, want=%d", len(values), len(st.Fields))
+	}
+	return &Struct{Def: st, Values: append([]Object(nil), values...)}
+}
+
+// Struct is a value of a StructType, with its fields read and set with '.'
+// like attributes.
+type Struct struct {
+	Def    *StructType
+	Values []Object // by index of the field in Def.Fields
+
+	inspecting bool // set while Inspect runs, to print a struct within itself as ...
+}
+
+func (sv *Struct) Type() ObjectType { return STRUCT_OBJ }
+
+func (sv *Struct) Inspect() string {
+	if sv.inspecting {
+		return "..."
+	}
+	sv.inspecting = true
+	defer func() { sv.inspecting = false }()
+
+	var out strings.Builder
+
+	out.WriteString(sv.Def.Name)
+	out.WriteString("{")
+	for idx, field := range sv.Def.Fields {
+		if idx > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(field + ": " + sv.Values[idx].Inspect())
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// GetAttr returns the field called name. Reading a field the struct does
+// not have raises an AttributeError naming its type.
+func (sv *Struct) GetAttr(name string) (Object, bool) {
+	idx, ok := sv.Def.index[name]
+	if !ok {
+		return newError(AttributeErrorKind, "%s", &fieldError{sv.Def.Name, name}), true
+	}
+	return sv.Values[idx], true
+}
+
+func (sv *Struct) SetAttr(name string, value Object) error {
+	idx, ok := sv.Def.index[name]
+	if !ok {
+		return &fieldError{sv.Def.Name, name}
+	}
+	sv.Values[idx] = value
+	return nil
+}
+
+// fieldError reports a field a struct does not have.
+type fieldError struct {
+	structName, field string
+}
+
+func (err *fieldError) Error() string {
+	return fmt.Sprintf("%s has no field %s", err.structName, err.field)
+}
+
+func (err *fieldError) Is(target error) bool { return target == ErrNoAttribute }
@@ -0,0 +1,325 @@
+// Package optimize simplifies syntax trees without changing what they
+// evaluate to.
+package optimize
+
+import (
+	"math"
+	"strconv"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/lint"
+	"Interpreter_in_Go/token"
+)
+
+// maxRounds bounds how often folding and inlining are repeated; each round
+// may expose new constants to the next one.
+const maxRounds = 8
+
+// Optimize rewrites root in place:
+//
+//   - operators applied to integer, string and boolean literals are folded
+//     into a literal, unless evaluating them fails, e.g. on division by zero
+//     or a type mismatch, which is left to run time;
+//   - if expressions whose condition is a literal are replaced by the branch
+//     taken;
+//   - uses of let bindings of a literal are replaced by the literal, when the
+//     name is never redeclared and the let is sure to have run before the use.
+//
+// The resolver annotations of root remain valid, so it may be resolved
+// before or after being optimized.
+func Optimize(root *ast.RootStatement) {
+	for round := 0; round < maxRounds; round++ {
+		changed := false
+		ast.Rewrite(root, func(node ast.Node) ast.Node {
+			folded := fold(node)
+			changed = changed || folded != node
+			return folded
+		})
+		if pruneStatements(root) {
+			changed = true
+		}
+		if inlineConstants(root) {
+			changed = true
+		}
+		if !changed {
+			return
+		}
+	}
+}
+
+func fold(node ast.Node) ast.Node {
+	switch node := node.(type) {
+	case *ast.PrefixExpression:
+		if folded := foldPrefix(node); folded != nil {
+			return folded
+		}
+	case *ast.InfixExpression:
+		if folded := foldInfix(node); folded != nil {
+			return folded
+		}
+	case *ast.IfExpression:
+		// the branch can only replace the if when it is a single expression;
+		// other constant ifs are handled where they are statements
+		if branch, ok := takenBranch(node); ok && branch != nil && len(branch.Statements) == 1 {
+			if stmt, ok := branch.Statements[0].(*ast.ExpressionStatement); ok && stmt.Expression != nil {
+				return stmt.Expression
+			}
+		}
+	}
+	return node
+}
+
+func foldPrefix(node *ast.PrefixExpression) ast.Expression {
+	switch node.Operator {
+	case "-":
+		if right, ok := node.Right.(*ast.IntegerLiteral); ok && right.Value != math.MinInt64 {
+			return integerLiteral(node.Pos(), -right.Value)
+		}
+	case "!":
+		if truthy, ok := truthiness(node.Right); ok {
+			return booleanLiteral(node.Pos(), !truthy)
+		}
+	}
+	return nil
+}
+
+func foldInfix(node *ast.InfixExpression) ast.Expression {
+	pos := node.Pos()
+
+	switch left := node.Left.(type) {
+	case *ast.IntegerLiteral:
+		if right, ok := node.Right.(*ast.IntegerLiteral); ok {
+			return foldIntegers(pos, node.Operator, left.Value, right.Value)
+		}
+	case *ast.StringLiteral:
+		if right, ok := node.Right.(*ast.StringLiteral); ok {
+			switch node.Operator {
+			case "+":
+				return stringLiteral(pos, left.Value+right.Value)
+			case "==":
+				return booleanLiteral(pos, left.Value == right.Value)
+			case "!=":
+				return booleanLiteral(pos, left.Value != right.Value)
+			}
+			return nil
+		}
+	case *ast.Boolean:
+		if right, ok := node.Right.(*ast.Boolean); ok {
+			switch node.Operator {
+			case "==":
+				return booleanLiteral(pos, left.Value == right.Value)
+			case "!=":
+				return booleanLiteral(pos, left.Value != right.Value)
+			}
+			return nil
+		}
+	}
+	// literals of different types are never the same object
+	if isLiteral(node.Left) && isLiteral(node.Right) {
+		switch node.Operator {
+		case "==":
+			return booleanLiteral(pos, false)
+		case "!=":
+			return booleanLiteral(pos, true)
+		}
+	}
+	return nil
+}
+
+func foldIntegers(pos token.Position, operator string, left, right int64) ast.Expression {
+	var value int64
+	switch operator {
+	case "+":
+		value = left + right
+	case "-":
+		value = left - right
+	case "*":
+		value = left * right
+	case "/":
+		if right == 0 || (left == math.MinInt64 && right == -1) {
+			return nil
+		}
+		value = left / right
+	case "<":
+		return booleanLiteral(pos, left < right)
+	case ">":
+		return booleanLiteral(pos, left > right)
+	case "==":
+		return booleanLiteral(pos, left == right)
+	case "!=":
+		return booleanLiteral(pos, left != right)
+	default:
+		return nil
+	}
+	if value == math.MinInt64 {
+		// has no literal form
+		return nil
+	}
+	return integerLiteral(pos, value)
+}
+
+// takenBranch returns the branch an if expression with a literal condition
+// takes; nil when the condition is false and there is no else.
+func takenBranch(node *ast.IfExpression) (*ast.BlockStatement, bool) {
+	truthy, ok := truthiness(node.Condition)
+	if !ok {
+		return nil, false
+	}
+	if truthy {
+		return node.Consequence, true
+	}
+	return node.Alternative, true
+}
+
+// truthiness reports whether a literal is truthy; ok is false for other
+// expressions.
+func truthiness(expr ast.Expression) (truthy bool, ok bool) {
+	switch expr := expr.(type) {
+	case *ast.Boolean:
+		return expr.Value, true
+	case *ast.IntegerLiteral, *ast.StringLiteral:
+		return true, true
+	}
+	return false, false
+}
+
+func isLiteral(expr ast.Expression) bool {
+	_, ok := truthiness(expr)
+	return ok
+}
+
+// pruneStatements replaces if statements with a literal condition by the
+// statements of the branch taken. As blocks do not introduce a scope, this
+// leaves the bindings made by the branch unchanged. A branch without
+// statements replaces the final statement of a body only when it has one,
+// since the body's value would change otherwise.
+func pruneStatements(root *ast.RootStatement) bool {
+	changed := false
+	ast.Inspect(root, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.RootStatement:
+			node.Statements, changed = spliceBranches(node.Statements, changed)
+		case *ast.BlockStatement:
+			node.Statements, changed = spliceBranches(node.Statements, changed)
+		}
+		return true
+	})
+	return changed
+}
+
+func spliceBranches(stmts []ast.Statement, changed bool) ([]ast.Statement, bool) {
+	var spliced []ast.Statement
+	for idx, stmt := range stmts {
+		exprStmt, ok := stmt.(*ast.ExpressionStatement)
+		if !ok {
+			spliced = append(spliced, stmt)
+			continue
+		}
+		ifExpr, ok := exprStmt.Expression.(*ast.IfExpression)
+		if !ok {
+			spliced = append(spliced, stmt)
+			continue
+		}
+		branch, ok := takenBranch(ifExpr)
+		last := idx == len(stmts)-1
+		switch {
+		case !ok:
+			spliced = append(spliced, stmt)
+		case branch != nil && len(branch.Statements) > 0:
+			spliced = append(spliced, branch.Statements...)
+			changed = true
+		case !last:
+			changed = true
+		default:
+			spliced = append(spliced, stmt)
+		}
+	}
+	return spliced, changed
+}
+
+// inlineConstants replaces uses of let bindings of literals. Only lets
+// directly in a function body or the program qualify, and only uses that
+// follow them in the source, so the binding has always been made by the time
+// the use runs.
+func inlineConstants(root *ast.RootStatement) bool {
+	candidates := map[*ast.Identifier]*ast.LetStatement{}
+	addLets := func(stmts []ast.Statement) {
+		for _, stmt := range stmts {
+			if let, ok := stmt.(*ast.LetStatement); ok && let.Name != nil && isLiteral(let.Value) {
+				candidates[let.Name] = let
+			}
+		}
+	}
+	addLets(root.Statements)
+	ast.Inspect(root, func(node ast.Node) bool {
+		if fn, ok := node.(*ast.FunctionLiteral); ok && fn.Body != nil {
+			addLets(fn.Body.Statements)
+		}
+		return true
+	})
+
+	replacements := map[*ast.Identifier]ast.Expression{}
+	for _, binding := range lint.Resolve(root).Bindings {
+		let, ok := candidates[binding.Name]
+		if !ok || binding.Redeclared {
+			continue
+		}
+		for _, use := range binding.Uses {
+			if let.Pos().Before(use.Pos()) {
+				replacements[use] = let.Value
+			}
+		}
+	}
+	// Names a class extends or a pattern matches variants of must stay names.
+	ast.Inspect(root, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.ClassStatement:
+			delete(replacements, node.Super)
+		case *ast.VariantPattern:
+			delete(replacements, node.Enum)
+		}
+		return true
+	})
+	if len(replacements) == 0 {
+		return false
+	}
+	ast.Rewrite(root, func(node ast.Node) ast.Node {
+		if ident, ok := node.(*ast.Identifier); ok {
+			if value, ok := replacements[ident]; ok {
+				return copyLiteral(value, ident.Pos())
+			}
+		}
+		return node
+	})
+	return true
+}
+
+func copyLiteral(expr ast.Expression, pos token.Position) ast.Expression {
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral:
+		return integerLiteral(pos, expr.Value)
+	case *ast.StringLiteral:
+		return stringLiteral(pos, expr.Value)
+	case *ast.Boolean:
+		return booleanLiteral(pos, expr.Value)
+	}
+	return expr
+}
+
+func integerLiteral(pos token.Position, value int64) *ast.IntegerLiteral {
+	literal := strconv.FormatInt(value, 10)
+	return &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: literal, Pos: pos}, Value: value}
+}
+
+func stringLiteral(pos token.Position, value string) *ast.StringLiteral {
+	return &ast.StringLiteral{Token: token.Token{Type: token.STRING, Literal: value, Pos: pos}, Value: value}
+}
+
+func booleanLiteral(pos token.Position, value bool) *ast.Boolean {
+	var tokenType token.TokenType = token.FALSE
+	literal := "false"
+	if value {
+		tokenType, literal = token.TRUE, "true"
+	}
+	return &ast.Boolean{Token: token.Token{Type: tokenType, Literal: literal, Pos: pos}, Value: value}
+}
@@ -0,0 +1,119 @@
+package optimize
+
+import (
+	"testing"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/evaluator"
+	"Interpreter_in_Go/format"
+	"Interpreter_in_Go/lexer"
+	"Interpreter_in_Go/object"
+	"Interpreter_in_Go/parser"
+	"Interpreter_in_Go/resolver"
+)
+
+func parse(t *testing.T, input string) *ast.RootStatement {
+	t.Helper()
+	psr := parser.NewParser(lexer.NewLexer(input))
+	root := psr.ParseRootStatement()
+	if len(psr.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, psr.Errors())
+	}
+	return root
+}
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"60 * 60 * 24", "86400;\n"},
+		{`"prefix" + "-" + "suffix"`, "\"prefix-suffix\";\n"},
+		{"1 - 5 * 2", "-9;\n"},
+		{"!true == false", "true;\n"},
+		{"-(2 + 3)", "-5;\n"},
+		{`1 < 2 == ("a" != "b")`, "true;\n"},
+		{`1 == "1"`, "false;\n"},
+		{"x + 1 * 2", "x + 2;\n"},
+
+		// failures are left to run time
+		{"1 / 0", "1 / 0;\n"},
+		{"true + 1", "true + 1;\n"},
+		{"-true", "-true;\n"},
+
+		{"if (true) { 1 } else { 2 }", "1;\n"},
+		{"let a = if (1 > 2) { x } else { y };", "let a = y;\n"},
+		{"if (false) { puts(1); }; 2", "2;\n"},
+		{"if (true) { let a = 1; puts(a); }", "let a = 1;\nputs(1);\n"},
+		// the value of the program is that of the if
+		{"1; if (false) { 2 }", "1;\nif (false) { 2 }\n"},
+
+		{"let a = 2; let b = a * 3; puts(b)", "let a = 2;\nlet b = 6;\nputs(6);\n"},
+		{"let debug = false; if (debug) { puts(1) }; 0", "let debug = false;\n0;\n"},
+		{"let f = func() { let n = 10; n * n };", "let f = func() {\n\tlet n = 10;\n\t100;\n};\n"},
+
+		// not known to be bound or constant when used
+		{"puts(a); let a = 1;", "puts(a);\nlet a = 1;\n"},
+		{"let f = func() { a }; let a = 1;", "let f = func() { a };\nlet a = 1;\n"},
+		{"let a = 1; let f = func() { a }; let a = 2; a", "let a = 1;\nlet f = func() { a };\nlet a = 2;\n2;\n"},
+		{"if (x) { let a = 1; }; a", "if (x) { let a = 1; }\na;\n"},
+		{"let a = [1]; a", "let a = [1];\na;\n"},
+		{"let len = 1; [len, first(x)]", "let len = 1;\n[1, first(x)];\n"},
+		{"let n = 1; let [a = n] = xs; puts(a)", "let n = 1;\nlet [a = 1] = xs;\nputs(a);\n"},
+		// names that are not expressions
+		{"let A = 1; class B extends A {}", "let A = 1;\nclass B extends A {}\n"},
+		{"let E = 1; match (x) { E.V => E }", "let E = 1;\nmatch (x) { E.V => 1 }\n"},
+	}
+	for _, tt := range tests {
+		root := parse(t, tt.input)
+		Optimize(root)
+		if got := format.Node(root); got != tt.expected {
+			t.Errorf("wrong optimization of %q.\nwant=%q\ngot=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestSemanticsAreUnchanged(t *testing.T) {
+	programs := []string{
+		"60 * 60 * 24",
+		`let sep = "-"; "prefix" + sep + "suffix"`,
+		"let a = 5; let b = a * 2 - 3; let f = func(x) { x * b + a }; f(4)",
+		"if (10 > 5) { let big = true; }; big",
+		"let x = 10; let f = func() { let y = x; let x = 1; x + y }; f()",
+		"let f = func(n) { if (true) { return n * 2; } n }; f(21)",
+		"let f = func() { if (false) { 1 } }; f()",
+		"let a = 1; if (a == 1) { 5 } else { 6 }",
+		"1 / 0 == 1",
+		"5 + true",
+		"-\"a\"",
+		"let f = func() { missing }; let missing = 2; f()",
+		"let s = \"a\"; [s == \"a\", s + s, !s, -(1 - 9)]",
+		"let h = {\"k\" + \"ey\": 1 + 1}; h[\"key\"]",
+	}
+	for _, input := range programs {
+		want := evaluate(t, input, false)
+		got := evaluate(t, input, true)
+		if want != got {
+			t.Errorf("optimizing %q changed its result. want=%s, got=%s", input, want, got)
+		}
+	}
+}
+
+func evaluate(t *testing.T, input string, optimize bool) (result string) {
+	t.Helper()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result = "panic"
+		}
+	}()
+	root := parse(t, input)
+	resolver.New().Resolve(root)
+	if optimize {
+		Optimize(root)
+	}
+	evaluated := evaluator.Evaluate(root, object.NewEnvironment())
+	if evaluated == nil {
+		return "<nil>"
+	}
+	return evaluated.Inspect()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/lexer"
+	"Interpreter_in_Go/optimize"
+	"Interpreter_in_Go/parser"
+)
+
+// parseCommand implements 'flint parse [-json] [-O] [file]'. It prints the
+// tree parsed from the file, or from stdin without one, in the parenthesized
+// form of ast.Node.String or, with -json, as encoded by ast.EncodeJSON. With
+// -O the tree is printed as optimized.
+func parseCommand(args []string) int {
+	flags := flag.NewFlagSet("parse", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the syntax tree as JSON")
+	optimized := optimizeFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	name, src, err := readSource(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	root, ok := parseSource(name, src)
+	if !ok {
+		return 1
+	}
+	if *optimized {
+		optimize.Optimize(root)
+	}
+	if !*asJSON {
+		fmt.Println(root.String())
+		return 0
+	}
+	encoded, err := ast.EncodeJSON(root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	var out bytes.Buffer
+	_ = json.Indent(&out, encoded, "", "  ")
+	out.WriteString("\n")
+	_, _ = out.WriteTo(os.Stdout)
+	return 0
+}
+
+// readSource reads the named file, or stdin when name is empty.
+func readSource(name string) (string, []byte, error) {
+	if name == "" {
+		src, err := io.ReadAll(os.Stdin)
+		return "<stdin>", src, err
+	}
+	src, err := os.ReadFile(name)
+	return name, src, err
+}
+
+// parseSource parses src, reporting any parser errors on stderr.
+func parseSource(name string, src []byte) (*ast.RootStatement, bool) {
+	psr := parser.NewParser(lexer.NewLexer(string(src)))
+	root := psr.ParseRootStatement()
+
+	if len(psr.Errors()) != 0 {
+		for _, err := range psr.Errors() {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", name, err)
+		}
+		return nil, false
+	}
+	return root, true
+}
@@ -22,16 +22,19 @@ const (
 )
 
 var precedences = map[token.TokenType]int{
-	token.EQ:        EQUALS,
-	token.NOT_EQ:    EQUALS,
-	token.LT:        LESSGREATER,
-	token.GT:        LESSGREATER,
-	token.PLUS:      SUM,
-	token.MINUS:     SUM,
-	token.SLASH:     PRODUCT,
-	token.ASTERISK:  PRODUCT,
-	token.L_PAREN:   CALL,
-	token.L_BRACKET: INDEX,
+	token.EQ:         EQUALS,
+	token.NOT_EQ:     EQUALS,
+	token.LT:         LESSGREATER,
+	token.GT:         LESSGREATER,
+	token.INSTANCEOF: LESSGREATER,
+	token.PLUS:       SUM,
+	token.MINUS:      SUM,
+	token.SLASH:      PRODUCT,
+	token.ASTERISK:   PRODUCT,
+	token.L_PAREN:    CALL,
+	token.L_BRACKET:  INDEX,
+	token.QUESTION:   INDEX,
+	token.DOT:        INDEX,
 }
 
 type (
@@ -40,8 +43,9 @@ type (
 )
 
 type Parser struct {
-	lxr    *lexer.Lexer
-	errors []string
+	lxr      *lexer.Lexer
+	errors   []string
+	comments []*ast.Comment
 
 	curToken  token.Token
 	peekToken token.Token
@@ -73,6 +77,7 @@ func (psr *Parser) ParseRootStatement() *ast.RootStatement {
 		}
 		psr.nextToken()
 	}
+	root.Comments = psr.comments
 	return root
 }
 
@@ -82,6 +87,20 @@ func (psr *Parser) parseStatement() ast.Statement {
 		return psr.parseLetStatement()
 	case token.RETURN:
 		return psr.parseReturnStatement()
+	case token.THROW:
+		return psr.parseThrowStatement()
+	case token.DEFER:
+		return psr.parseDeferStatement()
+	case token.IMPORT:
+		return psr.parseImportStatement()
+	case token.EXPORT:
+		return psr.parseExportStatement()
+	case token.STRUCT:
+		return psr.parseStructStatement()
+	case token.CLASS:
+		return psr.parseClassStatement()
+	case token.ENUM:
+		return psr.parseEnumStatement()
 	default:
 		return psr.parseExpressionStatement()
 	}
@@ -89,10 +108,17 @@ func (psr *Parser) parseStatement() ast.Statement {
 
 func (psr *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: psr.curToken}
-	if !psr.expectPeek(token.IDENT) {
-		return nil
+	if psr.peekTokenIs(token.L_BRACKET) || psr.peekTokenIs(token.L_BRACE) {
+		psr.nextToken()
+		if stmt.Pattern = psr.parsePattern(); stmt.Pattern == nil {
+			return nil
+		}
+	} else {
+		if !psr.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Name = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
 	}
-	stmt.Name = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
 
 	if !psr.expectPeek(token.ASSIGN) {
 		return nil
@@ -117,9 +143,276 @@ func (psr *Parser) parseReturnStatement() *ast.ReturnStatement {
 	return stmt
 }
 
-func (psr *Parser) parseExpressionStatement() *ast.ExpressionStatement {
+func (psr *Parser) parseThrowStatement() *ast.ThrowStatement {
+	stmt := &ast.ThrowStatement{Token: psr.curToken}
+	psr.nextToken()
+	stmt.Value = psr.parseExpression(LOWEST)
+
+	if psr.peekTokenIs(token.SEMICOLON) {
+		psr.nextToken()
+	}
+	return stmt
+}
+
+func (psr *Parser) parseDeferStatement() *ast.DeferStatement {
+	stmt := &ast.DeferStatement{Token: psr.curToken}
+	psr.nextToken()
+	expr := psr.parseExpression(LOWEST)
+	call, ok := expr.(*ast.CallExpression)
+	if !ok {
+		if expr != nil {
+			msg := fmt.Sprintf("expression in defer must be a function call, got %s", expr)
+			psr.errors = append(psr.errors, msg)
+		}
+		return nil
+	}
+	stmt.Call = call
+
+	if psr.peekTokenIs(token.SEMICOLON) {
+		psr.nextToken()
+	}
+	return stmt
+}
+
+func (psr *Parser) parseImportStatement() *ast.ImportStatement {
+	stmt := &ast.ImportStatement{Token: psr.curToken}
+
+	if psr.peekTokenIs(token.L_BRACE) {
+		psr.nextToken()
+		if stmt.Names = psr.parseImportNames(); stmt.Names == nil {
+			return nil
+		}
+		if !psr.expectWord("from") || !psr.expectPeek(token.STRING) {
+			return nil
+		}
+		stmt.Path = &ast.StringLiteral{Token: psr.curToken, Value: psr.curToken.Literal}
+	} else {
+		if !psr.expectPeek(token.STRING) {
+			return nil
+		}
+		stmt.Path = &ast.StringLiteral{Token: psr.curToken, Value: psr.curToken.Literal}
+		if !psr.expectWord("as") || !psr.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Alias = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+	}
+	if psr.peekTokenIs(token.SEMICOLON) {
+		psr.nextToken()
+	}
+	return stmt
+}
+
+// parseImportNames parses the braced list of names of a selective import.
+func (psr *Parser) parseImportNames() []*ast.Identifier {
+	var names []*ast.Identifier
+
+	for {
+		if !psr.expectPeek(token.IDENT) {
+			return nil
+		}
+		names = append(names, &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal})
+		if !psr.peekTokenIs(token.COMMA) {
+			break
+		}
+		psr.nextToken()
+	}
+	if !psr.expectPeek(token.R_BRACE) {
+		return nil
+	}
+	return names
+}
+
+func (psr *Parser) parseExportStatement() *ast.ExportStatement {
+	stmt := &ast.ExportStatement{Token: psr.curToken}
+	if !psr.expectPeek(token.LET) {
+		return nil
+	}
+	if stmt.Let = psr.parseLetStatement(); stmt.Let == nil {
+		return nil
+	}
+	if stmt.Let.Name == nil {
+		psr.errors = append(psr.errors, "cannot export a destructuring let")
+		return nil
+	}
+	return stmt
+}
+
+func (psr *Parser) parseStructStatement() *ast.StructStatement {
+	stmt := &ast.StructStatement{Token: psr.curToken}
+	if !psr.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+	if !psr.expectPeek(token.L_BRACE) {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for !psr.peekTokenIs(token.R_BRACE) {
+		if !psr.expectPeek(token.IDENT) {
+			return nil
+		}
+		field := psr.curToken.Literal
+		if seen[field] {
+			msg := fmt.Sprintf("duplicate field %s in struct %s", field, stmt.Name.Value)
+			psr.errors = append(psr.errors, msg)
+			return nil
+		}
+		seen[field] = true
+		stmt.Fields = append(stmt.Fields, field)
+
+		if !psr.peekTokenIs(token.R_BRACE) && !psr.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+	psr.nextToken()
+
+	if psr.peekTokenIs(token.SEMICOLON) {
+		psr.nextToken()
+	}
+	return stmt
+}
+
+func (psr *Parser) parseClassStatement() *ast.ClassStatement {
+	stmt := &ast.ClassStatement{Token: psr.curToken}
+	if !psr.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+	if psr.peekTokenIs(token.IDENT) && psr.peekToken.Literal == "extends" {
+		psr.nextToken()
+		if !psr.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Super = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+	}
+	if !psr.expectPeek(token.L_BRACE) {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for !psr.peekTokenIs(token.R_BRACE) {
+		if !psr.expectPeek(token.IDENT) {
+			return nil
+		}
+		method := psr.parseMethod()
+		if method == nil {
+			return nil
+		}
+		if seen[method.Name] {
+			msg := fmt.Sprintf("duplicate method %s in class %s", method.Name, stmt.Name.Value)
+			psr.errors = append(psr.errors, msg)
+			return nil
+		}
+		seen[method.Name] = true
+		stmt.Methods = append(stmt.Methods, method)
+
+		if psr.peekTokenIs(token.SEMICOLON) {
+			psr.nextToken()
+		}
+	}
+	psr.nextToken()
+	stmt.Close = psr.curToken
+
+	if psr.peekTokenIs(token.SEMICOLON) {
+		psr.nextToken()
+	}
+	return stmt
+}
+
+// parseMethod parses 'name(params) { body }' within a class, giving the
+// function the implicit self and super parameters.
+func (psr *Parser) parseMethod() *ast.Method {
+	method := &ast.Method{Token: psr.curToken, Name: psr.curToken.Literal}
+	fnLit := &ast.FunctionLiteral{Token: psr.curToken}
+	for _, name := range ast.SelfParameters {
+		fnLit.Parameters = append(fnLit.Parameters, &ast.Identifier{
+			Token: token.Token{Type: token.IDENT, Literal: name, Pos: psr.curToken.Pos},
+			Value: name,
+		})
+	}
+	if !psr.expectPeek(token.L_PAREN) {
+		return nil
+	}
+	params, patterns, ok := psr.parseFunctionParameters()
+	if !ok {
+		return nil
+	}
+	for _, param := range params {
+		for _, name := range ast.SelfParameters {
+			if param != nil && param.Value == name {
+				msg := fmt.Sprintf("%s cannot be a parameter of method %s", name, method.Name)
+				psr.errors = append(psr.errors, msg)
+				return nil
+			}
+		}
+	}
+	if patterns != nil {
+		fnLit.Patterns = append(make([]ast.Pattern, len(fnLit.Parameters)), patterns...)
+	}
+	fnLit.Parameters = append(fnLit.Parameters, params...)
+	if !psr.expectPeek(token.L_BRACE) {
+		return nil
+	}
+	fnLit.Body = psr.parseBlockStatement()
+	method.Function = fnLit
+	return method
+}
+
+func (psr *Parser) parseEnumStatement() *ast.EnumStatement {
+	stmt := &ast.EnumStatement{Token: psr.curToken}
+	if !psr.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+	if !psr.expectPeek(token.L_BRACE) {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for !psr.peekTokenIs(token.R_BRACE) {
+		if !psr.expectPeek(token.IDENT) {
+			return nil
+		}
+		variant := &ast.Variant{Token: psr.curToken, Name: psr.curToken.Literal}
+		if seen[variant.Name] {
+			msg := fmt.Sprintf("duplicate variant %s in enum %s", variant.Name, stmt.Name.Value)
+			psr.errors = append(psr.errors, msg)
+			return nil
+		}
+		seen[variant.Name] = true
+		if psr.peekTokenIs(token.L_PAREN) {
+			psr.nextToken()
+			fields, patterns, ok := psr.parseFunctionParameters()
+			if !ok {
+				return nil
+			}
+			if patterns != nil {
+				msg := fmt.Sprintf("fields of variant %s must be names", variant.Name)
+				psr.errors = append(psr.errors, msg)
+				return nil
+			}
+			for _, field := range fields {
+				variant.Fields = append(variant.Fields, field.Value)
+			}
+		}
+		stmt.Variants = append(stmt.Variants, variant)
+
+		if !psr.peekTokenIs(token.R_BRACE) && !psr.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+	psr.nextToken()
+
+	if psr.peekTokenIs(token.SEMICOLON) {
+		psr.nextToken()
+	}
+	return stmt
+}
+
+func (psr *Parser) parseExpressionStatement() ast.Statement {
 	stmt := &ast.ExpressionStatement{Token: psr.curToken}
 	stmt.Expression = psr.parseExpression(LOWEST)
+	if psr.peekTokenIs(token.ASSIGN) {
+		return psr.parseAssignStatement(stmt.Expression)
+	}
 
 	if psr.peekTokenIs(token.SEMICOLON) {
 		psr.nextToken()
@@ -127,6 +420,29 @@ func (psr *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	return stmt
 }
 
+// parseAssignStatement parses the '= value' assigned to target, which must
+// be a member expression such as 'user.name'.
+func (psr *Parser) parseAssignStatement(target ast.Expression) *ast.AssignStatement {
+	psr.nextToken()
+	stmt := &ast.AssignStatement{Token: psr.curToken}
+	psr.nextToken()
+	stmt.Value = psr.parseExpression(LOWEST)
+
+	if psr.peekTokenIs(token.SEMICOLON) {
+		psr.nextToken()
+	}
+	member, ok := target.(*ast.MemberExpression)
+	if !ok {
+		if target != nil {
+			msg := fmt.Sprintf("cannot assign to %s", target)
+			psr.errors = append(psr.errors, msg)
+		}
+		return nil
+	}
+	stmt.Target = member
+	return stmt
+}
+
 func (psr *Parser) parseExpression(precedence int) ast.Expression {
 	prefix := psr.prefixParseFns[psr.curToken.Type]
 	if nil == prefix {
@@ -167,9 +483,12 @@ func (psr *Parser) parseGroupedExpression() ast.Expression {
 func (psr *Parser) parseArrayLiteral() ast.Expression {
 	al := &ast.ArrayLiteral{Token: psr.curToken}
 	al.Elements = psr.parseExpressionList(token.R_BRACKET)
+	al.Close = psr.curToken
 	return al
 }
 
+// parseExpressionList parses a comma separated list ending with rb, which
+// may follow a trailing comma.
 func (psr *Parser) parseExpressionList(rb token.TokenType) []ast.Expression {
 	var list []ast.Expression
 
@@ -182,6 +501,9 @@ func (psr *Parser) parseExpressionList(rb token.TokenType) []ast.Expression {
 
 	for psr.peekTokenIs(token.COMMA) {
 		psr.nextToken()
+		if psr.peekTokenIs(rb) {
+			break
+		}
 		psr.nextToken()
 		list = append(list, psr.parseExpression(LOWEST))
 	}
@@ -214,6 +536,7 @@ func (psr *Parser) parseHashLiteral() ast.Expression {
 	if !psr.expectPeek(token.R_BRACE) {
 		return nil
 	}
+	hash.Close = psr.curToken
 	return hash
 }
 
@@ -282,13 +605,252 @@ func (psr *Parser) parseIfExpression() ast.Expression {
 	return expr
 }
 
+func (psr *Parser) parseMatchExpression() ast.Expression {
+	expr := &ast.MatchExpression{Token: psr.curToken}
+	if !psr.expectPeek(token.L_PAREN) {
+		return nil
+	}
+	psr.nextToken()
+	expr.Subject = psr.parseExpression(LOWEST)
+	if !psr.expectPeek(token.R_PAREN) {
+		return nil
+	}
+	if !psr.expectPeek(token.L_BRACE) {
+		return nil
+	}
+	for !psr.peekTokenIs(token.R_BRACE) {
+		psr.nextToken()
+		arm := psr.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		expr.Arms = append(expr.Arms, arm)
+
+		// arms ending with a block need no comma
+		if !psr.peekTokenIs(token.R_BRACE) && (arm.Body == nil || psr.peekTokenIs(token.COMMA)) {
+			if !psr.expectPeek(token.COMMA) {
+				return nil
+			}
+		}
+	}
+	psr.nextToken()
+	expr.Close = psr.curToken
+	return expr
+}
+
+// parseMatchArm parses 'pattern if guard => value', where the value is a
+// block when it starts with '{'.
+func (psr *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{Pattern: psr.parsePattern()}
+	if arm.Pattern == nil {
+		return nil
+	}
+	if psr.peekTokenIs(token.IF) {
+		psr.nextToken()
+		psr.nextToken()
+		arm.Guard = psr.parseExpression(LOWEST)
+	}
+	if !psr.expectPeek(token.ARROW) {
+		return nil
+	}
+	arm.Token = psr.curToken
+	psr.nextToken()
+	if psr.currentTokenIs(token.L_BRACE) {
+		arm.Body = psr.parseBlockStatement()
+	} else {
+		arm.Value = psr.parseExpression(LOWEST)
+	}
+	return arm
+}
+
+// parsePattern parses the pattern starting at the current token.
+func (psr *Parser) parsePattern() ast.Pattern {
+	switch psr.curToken.Type {
+	case token.IDENT:
+		ident := &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+		if !psr.peekTokenIs(token.DOT) {
+			return ident
+		}
+		psr.nextToken()
+		if !psr.expectPeek(token.IDENT) {
+			return nil
+		}
+		pattern := &ast.VariantPattern{Enum: ident, Variant: psr.curToken.Literal}
+		if psr.peekTokenIs(token.L_PAREN) {
+			psr.nextToken()
+			pattern.Fields = psr.parsePatternList(token.R_PAREN)
+			if !psr.currentTokenIs(token.R_PAREN) {
+				return nil
+			}
+		}
+		return pattern
+	case token.INT, token.STRING, token.TRUE, token.FALSE, token.MINUS:
+		value := psr.parseLiteral()
+		if value == nil {
+			return nil
+		}
+		return &ast.LiteralPattern{Value: value}
+	case token.L_BRACKET:
+		pattern := &ast.ArrayPattern{Token: psr.curToken}
+		for !psr.peekTokenIs(token.R_BRACKET) {
+			psr.nextToken()
+			if psr.currentTokenIs(token.ELLIPSIS) {
+				if !psr.expectPeek(token.IDENT) {
+					return nil
+				}
+				pattern.Rest = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+				break
+			}
+			element := psr.parseElementPattern()
+			if element == nil {
+				return nil
+			}
+			pattern.Elements = append(pattern.Elements, element)
+			if !psr.peekTokenIs(token.R_BRACKET) && !psr.expectPeek(token.COMMA) {
+				return nil
+			}
+		}
+		if !psr.expectPeek(token.R_BRACKET) {
+			return nil
+		}
+		return pattern
+	case token.L_BRACE:
+		pattern := &ast.HashPattern{Token: psr.curToken}
+		for !psr.peekTokenIs(token.R_BRACE) {
+			psr.nextToken()
+			key := psr.parseLiteral()
+			if key == nil || !psr.expectPeek(token.COLON) {
+				return nil
+			}
+			psr.nextToken()
+			value := psr.parseElementPattern()
+			if value == nil {
+				return nil
+			}
+			pattern.Keys = append(pattern.Keys, key)
+			pattern.Values = append(pattern.Values, value)
+			if !psr.peekTokenIs(token.R_BRACE) && !psr.expectPeek(token.COMMA) {
+				return nil
+			}
+		}
+		if !psr.expectPeek(token.R_BRACE) {
+			return nil
+		}
+		return pattern
+	}
+	msg := fmt.Sprintf("expected a pattern, got %s instead", psr.curToken.Type)
+	psr.errors = append(psr.errors, msg)
+	return nil
+}
+
+// parseElementPattern parses an element of an array or hash pattern, which
+// may be followed by '= default'.
+func (psr *Parser) parseElementPattern() ast.Pattern {
+	pattern := psr.parsePattern()
+	if pattern == nil || !psr.peekTokenIs(token.ASSIGN) {
+		return pattern
+	}
+	psr.nextToken()
+	dflt := &ast.DefaultPattern{Token: psr.curToken, Pattern: pattern}
+	psr.nextToken()
+	if dflt.Default = psr.parseExpression(LOWEST); dflt.Default == nil {
+		return nil
+	}
+	return dflt
+}
+
+// parsePatternList parses the patterns separated by commas up to end.
+func (psr *Parser) parsePatternList(end token.TokenType) []ast.Pattern {
+	var patterns []ast.Pattern
+
+	for !psr.peekTokenIs(end) {
+		psr.nextToken()
+		pattern := psr.parsePattern()
+		if pattern == nil {
+			return nil
+		}
+		patterns = append(patterns, pattern)
+		if !psr.peekTokenIs(end) && !psr.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+	psr.nextToken()
+	return patterns
+}
+
+// parseLiteral parses an integer, string or boolean literal, or a negated
+// integer, as patterns and the keys of hash patterns take.
+func (psr *Parser) parseLiteral() ast.Expression {
+	tokn := psr.curToken
+	switch tokn.Type {
+	case token.INT, token.STRING, token.TRUE, token.FALSE:
+		return psr.prefixParseFns[tokn.Type]()
+	case token.MINUS:
+		if psr.peekTokenIs(token.INT) {
+			return psr.parsePrefixExpression()
+		}
+	}
+	msg := fmt.Sprintf("expected a literal, got %s instead", tokn.Type)
+	if tokn.Type == token.MINUS {
+		msg = fmt.Sprintf("expected next token to be %s, got %s instead", token.INT, psr.peekToken.Type)
+	}
+	psr.errors = append(psr.errors, msg)
+	return nil
+}
+
+func (psr *Parser) parseTryExpression() ast.Expression {
+	expr := &ast.TryExpression{Token: psr.curToken}
+	if !psr.expectPeek(token.L_BRACE) {
+		return nil
+	}
+	expr.Body = psr.parseBlockStatement()
+
+	if psr.peekTokenIs(token.CATCH) {
+		psr.nextToken()
+
+		if psr.peekTokenIs(token.L_PAREN) {
+			psr.nextToken()
+			if !psr.expectPeek(token.IDENT) {
+				return nil
+			}
+			expr.Param = &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
+			if !psr.expectPeek(token.R_PAREN) {
+				return nil
+			}
+		}
+		if !psr.expectPeek(token.L_BRACE) {
+			return nil
+		}
+		expr.Catch = psr.parseBlockStatement()
+	}
+	if psr.peekTokenIs(token.FINALLY) {
+		psr.nextToken()
+
+		if !psr.expectPeek(token.L_BRACE) {
+			return nil
+		}
+		expr.Finally = psr.parseBlockStatement()
+	}
+	if expr.Catch == nil && expr.Finally == nil {
+		msg := fmt.Sprintf("expected next token to be %s or %s, got %s instead",
+			token.CATCH, token.FINALLY, psr.peekToken.Type)
+		psr.errors = append(psr.errors, msg)
+		return nil
+	}
+	return expr
+}
+
 func (psr *Parser) parseFunctionLiteral() ast.Expression {
 	fnLit := &ast.FunctionLiteral{Token: psr.curToken}
 
 	if !psr.expectPeek(token.L_PAREN) {
 		return nil
 	}
-	fnLit.Parameters = psr.parseFunctionParameters()
+	params, patterns, ok := psr.parseFunctionParameters()
+	if !ok {
+		return nil
+	}
+	fnLit.Parameters, fnLit.Patterns = params, patterns
 	if !psr.expectPeek(token.L_BRACE) {
 		return nil
 	}
@@ -296,27 +858,45 @@ func (psr *Parser) parseFunctionLiteral() ast.Expression {
 	return fnLit
 }
 
-func (psr *Parser) parseFunctionParameters() []*ast.Identifier {
-	var identifiers []*ast.Identifier
-
+// parseFunctionParameters parses the parameters of a function up to the
+// closing ')'. A parameter is a name, or an array or hash pattern that
+// destructures the argument in its place: patterns is nil unless there is
+// one, and otherwise holds the pattern of each parameter, nil for names.
+func (psr *Parser) parseFunctionParameters() (params []*ast.Identifier, patterns []ast.Pattern, ok bool) {
 	if psr.peekTokenIs(token.R_PAREN) {
 		psr.nextToken()
-		return identifiers
+		return nil, nil, true
 	}
-	psr.nextToken()
-	ident := &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
-	identifiers = append(identifiers, ident)
-
-	for psr.peekTokenIs(token.COMMA) {
-		psr.nextToken()
+	for {
+		if psr.peekTokenIs(token.L_BRACKET) || psr.peekTokenIs(token.L_BRACE) {
+			psr.nextToken()
+			pattern := psr.parsePattern()
+			if pattern == nil {
+				return nil, nil, false
+			}
+			if patterns == nil {
+				patterns = make([]ast.Pattern, len(params))
+			}
+			params = append(params, nil)
+			patterns = append(patterns, pattern)
+		} else {
+			if !psr.expectPeek(token.IDENT) {
+				return nil, nil, false
+			}
+			params = append(params, &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal})
+			if patterns != nil {
+				patterns = append(patterns, nil)
+			}
+		}
+		if !psr.peekTokenIs(token.COMMA) {
+			break
+		}
 		psr.nextToken()
-		ident := &ast.Identifier{Token: psr.curToken, Value: psr.curToken.Literal}
-		identifiers = append(identifiers, ident)
 	}
 	if !psr.expectPeek(token.R_PAREN) {
-		return nil
+		return nil, nil, false
 	}
-	return identifiers
+	return params, patterns, true
 }
 
 func (psr *Parser) parseBlockStatement() *ast.BlockStatement {
@@ -332,12 +912,14 @@ func (psr *Parser) parseBlockStatement() *ast.BlockStatement {
 		}
 		psr.nextToken()
 	}
+	block.Close = psr.curToken
 	return block
 }
 
 func (psr *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	expr := &ast.CallExpression{Token: psr.curToken, Function: function}
 	expr.Arguments = psr.parseExpressionList(token.R_PAREN)
+	expr.Close = psr.curToken
 	return expr
 }
 
@@ -353,10 +935,35 @@ func (psr *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return expr
 }
 
+func (psr *Parser) parsePostfixExpression(left ast.Expression) ast.Expression {
+	return &ast.PostfixExpression{Token: psr.curToken, Operator: psr.curToken.Literal, Left: left}
+}
+
+func (psr *Parser) parseMemberExpression(left ast.Expression) ast.Expression {
+	expr := &ast.MemberExpression{Token: psr.curToken, Object: left}
+	if !psr.expectPeek(token.IDENT) {
+		return nil
+	}
+	expr.Property = psr.curToken.Literal
+	return expr
+}
+
 func (psr *Parser) Errors() []string {
 	return psr.errors
 }
 
+// expectWord is expectPeek for the identifiers that only act as keywords
+// within a statement, such as the 'as' and 'from' of imports.
+func (psr *Parser) expectWord(word string) bool {
+	if psr.peekTokenIs(token.IDENT) && psr.peekToken.Literal == word {
+		psr.nextToken()
+		return true
+	}
+	msg := fmt.Sprintf("expected next token to be %q, got %s instead", word, psr.peekToken.Type)
+	psr.errors = append(psr.errors, msg)
+	return false
+}
+
 func (psr *Parser) peekError(tokn token.TokenType) {
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead",
 		tokn, psr.peekToken.Type)
@@ -371,6 +978,12 @@ func (psr *Parser) noPrefixParseFnError(tokn token.TokenType) {
 func (psr *Parser) nextToken() {
 	psr.curToken = psr.peekToken
 	psr.peekToken = psr.lxr.NextToken()
+
+	for psr.peekToken.Type == token.COMMENT {
+		comment := &ast.Comment{Token: psr.peekToken, Text: psr.peekToken.Literal}
+		psr.comments = append(psr.comments, comment)
+		psr.peekToken = psr.lxr.NextToken()
+	}
 }
 
 func (psr *Parser) currentTokenIs(tokn token.TokenType) bool {
@@ -398,6 +1011,15 @@ func (psr *Parser) peekPrecedence() int {
 	return LOWEST
 }
 
+// Precedence returns the binding power of the given infix operator token,
+// or LOWEST when the token is not an infix operator.
+func Precedence(tokn token.TokenType) int {
+	if pdc, ok := precedences[tokn]; ok {
+		return pdc
+	}
+	return LOWEST
+}
+
 func (psr *Parser) curPrecedence() int {
 	if pdc, ok := precedences[psr.curToken.Type]; ok {
 		return pdc
@@ -438,7 +1060,9 @@ func registerPrefixParseFunctions(psr *Parser) {
 	psr.registerPrefix(token.L_BRACKET, psr.parseArrayLiteral)
 
 	psr.registerPrefix(token.IF, psr.parseIfExpression)
+	psr.registerPrefix(token.TRY, psr.parseTryExpression)
 	psr.registerPrefix(token.FUNCTION, psr.parseFunctionLiteral)
+	psr.registerPrefix(token.MATCH, psr.parseMatchExpression)
 }
 
 func registerInfixParseFunctions(psr *Parser) {
@@ -454,7 +1078,10 @@ func registerInfixParseFunctions(psr *Parser) {
 
 	psr.registerInfix(token.LT, psr.parseInfixExpression)
 	psr.registerInfix(token.GT, psr.parseInfixExpression)
+	psr.registerInfix(token.INSTANCEOF, psr.parseInfixExpression)
 
 	psr.registerInfix(token.L_PAREN, psr.parseCallExpression)
 	psr.registerInfix(token.L_BRACKET, psr.parseIndexExpression)
+	psr.registerInfix(token.QUESTION, psr.parsePostfixExpression)
+	psr.registerInfix(token.DOT, psr.parseMemberExpression)
 }
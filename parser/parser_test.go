@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"Interpreter_in_Go/ast"
@@ -258,6 +259,7 @@ func TestParsingInfixExpressions(t *testing.T) {
 		{"true == true", true, "==", true},
 		{"true != false", true, "!=", false},
 		{"false == false", false, "==", false},
+		{"a instanceof b", "a", "instanceof", "b"},
 	}
 	for _, it := range infixTests {
 		lxr := lexer.NewLexer(it.input)
@@ -298,6 +300,10 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"-a * b",
 			"((-a) * b)",
 		},
+		{
+			"a.b instanceof C == x + 1 instanceof D",
+			"(((a.b) instanceof C) == ((x + 1) instanceof D))",
+		},
 		{
 			"!-a",
 			"(!(-a))",
@@ -401,6 +407,22 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"add(a, [b, c,],)",
+			"add(a, [b, c])",
+		},
+		{
+			"-f(x)? + a[0]?",
+			"((-(f(x)?)) + ((a[0])?))",
+		},
+		{
+			"f(x)?[0]",
+			"((f(x)?)[0])",
+		},
+		{
+			"-a.b.c(x)[0] + d.e",
+			"((-(((a.b).c)(x)[0])) + (d.e))",
+		},
 	}
 	for _, tt := range tests {
 		lxr := lexer.NewLexer(tt.input)
@@ -503,6 +525,408 @@ func TestIfElseExpression(t *testing.T) {
 	}
 }
 
+func TestTryExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`try { f() } catch (e) { e }`, "try f() catch (e) e"},
+		{`try { f() } catch { 0 }`, "try f() catch 0"},
+		{`try { f() } finally { g() }`, "try f() finally g()"},
+		{`try { throw "x"; } catch (e) { throw e; } finally { 1 }`, `try throw x; catch (e) throw e; finally 1`},
+	}
+	for _, tt := range tests {
+		psr := NewParser(lexer.NewLexer(tt.input))
+		root := psr.ParseRootStatement()
+		checkParserErrors(t, psr)
+
+		if len(root.Statements) != 1 {
+			t.Fatalf("root.Statements does not contain 1 statement. got=%d", len(root.Statements))
+		}
+		stmt, ok := root.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("root.Statements[0] is not *ast.ExpressionStatement. got=%T", root.Statements[0])
+		}
+		if _, ok := stmt.Expression.(*ast.TryExpression); !ok {
+			t.Fatalf("stmt.Expression is not *ast.TryExpression. got=%T", stmt.Expression)
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("wrong tree. expected=%q, got=%q", tt.expected, stmt.String())
+		}
+	}
+}
+
+func TestTryWithoutHandler(t *testing.T) {
+	psr := NewParser(lexer.NewLexer(`try { f() } g()`))
+	psr.ParseRootStatement()
+
+	errs := psr.Errors()
+	if len(errs) == 0 || errs[0] != "expected next token to be CATCH or FINALLY, got IDENT instead" {
+		t.Errorf("wrong errors. got=%q", errs)
+	}
+}
+
+func TestDeferStatement(t *testing.T) {
+	psr := NewParser(lexer.NewLexer(`defer close(f, 1);`))
+	root := psr.ParseRootStatement()
+	checkParserErrors(t, psr)
+
+	if len(root.Statements) != 1 {
+		t.Fatalf("root.Statements does not contain 1 statement. got=%d", len(root.Statements))
+	}
+	stmt, ok := root.Statements[0].(*ast.DeferStatement)
+	if !ok {
+		t.Fatalf("root.Statements[0] is not *ast.DeferStatement. got=%T", root.Statements[0])
+	}
+	if stmt.Call.String() != "close(f, 1)" {
+		t.Errorf("stmt.Call is not %q. got=%q", "close(f, 1)", stmt.Call.String())
+	}
+}
+
+func TestDeferWithoutCall(t *testing.T) {
+	psr := NewParser(lexer.NewLexer(`defer x + 1;`))
+	psr.ParseRootStatement()
+
+	errs := psr.Errors()
+	if len(errs) == 0 || errs[0] != "expression in defer must be a function call, got (x + 1)" {
+		t.Errorf("wrong errors. got=%q", errs)
+	}
+}
+
+func TestAssignStatement(t *testing.T) {
+	psr := NewParser(lexer.NewLexer(`user.name = "x" + y; 1`))
+	root := psr.ParseRootStatement()
+	checkParserErrors(t, psr)
+
+	if len(root.Statements) != 2 {
+		t.Fatalf("root.Statements does not contain 2 statements. got=%d", len(root.Statements))
+	}
+	stmt, ok := root.Statements[0].(*ast.AssignStatement)
+	if !ok {
+		t.Fatalf("root.Statements[0] is not *ast.AssignStatement. got=%T", root.Statements[0])
+	}
+	if stmt.Target.Property != "name" || stmt.String() != "(user.name) = (x + y);" {
+		t.Errorf("wrong statement. got=%q", stmt.String())
+	}
+}
+
+func TestAssignErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`x = 1;`, "cannot assign to x"},
+		{`f().x() = 1;`, "cannot assign to (f().x)()"},
+		{`user.1;`, "expected next token to be IDENT, got INT instead"},
+	}
+	for _, tt := range tests {
+		psr := NewParser(lexer.NewLexer(tt.input))
+		psr.ParseRootStatement()
+
+		errs := psr.Errors()
+		if len(errs) != 1 || errs[0] != tt.expected {
+			t.Errorf("wrong errors for %q. got=%q", tt.input, errs)
+		}
+	}
+}
+
+func TestStructStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct Point { x, y }", "struct Point { x, y }"},
+		{"struct Point {\n\tx,\n\ty,\n};", "struct Point { x, y }"},
+		{"struct Unit {}", "struct Unit {}"},
+	}
+	for _, tt := range tests {
+		psr := NewParser(lexer.NewLexer(tt.input))
+		root := psr.ParseRootStatement()
+		checkParserErrors(t, psr)
+
+		if len(root.Statements) != 1 || root.Statements[0].String() != tt.expected {
+			t.Errorf("wrong statements for %q. got=%q", tt.input, root.String())
+		}
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct { x }", "expected next token to be IDENT, got { instead"},
+		{"struct Point { x y }", "expected next token to be ,, got IDENT instead"},
+		{"struct Point { x, x }", "duplicate field x in struct Point"},
+	}
+	for _, tt := range tests {
+		psr := NewParser(lexer.NewLexer(tt.input))
+		psr.ParseRootStatement()
+
+		errs := psr.Errors()
+		if len(errs) == 0 || errs[0] != tt.expected {
+			t.Errorf("wrong errors for %q. got=%q", tt.input, errs)
+		}
+	}
+}
+
+func TestClassStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"class Animal { init(name) { self.name = name } speak() { self.name } }",
+			"class Animal { init(name)(self.name) = name; speak()(self.name) }"},
+		{"class Dog extends Animal {\n\tspeak() { super.speak() };\n};", "class Dog extends Animal { speak()(super.speak)() }"},
+		{"class Empty {}", "class Empty { }"},
+	}
+	for _, tt := range tests {
+		psr := NewParser(lexer.NewLexer(tt.input))
+		root := psr.ParseRootStatement()
+		checkParserErrors(t, psr)
+
+		if len(root.Statements) != 1 || root.Statements[0].String() != tt.expected {
+			t.Errorf("wrong statements for %q. got=%q", tt.input, root.String())
+		}
+	}
+
+	psr := NewParser(lexer.NewLexer("class Point { init(x, y) {} }"))
+	root := psr.ParseRootStatement()
+	checkParserErrors(t, psr)
+	method := root.Statements[0].(*ast.ClassStatement).Methods[0]
+	var params []string
+	for _, param := range method.Function.Parameters {
+		params = append(params, param.Value)
+	}
+	if strings.Join(params, ", ") != "self, super, x, y" {
+		t.Errorf("wrong parameters of init. got=%v", params)
+	}
+}
+
+func TestClassErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"class { }", "expected next token to be IDENT, got { instead"},
+		{"class Dog extends { }", "expected next token to be IDENT, got { instead"},
+		{"class Dog from Animal { }", "expected next token to be {, got IDENT instead"},
+		{"class Dog { speak }", "expected next token to be (, got } instead"},
+		{"class Dog { func() {} }", "expected next token to be IDENT, got FUNCTION instead"},
+		{"class Dog { a() {} a() {} }", "duplicate method a in class Dog"},
+		{"class Dog { init(self) {} }", "self cannot be a parameter of method init"},
+	}
+	for _, tt := range tests {
+		psr := NewParser(lexer.NewLexer(tt.input))
+		psr.ParseRootStatement()
+
+		errs := psr.Errors()
+		if len(errs) == 0 || errs[0] != tt.expected {
+			t.Errorf("wrong errors for %q. got=%q", tt.input, errs)
+		}
+	}
+}
+
+func TestEnumStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"enum Shape { Circle(r), Rect(w, h), Empty }", "enum Shape { Circle(r), Rect(w, h), Empty }"},
+		{"enum Color {\n\tRed,\n\tGreen,\n};", "enum Color { Red, Green }"},
+		{"enum Never {}", "enum Never {}"},
+	}
+	for _, tt := range tests {
+		psr := NewParser(lexer.NewLexer(tt.input))
+		root := psr.ParseRootStatement()
+		checkParserErrors(t, psr)
+
+		if len(root.Statements) != 1 || root.Statements[0].String() != tt.expected {
+			t.Errorf("wrong statements for %q. got=%q", tt.input, root.String())
+		}
+	}
+}
+
+func TestEnumErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"enum { A }", "expected next token to be IDENT, got { instead"},
+		{"enum E { A B }", "expected next token to be ,, got IDENT instead"},
+		{"enum E { A, A(x) }", "duplicate variant A in enum E"},
+	}
+	for _, tt := range tests {
+		psr := NewParser(lexer.NewLexer(tt.input))
+		psr.ParseRootStatement()
+
+		errs := psr.Errors()
+		if len(errs) == 0 || errs[0] != tt.expected {
+			t.Errorf("wrong errors for %q. got=%q", tt.input, errs)
+		}
+	}
+}
+
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match (x) { 1 => a, -2 => b, \"s\" => c, true => d, _ => e }",
+			"match x { 1 => a, (-2) => b, s => c, true => d, _ => e }"},
+		{"match (xs) { [] => 0, [x, [y], ...tail] if x > y => tail, }",
+			"match xs { [] => 0, [x, [y], ...tail] if (x > y) => tail }"},
+		{`match (msg) { {"kind": k, 1: _} => k }`, "match msg { {kind: k, 1: _} => k }"},
+		{"match (s) { Shape.Circle(r) => { puts(r); r } Shape.Empty => 0 }",
+			"match s { Shape.Circle(r) => puts(r)r, Shape.Empty => 0 }"},
+	}
+	for _, tt := range tests {
+		psr := NewParser(lexer.NewLexer(tt.input))
+		root := psr.ParseRootStatement()
+		checkParserErrors(t, psr)
+
+		if len(root.Statements) != 1 || root.Statements[0].String() != tt.expected {
+			t.Errorf("wrong statements for %q. got=%q", tt.input, root.String())
+		}
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match x { _ => 1 }", "expected next token to be (, got IDENT instead"},
+		{"match (x) { 1 + 2 => 3 }", "expected next token to be =>, got + instead"},
+		{"match (x) { f(y) => 1 }", "expected next token to be =>, got ( instead"},
+		{"match (x) { 1 => 2 3 => 4 }", "expected next token to be ,, got INT instead"},
+		{"match (x) { [...a, b] => 1 }", "expected next token to be ], got , instead"},
+		{"match (x) { {k: v} => 1 }", "expected a literal, got IDENT instead"},
+		{"match (x) { func() {} => 1 }", "expected a pattern, got FUNCTION instead"},
+	}
+	for _, tt := range tests {
+		psr := NewParser(lexer.NewLexer(tt.input))
+		psr.ParseRootStatement()
+
+		errs := psr.Errors()
+		if len(errs) == 0 || errs[0] != tt.expected {
+			t.Errorf("wrong errors for %q. got=%q", tt.input, errs)
+		}
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b, ...tail] = xs;", "let [a, b, ...tail] = xs;"},
+		{`let { "name": n, "age": a } = person;`, "let {name: n, age: a} = person;"},
+		{`let [x, y = x + 1, {"k": [z] = []}] = xs;`, "let [x, y = (x + 1), {k: [z] = []}] = xs;"},
+		{`func([a, b], c, {"id": id}) { a }`, "func([a, b], c, {id: id})a"},
+		{`class A { m({"x": x}) { x } }`, "class A { m({x: x})x }"},
+	}
+	for _, tt := range tests {
+		psr := NewParser(lexer.NewLexer(tt.input))
+		root := psr.ParseRootStatement()
+		checkParserErrors(t, psr)
+
+		if len(root.Statements) != 1 || root.Statements[0].String() != tt.expected {
+			t.Errorf("wrong statements for %q. got=%q", tt.input, root.String())
+		}
+	}
+
+	psr := NewParser(lexer.NewLexer("func(a, [b], c) {}"))
+	root := psr.ParseRootStatement()
+	checkParserErrors(t, psr)
+	fn := root.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if len(fn.Parameters) != 3 || fn.Pattern(0) != nil || fn.Pattern(2) != nil {
+		t.Fatalf("wrong parameters. got=%v, patterns=%v", fn.Parameters, fn.Patterns)
+	}
+	if fn.Parameters[0].Value != "a" || fn.Parameters[1] != nil || fn.Parameters[2].Value != "c" {
+		t.Errorf("pattern parameter should leave its name nil. got=%v", fn.Parameters)
+	}
+	if pattern, ok := fn.Pattern(1).(*ast.ArrayPattern); !ok || pattern.String() != "[b]" {
+		t.Errorf("wrong pattern of parameter 1. got=%v", fn.Pattern(1))
+	}
+
+	psr = NewParser(lexer.NewLexer("class A { m(a, [b]) {} }"))
+	root = psr.ParseRootStatement()
+	checkParserErrors(t, psr)
+	method := root.Statements[0].(*ast.ClassStatement).Methods[0]
+	if len(method.Function.Patterns) != 4 || method.Function.Pattern(3) == nil {
+		t.Errorf("patterns not aligned with the parameters of m. got=%v", method.Function.Patterns)
+	}
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, 1 + 2] = xs;", "expected next token to be ,, got + instead"},
+		{"let [a = ] = xs;", "no prefix parse function for ] found"},
+		{"let {a: b} = h;", "expected a literal, got IDENT instead"},
+		{"let [a] xs;", "expected next token to be =, got IDENT instead"},
+		{"export let [a] = xs;", "cannot export a destructuring let"},
+		{"func(1) {}", "expected next token to be IDENT, got INT instead"},
+		{"func(a, [b) {}", "expected next token to be ,, got ) instead"},
+		{"enum E { A([x]) }", "fields of variant A must be names"},
+	}
+	for _, tt := range tests {
+		psr := NewParser(lexer.NewLexer(tt.input))
+		psr.ParseRootStatement()
+
+		errs := psr.Errors()
+		if len(errs) == 0 || errs[0] != tt.expected {
+			t.Errorf("wrong errors for %q. got=%q", tt.input, errs)
+		}
+	}
+}
+
+func TestImportStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "lib/math.fl" as math`, `import "lib/math.fl" as math;`},
+		{`import { sin, cos } from "lib/math.fl";`, `import { sin, cos } from "lib/math.fl";`},
+		{`export let x = 1;`, `export let x = 1;`},
+	}
+	for _, tt := range tests {
+		psr := NewParser(lexer.NewLexer(tt.input))
+		root := psr.ParseRootStatement()
+		checkParserErrors(t, psr)
+
+		if len(root.Statements) != 1 || root.Statements[0].String() != tt.expected {
+			t.Errorf("wrong statements for %q. got=%q", tt.input, root.String())
+		}
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "lib.fl";`, `expected next token to be "as", got ; instead`},
+		{`import "lib.fl" as "x";`, "expected next token to be IDENT, got STRING instead"},
+		{`import { a, } from "lib.fl";`, "expected next token to be IDENT, got } instead"},
+		{`import { a } "lib.fl";`, `expected next token to be "from", got STRING instead`},
+		{`import lib;`, "expected next token to be STRING, got IDENT instead"},
+		{`export x;`, "expected next token to be LET, got IDENT instead"},
+	}
+	for _, tt := range tests {
+		psr := NewParser(lexer.NewLexer(tt.input))
+		psr.ParseRootStatement()
+
+		errs := psr.Errors()
+		if len(errs) == 0 || errs[0] != tt.expected {
+			t.Errorf("wrong errors for %q. got=%q", tt.input, errs)
+		}
+	}
+}
+
 func TestFunctionLiteralParsing(t *testing.T) {
 	input := `func(x, y) { x + y; }`
 
@@ -820,3 +1244,33 @@ func testIntegerLiteral(t *testing.T, il ast.Expression, value int64) bool {
 	}
 	return true
 }
+
+func TestCommentsAreCollected(t *testing.T) {
+	input := `// header
+let x = 5; // trailing
+let f = func() {
+	// inside
+	x
+};`
+	lxr := lexer.NewLexer(input)
+	psr := NewParser(lxr)
+	root := psr.ParseRootStatement()
+	checkParserErrors(t, psr)
+
+	if len(root.Statements) != 2 {
+		t.Fatalf("root.Statements does not contain 2 statements. got=%d", len(root.Statements))
+	}
+	expected := []string{"// header", "// trailing", "// inside"}
+	if len(root.Comments) != len(expected) {
+		t.Fatalf("root.Comments has wrong length. got=%d", len(root.Comments))
+	}
+	for i, text := range expected {
+		if root.Comments[i].Text != text {
+			t.Errorf("root.Comments[%d] wrong. expected=%q, got=%q", i, text, root.Comments[i].Text)
+		}
+	}
+	fn := root.Statements[1].(*ast.LetStatement).Value.(*ast.FunctionLiteral)
+	if fn.Body.Close.Pos.Line != 6 || fn.Body.Close.Literal != "}" {
+		t.Errorf("fn.Body.Close wrong. got=%q at %s", fn.Body.Close.Literal, fn.Body.Close.Pos)
+	}
+}
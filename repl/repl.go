@@ -1,9 +1,13 @@
 package repl
 
 import (
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/compiler"
 	"Interpreter_in_Go/evaluator"
 	"Interpreter_in_Go/object"
 	"Interpreter_in_Go/parser"
+	"Interpreter_in_Go/resolver"
+	"Interpreter_in_Go/vm"
 	"bufio"
 	"fmt"
 	"io"
@@ -13,9 +17,20 @@ import (
 
 const PROMPT = ">>"
 
-func Start(input io.Reader, output io.Writer) {
+// Engines that can run the programs entered in the REPL.
+const (
+	EngineEval = "eval" // the tree-walking evaluator
+	EngineVM   = "vm"   // the bytecode compiler and virtual machine
+)
+
+func Start(input io.Reader, output io.Writer, engine string) {
 	scanner := bufio.NewScanner(input)
-	env := object.NewEnvironment()
+	rsv := resolver.New()
+
+	run := evaluatorRunner()
+	if engine == EngineVM {
+		run = vmRunner(output)
+	}
 
 	for {
 		fmt.Printf(PROMPT)
@@ -32,14 +47,54 @@ func Start(input io.Reader, output io.Writer) {
 			printParserErrors(output, psr.Errors())
 			continue
 		}
-		evaluated := evaluator.Evaluate(root, env)
-		if evaluated != nil {
+		if errs := rsv.Resolve(root); len(errs) != 0 {
+			printResolverErrors(output, errs)
+			continue
+		}
+		evaluated := run(root)
+		if errOb, ok := evaluated.(*object.Error); ok {
+			_, _ = io.WriteString(output, errOb.Traceback())
+			_, _ = io.WriteString(output, "\n")
+		} else if evaluated != nil {
 			_, _ = io.WriteString(output, evaluated.Inspect())
 			_, _ = io.WriteString(output, "\n")
 		}
 	}
 }
 
+// evaluatorRunner and vmRunner return functions that run one line of input,
+// keeping the bindings of earlier lines.
+func evaluatorRunner() func(root *ast.RootStatement) object.Object {
+	env := object.NewEnvironment()
+	ev := evaluator.New(evaluator.Options{Loader: evaluator.NewLoader(evaluator.SearchPath(".")...)})
+	return func(root *ast.RootStatement) object.Object {
+		return ev.Evaluate(root, env)
+	}
+}
+
+func vmRunner(output io.Writer) func(root *ast.RootStatement) object.Object {
+	symbolTable := compiler.NewSymbolTable()
+	constants := []object.Object{}
+	globals := make([]object.Object, vm.GlobalsSize)
+
+	return func(root *ast.RootStatement) object.Object {
+		cmp := compiler.NewWithState(symbolTable, constants)
+		if err := cmp.Compile(root); err != nil {
+			errMsg := fmt.Sprintf("%sCompiler ERROR::%s %s\n", object.COLOR_RED, object.COLOR_RESET, err)
+			_, _ = io.WriteString(output, errMsg)
+			return nil
+		}
+		bytecode := cmp.Bytecode()
+		constants = bytecode.Constants
+
+		machine := vm.NewWithGlobalsStore(bytecode, globals)
+		if err := machine.Run(); err != nil {
+			return err.(*vm.RuntimeError).Err
+		}
+		return machine.Result()
+	}
+}
+
 func printParserErrors(output io.Writer, errors []string) {
 	errMsg := fmt.Sprintf("%sParser ERROR::%s\n", object.COLOR_RED, object.COLOR_RESET)
 	_, _ = io.WriteString(output, errMsg)
@@ -48,3 +103,12 @@ func printParserErrors(output io.Writer, errors []string) {
 		_, _ = io.WriteString(output, "\t"+err+"\n")
 	}
 }
+
+func printResolverErrors(output io.Writer, errors []resolver.Error) {
+	errMsg := fmt.Sprintf("%sResolver ERROR::%s\n", object.COLOR_RED, object.COLOR_RESET)
+	_, _ = io.WriteString(output, errMsg)
+
+	for _, err := range errors {
+		_, _ = io.WriteString(output, "\t"+err.Error()+"\n")
+	}
+}
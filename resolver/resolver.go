@@ -0,0 +1,219 @@
+package resolver
+
+import (
+	"fmt"
+	"sort"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/evaluator"
+	"Interpreter_in_Go/scope"
+	"Interpreter_in_Go/token"
+)
+
+// Error is a problem found while resolving names, reported before the
+// program runs.
+type Error struct {
+	Pos     token.Position
+	Message string
+}
+
+func (err Error) Error() string {
+	return fmt.Sprintf("%s: %s", err.Pos, err.Message)
+}
+
+// Resolver binds identifiers to the place their value lives at run time, so
+// the evaluator can read locals from slots instead of looking them up by
+// name. Globals stay looked up by name; the names defined by every program
+// resolved so far are remembered, which lets a REPL resolve line by line.
+type Resolver struct {
+	globals map[string]bool
+}
+
+func New() *Resolver {
+	return &Resolver{globals: make(map[string]bool)}
+}
+
+// names are the names declared in a scope.
+type names struct {
+	slots map[string]int // slot of each local declared so far
+	count int            // slots taken
+
+	// declared holds where each local is declared, and conditional the
+	// locals bound by code that may not run, such as the let in the block
+	// of an if, so that later reads cannot count on them being bound.
+	declared    map[string]token.Position
+	conditional map[string]bool
+
+	defined map[string]bool // names declared by this program in the global scope
+}
+
+// resolution resolves a program as the scope.Handler of its walk.
+type resolution struct {
+	globals map[string]bool // of the programs resolved before
+	global  *names
+	errors  []Error
+}
+
+// Resolve annotates the identifiers and function literals of root and
+// returns the undefined and duplicate names it found, ordered by position.
+// A program with errors must not be evaluated.
+//
+// Statements are resolved in order, so a use only sees the names declared
+// before it, except inside function bodies: those run once the enclosing
+// scope is set up and see all of its names.
+func (res *Resolver) Resolve(root *ast.RootStatement) []Error {
+	rsn := &resolution{globals: res.globals}
+	scope.Walk[*names](root, rsn)
+
+	sort.SliceStable(rsn.errors, func(i, j int) bool {
+		return rsn.errors[i].Pos.Before(rsn.errors[j].Pos)
+	})
+	if len(rsn.errors) == 0 {
+		for name := range rsn.global.defined {
+			res.globals[name] = true
+		}
+	}
+	return rsn.errors
+}
+
+func (rsn *resolution) Open(sc *scope.Scope[*names]) {
+	sc.Data = &names{
+		slots:       make(map[string]int),
+		declared:    make(map[string]token.Position),
+		conditional: make(map[string]bool),
+	}
+	if sc.Global() {
+		sc.Data.defined = make(map[string]bool)
+		for name := range rsn.globals {
+			sc.Data.slots[name] = 0
+		}
+		rsn.global = sc.Data
+	}
+}
+
+// Close records the slots a call of a function, a match arm or a catch
+// block needs.
+func (rsn *resolution) Close(sc *scope.Scope[*names]) {
+	switch node := sc.Node.(type) {
+	case *ast.FunctionLiteral:
+		node.Locals = sc.Data.count
+	case *ast.MatchArm:
+		node.Locals = sc.Data.count
+	case *ast.TryExpression:
+		node.CatchLocals = sc.Data.count
+	}
+}
+
+func (rsn *resolution) Declare(sc *scope.Scope[*names], decl scope.Declaration) {
+	switch decl.Kind {
+	case scope.Export:
+		if !sc.Global() {
+			rsn.errorf(decl.Node.Pos(), "export is only allowed at the top level")
+		}
+	case scope.Pattern:
+		if decl.Repeated {
+			rsn.errorf(decl.Name.Pos(), "%s is bound more than once in the pattern", decl.Name.Value)
+			return
+		}
+	}
+	rsn.declare(sc, decl.Name)
+	sc.Data.declared[decl.Name.Value] = decl.Name.Pos()
+	sc.Data.conditional[decl.Name.Value] = !runsFirst(sc.Node, decl.Node)
+}
+
+// runsFirst reports whether node, declaring a name in the scope of
+// scopeNode, always runs before the code following it in that scope: when
+// it is a statement of its body or the scope itself, as for parameters.
+func runsFirst(scopeNode, node ast.Node) bool {
+	if node == scopeNode {
+		return true
+	}
+	var body []ast.Statement
+	switch scopeNode := scopeNode.(type) {
+	case *ast.RootStatement:
+		body = scopeNode.Statements
+	case *ast.FunctionLiteral:
+		if scopeNode.Body != nil {
+			body = scopeNode.Body.Statements
+		}
+	case *ast.MatchArm:
+		if scopeNode.Body != nil {
+			body = scopeNode.Body.Statements
+		}
+	case *ast.TryExpression:
+		body = scopeNode.Catch.Statements
+	}
+	for _, stmt := range body {
+		if stmt == node {
+			return true
+		}
+	}
+	return false
+}
+
+func (rsn *resolution) declare(sc *scope.Scope[*names], name *ast.Identifier) {
+	if sc.Global() {
+		if sc.Data.defined[name.Value] {
+			rsn.errorf(name.Pos(), "%s is already declared in this scope", name.Value)
+		}
+		sc.Data.defined[name.Value] = true
+		sc.Data.slots[name.Value] = 0
+		name.Binding = ast.Binding{Kind: ast.Global}
+		return
+	}
+	if _, ok := sc.Data.slots[name.Value]; ok {
+		rsn.errorf(name.Pos(), "%s is already declared in this scope", name.Value)
+	}
+	sc.Data.slots[name.Value] = sc.Data.count
+	name.Binding = ast.Binding{Kind: ast.Local, Slot: sc.Data.count}
+	sc.Data.count++
+}
+
+// Use binds ident to the nearest declaration of its name, or to the builtin
+// of that name when there is none.
+//
+// A local may still be unbound when ident is read: when its declaration may
+// not run, or ident is in a function defined before it. The read fails then,
+// whereas an unresolved program would find the name further out, so such
+// reads are rejected when there is a name further out to find.
+func (rsn *resolution) Use(sc *scope.Scope[*names], ident *ast.Identifier) {
+	var from ast.Node // the scope within sc that ident is in, if any
+	for depth := 0; sc != nil; depth, from, sc = depth+1, sc.Node, sc.Outer {
+		slot, ok := sc.Data.slots[ident.Value]
+		if !ok {
+			continue
+		}
+		if sc.Global() {
+			ident.Binding = ast.Binding{Kind: ast.Global}
+			return
+		}
+		declared := sc.Data.declared[ident.Value]
+		unbound := sc.Data.conditional[ident.Value] || from != nil && from.Pos().Before(declared)
+		if unbound && rsn.visible(sc.Outer, ident.Value) {
+			rsn.errorf(ident.Pos(), "%s may be read before its declaration at %s has run", ident.Value, declared)
+			return
+		}
+		ident.Binding = ast.Binding{Kind: ast.Local, Depth: depth, Slot: slot}
+		return
+	}
+	if evaluator.IsBuiltIn(ident.Value) {
+		ident.Binding = ast.Binding{Kind: ast.BuiltIn}
+		return
+	}
+	rsn.errorf(ident.Pos(), "%s is not defined", ident.Value)
+}
+
+// visible reports whether name is declared in sc or the scopes around it, or
+// is a builtin.
+func (rsn *resolution) visible(sc *scope.Scope[*names], name string) bool {
+	for ; sc != nil; sc = sc.Outer {
+		if _, ok := sc.Data.slots[name]; ok {
+			return true
+		}
+	}
+	return evaluator.IsBuiltIn(name)
+}
+
+func (rsn *resolution) errorf(pos token.Position, format string, args ...any) {
+	rsn.errors = append(rsn.errors, Error{Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,240 @@
+package resolver
+
+import (
+	"fmt"
+	"testing"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/evaluator"
+	"Interpreter_in_Go/lexer"
+	"Interpreter_in_Go/object"
+	"Interpreter_in_Go/parser"
+)
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; let f = func(y) { x + y }; f(x);", nil},
+		{"let even = func(n) { odd(n) }; let odd = func(n) { even(n) };", nil},
+		{"puts(len([1]));", nil},
+		{"x;", []string{"1:1: x is not defined"}},
+		{"let x = x;", []string{"1:9: x is not defined"}},
+		{"let f = func() { y }; let g = func() { let y = 1; };", []string{"1:18: y is not defined"}},
+		{"let x = 1; let x = 2;", []string{"1:16: x is already declared in this scope"}},
+		{"let f = func(a, a) { let b = a; let b = 1; };", []string{
+			"1:17: a is already declared in this scope",
+			"1:37: b is already declared in this scope",
+		}},
+		{"let f = func(x) { let x = 1; }; let g = func() { let f = f; };", []string{
+			"1:23: x is already declared in this scope",
+		}},
+		{"try { 1 } catch (e) { e }; try { 2 } catch (e) { e }; e;", []string{"1:55: e is not defined"}},
+		{"try { 1 } catch (e) { let x = e; x }; let e = 1; let x = 2;", nil},
+		{"let f = func() { try { 1 } catch (e) { e }; try { 2 } catch (e) { e } };", nil},
+		{"try { e } catch (e) { 1 };", []string{"1:7: e is not defined"}},
+		{`import "lib.fl" as lib; import { a, b } from "lib.fl"; export let c = lib["x"] + a + b;`, nil},
+		{`import { a } from "x.fl"; let a = 1;`, []string{"1:31: a is already declared in this scope"}},
+		{`let f = func() { export let x = 1; };`, []string{"1:18: export is only allowed at the top level"}},
+		{`struct Point { x, y } let Point = 1;`, []string{"1:27: Point is already declared in this scope"}},
+		{"class A extends B { m(x) { self.f(x, y) + super.m(x) } }", []string{
+			"1:17: B is not defined", "1:38: y is not defined",
+		}},
+		{"class A { m() { let self = 1; } } self;", []string{
+			"1:21: self is already declared in this scope", "1:35: self is not defined",
+		}},
+		{"enum E { A(x) } let f = func(v) { match (v) { E.A(x) if x > 0 => x, [a, ...b] => a + b, _ => c } };", []string{
+			"1:94: c is not defined",
+		}},
+		{"let [a, b = a, ...c] = [1]; let f = func([x, y], {1: z = x}) { x + y + z + d };", []string{
+			"1:76: d is not defined",
+		}},
+		{"let [a, {1: a}] = xs; let a = 1;", []string{
+			"1:13: a is bound more than once in the pattern", "1:19: xs is not defined", "1:27: a is already declared in this scope",
+		}},
+		{"let outer = func() { let x = 5; let f = func() { let g = func() { x }; let r = g(); let x = 1; r }; f() }; outer();", []string{
+			"1:67: x may be read before its declaration at 1:89 has run",
+		}},
+		{"let v = 5; let f = func(c) { if (c) { let v = 1; } v }; f(false);", []string{
+			"1:52: v may be read before its declaration at 1:43 has run",
+		}},
+		{"let f = func() { let g = func() { x }; let x = 1; g() };", nil},
+		{"let f = func() { let g = func() { len }; let len = 1; g() };", []string{
+			"1:35: len may be read before its declaration at 1:46 has run",
+		}},
+		{"match (1) { x => x }; let x = 2;", nil},
+		{"let x = 1; match ([2, 3]) { [x, 5] => 0, _ => x }; x;", nil},
+		{"match (1) { a => a, _ => a }", []string{"1:26: a is not defined"}},
+		{"match (1) { [a, {1: a}] => a, F.B(_, _) => 0 }", []string{
+			"1:21: a is bound more than once in the pattern", "1:31: F is not defined",
+		}},
+	}
+	for _, tt := range tests {
+		errs := New().Resolve(parse(t, tt.input))
+
+		var got []string
+		for _, err := range errs {
+			got = append(got, err.Error())
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+			t.Errorf("wrong errors for %q.\nexpected=%q\ngot=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestResolveBindings(t *testing.T) {
+	root := parse(t, "let g = 1; let f = func(a) { let b = a; func(c) { len(c) + b + g } };")
+	if errs := New().Resolve(root); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	expected := map[string]ast.Binding{
+		"g":   {Kind: ast.Global},
+		"f":   {Kind: ast.Global},
+		"a":   {Kind: ast.Local, Slot: 0},
+		"b":   {Kind: ast.Local, Slot: 1},
+		"c":   {Kind: ast.Local, Slot: 0},
+		"len": {Kind: ast.BuiltIn},
+	}
+	uses := map[string]ast.Binding{
+		"a": {Kind: ast.Local, Slot: 0},
+		"b": {Kind: ast.Local, Depth: 1, Slot: 1},
+		"g": {Kind: ast.Global},
+	}
+	declared := map[*ast.Identifier]bool{}
+	ast.Inspect(root, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			declared[node.Name] = true
+		case *ast.FunctionLiteral:
+			for _, param := range node.Parameters {
+				declared[param] = true
+			}
+		case *ast.Identifier:
+			want := expected[node.Value]
+			if use, ok := uses[node.Value]; ok && !declared[node] {
+				want = use
+			}
+			if node.Binding != want {
+				t.Errorf("wrong binding for %s at %s. expected=%+v, got=%+v",
+					node.Value, node.Pos(), want, node.Binding)
+			}
+		}
+		return true
+	})
+	fn := root.Statements[1].(*ast.LetStatement).Value.(*ast.FunctionLiteral)
+	if fn.Locals != 2 {
+		t.Errorf("fn.Locals wrong. expected=2, got=%d", fn.Locals)
+	}
+}
+
+func TestResolveIncrementally(t *testing.T) {
+	rsv := New()
+	if errs := rsv.Resolve(parse(t, "let x = 1;")); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if errs := rsv.Resolve(parse(t, "let f = func() { x }; let x = 2;")); len(errs) != 0 {
+		t.Fatalf("redefining a global of an earlier program failed: %v", errs)
+	}
+	if errs := rsv.Resolve(parse(t, "y;")); len(errs) != 1 {
+		t.Fatalf("expected an error for y. got=%v", errs)
+	}
+	if errs := rsv.Resolve(parse(t, "let [a, ...rest] = [1, 2]; let first = 1;")); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	root := parse(t, "rest; first; len;")
+	rsv.Resolve(root)
+	for idx, want := range []ast.BindingKind{ast.Global, ast.Global, ast.BuiltIn} {
+		ident := root.Statements[idx].(*ast.ExpressionStatement).Expression.(*ast.Identifier)
+		if ident.Binding.Kind != want {
+			t.Errorf("wrong binding for %s. expected=%v, got=%v", ident.Value, want, ident.Binding.Kind)
+		}
+	}
+}
+
+func TestResolvedEvaluation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let f = func(x) { let y = x * 2; y + 1 }; f(5);", "11"},
+		{"let newAdder = func(x) { func(y) { x + y } }; let addTwo = newAdder(2); addTwo(3);", "5"},
+		{"let fib = func(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) }; fib(15);", "610"},
+		{"let x = 10; let f = func() { let y = x; let x = 1; y + x }; f();", "11"},
+		{"let f = func(c) { if (c) { let v = 1; } v }; f(false);", "ERROR:: Identifier 'v' not found"},
+		{"let f = func(x) { struct Box { value } Box(x) }; f(1).value;", "1"},
+		{
+			"let f = func(n) { class C { init(x) { self.x = x + n } get() { func() { self.x } } } C(1) }; f(2).get()();",
+			"3",
+		},
+		{
+			"enum E { A(x), B } let f = func(n, v) { match (v) { E.A(x) => x + n, [y, ...z] => len(z) + y, _ => n } }; [f(1, E.A(2)), f(1, [5, 6]), f(1, E.B)];",
+			"[3, 6, 1]",
+		},
+		{
+			"let f = func(n, [a, b = n], {\"k\": c}) { let [x, ...y] = [a + b, c]; x + len(y) }; f(10, [1], {\"k\": 0});",
+			"12",
+		},
+		{"let x = 1; [match ([2, 3]) { [x, 5] => 0, _ => x }, x];", "[1, 1]"},
+		{"let f = func(xs) { match (xs) { [x, ...rest] if (len(rest) > 2) => rest, [first, ...rest] => [first, len(rest)] } }; [f([1, 2, 3, 4]), f([1, 2, 3])];",
+			"[[2, 3, 4], [1, 2]]"},
+		{"match ([1, 2]) { [first, ...rest] => rest }; rest([1, 2]);", "[2]"},
+		{"let xs = [1, 2, 3, 4]; let [a, b, ...rest] = xs; rest;", "[3, 4]"},
+		{"let f = func() { len(\"ab\") }; let a = f(); let len = func(x) { 0 }; [a, f()];", "[2, 0]"},
+		{"let tail = func([_, ...rest]) { rest }; [tail([1, 2]), rest([1, 2])];", "[[2], [2]]"},
+		{"match (1) { x => x }; let x = 2; x;", "2"},
+		{"let f = match (3) { n => func() { let m = n; match (m) { 3 => m + n } } }; f();", "6"},
+		{
+			"let counter = func(n) { let step = func(i, acc) { if (i > n) { acc } else { step(i + 1, push(acc, i)) } }; step(1, []) }; counter(3);",
+			"[1, 2, 3]",
+		},
+	}
+	for _, tt := range tests {
+		root := parse(t, tt.input)
+		if errs := New().Resolve(root); len(errs) != 0 {
+			t.Fatalf("unexpected errors for %q: %v", tt.input, errs)
+		}
+		if got := evaluate(root); got != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+		// resolving must not change what a program does
+		if got := evaluate(parse(t, tt.input)); got != tt.expected {
+			t.Errorf("wrong unresolved result for %q. expected=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func evaluate(root *ast.RootStatement) string {
+	result := evaluator.Evaluate(root, object.NewEnvironment())
+	if errOb, ok := result.(*object.Error); ok {
+		return "ERROR:: " + errOb.Message
+	}
+	return result.Inspect()
+}
+
+const fibProgram = "let fib = func(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) }; fib(18);"
+
+func BenchmarkEvaluateUnresolved(b *testing.B) {
+	root := parser.NewParser(lexer.NewLexer(fibProgram)).ParseRootStatement()
+	for i := 0; i < b.N; i++ {
+		evaluator.Evaluate(root, object.NewEnvironment())
+	}
+}
+
+func BenchmarkEvaluateResolved(b *testing.B) {
+	root := parser.NewParser(lexer.NewLexer(fibProgram)).ParseRootStatement()
+	New().Resolve(root)
+	for i := 0; i < b.N; i++ {
+		evaluator.Evaluate(root, object.NewEnvironment())
+	}
+}
+
+func parse(t *testing.T, input string) *ast.RootStatement {
+	t.Helper()
+	psr := parser.NewParser(lexer.NewLexer(input))
+	root := psr.ParseRootStatement()
+	if len(psr.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", psr.Errors())
+	}
+	return root
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/bytecode"
+	"Interpreter_in_Go/compiler"
+	"Interpreter_in_Go/evaluator"
+	"Interpreter_in_Go/object"
+	"Interpreter_in_Go/optimize"
+	"Interpreter_in_Go/repl"
+	"Interpreter_in_Go/resolver"
+	"Interpreter_in_Go/vm"
+)
+
+// runCommand implements 'flint run [-engine=eval|vm] [-O] [file]', which
+// executes a script, or stdin without one. Bytecode files written by 'flint
+// build' are run on the virtual machine without being parsed. The exit code
+// is 1 when the script does not compile or ends in an error.
+func runCommand(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	engine := engineFlag(flags)
+	optimized := optimizeFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if !validEngine(*engine) {
+		return 2
+	}
+	name, src, err := readSource(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	var result object.Object
+	if bytecode.IsBytecode(src) {
+		program, err := bytecode.Decode(src)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", name, err)
+			return 1
+		}
+		result = runBytecode(program)
+	} else {
+		root, ok := resolveSource(name, src, *optimized)
+		if !ok {
+			return 1
+		}
+		result = evaluate(root, *engine, flags.Arg(0))
+	}
+	if errOb, ok := result.(*object.Error); ok {
+		fmt.Fprintln(os.Stderr, errOb.Traceback())
+		return 1
+	}
+	return 0
+}
+
+// resolveSource parses and resolves src, reporting any errors on stderr, and
+// optimizes the tree when asked to.
+func resolveSource(name string, src []byte, optimized bool) (*ast.RootStatement, bool) {
+	root, ok := parseSource(name, src)
+	if !ok {
+		return nil, false
+	}
+	if errs := resolver.New().Resolve(root); len(errs) != 0 {
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "%s:%s\n", name, err)
+		}
+		return nil, false
+	}
+	if optimized {
+		optimize.Optimize(root)
+	}
+	return root, true
+}
+
+func optimizeFlag(flags *flag.FlagSet) *bool {
+	return flags.Bool("O", false, "fold constants and remove dead branches before running")
+}
+
+func engineFlag(flags *flag.FlagSet) *string {
+	return flags.String("engine", repl.EngineEval, "engine running the program: eval or vm")
+}
+
+func validEngine(engine string) bool {
+	if engine != repl.EngineEval && engine != repl.EngineVM {
+		fmt.Fprintf(os.Stderr, "unknown engine %q, want %s or %s\n", engine, repl.EngineEval, repl.EngineVM)
+		return false
+	}
+	return true
+}
+
+// evaluate runs root, read from file, with the given engine. Compile and run
+// time errors are returned as error objects, like the evaluator reports them.
+// Modules are searched for next to file, or in the working directory for
+// stdin, and then in FLINTPATH.
+func evaluate(root *ast.RootStatement, engine, file string) object.Object {
+	if engine == repl.EngineEval {
+		dir := "."
+		if file != "" {
+			dir = filepath.Dir(file)
+		}
+		ev := evaluator.New(evaluator.Options{Loader: evaluator.NewLoader(evaluator.SearchPath(dir)...)})
+		return ev.Evaluate(root, object.NewEnvironment())
+	}
+	cmp := compiler.New()
+	if err := cmp.Compile(root); err != nil {
+		return &object.Error{Message: err.Error()}
+	}
+	return runBytecode(cmp.Bytecode())
+}
+
+func runBytecode(program *compiler.Bytecode) object.Object {
+	machine := vm.New(program)
+	if err := machine.Run(); err != nil {
+		return err.(*vm.RuntimeError).Err
+	}
+	return machine.Result()
+}
@@ -0,0 +1,222 @@
+// Package scope walks a program the way the evaluator binds its names,
+// telling a Handler each name declared and used along with the scope it is
+// in. The resolver and the linter are both built on it, so they always agree
+// on which declaration a name refers to.
+package scope
+
+import "Interpreter_in_Go/ast"
+
+// Kind tells how a name was declared.
+type Kind int
+
+const (
+	Let       Kind = iota // the name of a let statement
+	Export                // the name of the let of an export statement
+	Parameter             // a function parameter
+	Catch                 // the error of a catch block
+	Pattern               // a name in the pattern of a let, a parameter or a match arm
+	Import                // a name bound by an import statement
+	Type                  // the name of a struct, enum or class
+)
+
+// Declaration is a name being declared.
+type Declaration struct {
+	Name  *ast.Identifier
+	Kind  Kind
+	Node  ast.Node       // the statement, expression or match arm declaring it
+	Value ast.Expression // the bound expression of a let, nil otherwise
+
+	// Repeated is set for a name already bound by the same pattern, as in
+	// '[a, a]'.
+	Repeated bool
+}
+
+// Scope is where names are declared: the program, the body of a function, a
+// match arm or a catch block.
+type Scope[T any] struct {
+	Outer *Scope[T]
+	Node  ast.Node // *ast.RootStatement, *ast.FunctionLiteral, *ast.MatchArm or *ast.TryExpression
+	Data  T        // set up by the Handler when the scope is opened
+
+	pending []*ast.FunctionLiteral // bodies walked once the scope is complete
+	arms    []*Scope[T]            // scopes of the match arms and catch blocks within it
+}
+
+// Global reports whether sc is the scope of the program.
+func (sc *Scope[T]) Global() bool { return sc.Outer == nil }
+
+// Handler is told about the scopes and names of a program as Walk goes.
+type Handler[T any] interface {
+	// Open sets up the Data of a new scope.
+	Open(sc *Scope[T])
+	// Close is called once every name of sc has been declared. The scopes
+	// of the functions defined in it are walked after that.
+	Close(sc *Scope[T])
+	Declare(sc *Scope[T], decl Declaration)
+	Use(sc *Scope[T], ident *ast.Identifier)
+}
+
+type walker[T any] struct {
+	handler Handler[T]
+}
+
+// Walk walks root with handler. Statements are walked in order, so a use
+// only follows the names declared before it, except in function bodies:
+// those run once the enclosing scope is set up and are walked after it.
+func Walk[T any](root *ast.RootStatement, handler Handler[T]) {
+	wk := &walker[T]{handler: handler}
+	global := wk.open(nil, root)
+	wk.statements(root, global)
+	wk.close(global)
+}
+
+func (wk *walker[T]) open(outer *Scope[T], node ast.Node) *Scope[T] {
+	sc := &Scope[T]{Outer: outer, Node: node}
+	wk.handler.Open(sc)
+	return sc
+}
+
+// close closes sc and walks the functions, match arms and catch blocks
+// within it.
+func (wk *walker[T]) close(sc *Scope[T]) {
+	wk.handler.Close(sc)
+	for len(sc.pending) > 0 {
+		fn := sc.pending[0]
+		sc.pending = sc.pending[1:]
+
+		inner := wk.open(sc, fn)
+		for idx, param := range fn.Parameters {
+			if pattern := fn.Pattern(idx); pattern != nil {
+				wk.pattern(inner, pattern, fn)
+			} else {
+				wk.declare(inner, Declaration{Name: param, Kind: Parameter, Node: fn})
+			}
+		}
+		if fn.Body != nil {
+			wk.statements(fn.Body, inner)
+		}
+		wk.close(inner)
+	}
+	for _, arm := range sc.arms {
+		wk.close(arm)
+	}
+}
+
+func (wk *walker[T]) statements(node ast.Node, sc *Scope[T]) {
+	ast.Inspect(node, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			wk.let(sc, node, Declaration{Kind: Let, Node: node})
+			return false
+		case *ast.ExportStatement:
+			if node.Let != nil {
+				wk.let(sc, node.Let, Declaration{Kind: Export, Node: node})
+			}
+			return false
+		case *ast.ImportStatement:
+			wk.declare(sc, Declaration{Name: node.Alias, Kind: Import, Node: node})
+			for _, name := range node.Names {
+				wk.declare(sc, Declaration{Name: name, Kind: Import, Node: node})
+			}
+			return false
+		case *ast.StructStatement:
+			wk.declare(sc, Declaration{Name: node.Name, Kind: Type, Node: node})
+			return false
+		case *ast.EnumStatement:
+			wk.declare(sc, Declaration{Name: node.Name, Kind: Type, Node: node})
+			return false
+		case *ast.ClassStatement:
+			if node.Super != nil {
+				wk.handler.Use(sc, node.Super)
+			}
+			wk.declare(sc, Declaration{Name: node.Name, Kind: Type, Node: node})
+			for _, method := range node.Methods {
+				sc.pending = append(sc.pending, method.Function)
+			}
+			return false
+		case *ast.TryExpression:
+			wk.statements(node.Body, sc)
+			if node.Catch != nil {
+				inner := wk.open(sc, node)
+				wk.declare(inner, Declaration{Name: node.Param, Kind: Catch, Node: node})
+				wk.statements(node.Catch, inner)
+				sc.arms = append(sc.arms, inner)
+			}
+			if node.Finally != nil {
+				wk.statements(node.Finally, sc)
+			}
+			return false
+		case *ast.MatchExpression:
+			wk.statements(node.Subject, sc)
+			for _, arm := range node.Arms {
+				inner := wk.open(sc, arm)
+				wk.pattern(inner, arm.Pattern, arm)
+				if arm.Guard != nil {
+					wk.statements(arm.Guard, inner)
+				}
+				if arm.Body != nil {
+					wk.statements(arm.Body, inner)
+				} else if arm.Value != nil {
+					wk.statements(arm.Value, inner)
+				}
+				sc.arms = append(sc.arms, inner)
+			}
+			return false
+		case *ast.FunctionLiteral:
+			sc.pending = append(sc.pending, node)
+			return false
+		case *ast.Identifier:
+			wk.handler.Use(sc, node)
+		}
+		return true
+	})
+}
+
+// let walks the value of a let before declaring its name or the names in
+// its pattern, so the value sees the names declared before the let.
+func (wk *walker[T]) let(sc *Scope[T], let *ast.LetStatement, decl Declaration) {
+	if let.Value != nil {
+		wk.statements(let.Value, sc)
+	}
+	if let.Pattern != nil {
+		wk.pattern(sc, let.Pattern, let)
+		return
+	}
+	decl.Name, decl.Value = let.Name, let.Value
+	wk.declare(sc, decl)
+}
+
+// pattern declares the names in a pattern, walking the enums of its
+// variants and its defaults as uses. A default is walked before the name it
+// stands for, and the wildcard '_' declares nothing.
+func (wk *walker[T]) pattern(sc *Scope[T], pattern ast.Pattern, node ast.Node) {
+	bound := make(map[string]bool)
+	var visit func(child ast.Node) bool
+	visit = func(child ast.Node) bool {
+		switch child := child.(type) {
+		case *ast.VariantPattern:
+			wk.handler.Use(sc, child.Enum)
+			for _, field := range child.Fields {
+				ast.Inspect(field, visit)
+			}
+			return false
+		case *ast.DefaultPattern:
+			wk.statements(child.Default, sc)
+			ast.Inspect(child.Pattern, visit)
+			return false
+		case *ast.Identifier:
+			if child.Value != "_" {
+				wk.declare(sc, Declaration{Name: child, Kind: Pattern, Node: node, Repeated: bound[child.Value]})
+				bound[child.Value] = true
+			}
+		}
+		return true
+	}
+	ast.Inspect(pattern, visit)
+}
+
+func (wk *walker[T]) declare(sc *Scope[T], decl Declaration) {
+	if decl.Name != nil {
+		wk.handler.Declare(sc, decl)
+	}
+}
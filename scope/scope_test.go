@@ -0,0 +1,83 @@
+package scope
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/lexer"
+	"Interpreter_in_Go/parser"
+)
+
+// recorder writes down what Walk reports, naming scopes by their depth.
+type recorder struct {
+	events []string
+}
+
+func depth(sc *Scope[int]) int {
+	return sc.Data
+}
+
+func (rec *recorder) Open(sc *Scope[int]) {
+	if sc.Outer != nil {
+		sc.Data = sc.Outer.Data + 1
+	}
+	rec.events = append(rec.events, fmt.Sprintf("open %d", depth(sc)))
+}
+
+func (rec *recorder) Close(sc *Scope[int]) {
+	rec.events = append(rec.events, fmt.Sprintf("close %d", depth(sc)))
+}
+
+func (rec *recorder) Declare(sc *Scope[int], decl Declaration) {
+	event := fmt.Sprintf("declare %s %d kind=%d", decl.Name.Value, depth(sc), decl.Kind)
+	if decl.Repeated {
+		event += " repeated"
+	}
+	rec.events = append(rec.events, event)
+}
+
+func (rec *recorder) Use(sc *Scope[int], ident *ast.Identifier) {
+	rec.events = append(rec.events, fmt.Sprintf("use %s %d", ident.Value, depth(sc)))
+}
+
+func TestWalk(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; x;", []string{
+			"open 0", "declare x 0 kind=0", "use x 0", "close 0",
+		}},
+		{"let f = func(a) { a + b }; let b = 2;", []string{
+			"open 0", "declare f 0 kind=0", "declare b 0 kind=0", "close 0",
+			"open 1", "declare a 1 kind=2", "use a 1", "use b 1", "close 1",
+		}},
+		{`let [a, {"k": a = d}] = v;`, []string{
+			"open 0", "use v 0", "declare a 0 kind=4", "use d 0", "declare a 0 kind=4 repeated", "close 0",
+		}},
+		{"try { 1 } catch (e) { e }; e;", []string{
+			"open 0", "open 1", "declare e 1 kind=3", "use e 1", "use e 0", "close 0", "close 1",
+		}},
+		{"export let y = 1; class A extends B { m() { y } }", []string{
+			"open 0", "declare y 0 kind=1", "use B 0", "declare A 0 kind=6", "close 0",
+			"open 1", "declare self 1 kind=2", "declare super 1 kind=2", "use y 1", "close 1",
+		}},
+	}
+
+	for _, tt := range tests {
+		psr := parser.NewParser(lexer.NewLexer(tt.input))
+		root := psr.ParseRootStatement()
+		if len(psr.Errors()) != 0 {
+			t.Fatalf("parser errors for %q: %v", tt.input, psr.Errors())
+		}
+		rec := &recorder{}
+		Walk[int](root, rec)
+		got := strings.Join(rec.events, "\n")
+		want := strings.Join(tt.expected, "\n")
+		if got != want {
+			t.Errorf("walk of %q:\ngot:\n%s\nwant:\n%s", tt.input, got, want)
+		}
+	}
+}
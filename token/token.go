@@ -1,15 +1,35 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
+// Position is a location in the source text. Line and Column are 1-based,
+// with Column counted in bytes.
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (pos Position) String() string {
+	return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+}
+
+// Before reports whether pos comes earlier in the source than other.
+func (pos Position) Before(other Position) bool {
+	return pos.Line < other.Line || pos.Line == other.Line && pos.Column < other.Column
+}
+
 type Token struct {
 	Type    TokenType
 	Literal string
+	Pos     Position
 }
 
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
+	COMMENT = "COMMENT" // '// ...' up to the end of the line
 
 	// Identifiers and literals
 
@@ -32,11 +52,17 @@ const (
 	LT = "<"
 	GT = ">"
 
+	QUESTION = "?" // postfix, unwraps a result
+
+	ARROW    = "=>"  // separates a match arm's pattern from its value
+	ELLIPSIS = "..." // the rest of an array pattern, eg. [first, ...rest]
+
 	// Delimiters
 
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
+	DOT       = "." // member access, eg. user.name
 
 	L_PAREN   = "("
 	R_PAREN   = ")"
@@ -54,16 +80,42 @@ const (
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	THROW    = "THROW"
+	TRY      = "TRY"
+	CATCH    = "CATCH"
+	FINALLY  = "FINALLY"
+	DEFER    = "DEFER"
+	IMPORT   = "IMPORT"
+	EXPORT   = "EXPORT"
+	STRUCT   = "STRUCT"
+	CLASS    = "CLASS"
+	ENUM     = "ENUM"
+	MATCH    = "MATCH"
+
+	INSTANCEOF = "INSTANCEOF" // infix, eg. rex instanceof Dog
 )
 
 var keywords = map[string]TokenType{
-	"func":   FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"func":    FUNCTION,
+	"let":     LET,
+	"true":    TRUE,
+	"false":   FALSE,
+	"if":      IF,
+	"else":    ELSE,
+	"return":  RETURN,
+	"throw":   THROW,
+	"try":     TRY,
+	"catch":   CATCH,
+	"finally": FINALLY,
+	"defer":   DEFER,
+	"import":  IMPORT,
+	"export":  EXPORT,
+	"struct":  STRUCT,
+	"class":   CLASS,
+	"enum":    ENUM,
+	"match":   MATCH,
+
+	"instanceof": INSTANCEOF,
 }
 
 func LookupIdent(ident string) TokenType {
@@ -0,0 +1,40 @@
+package vm
+
+import (
+	"Interpreter_in_Go/code"
+	"Interpreter_in_Go/object"
+)
+
+// Frame is the activation of a closure: where it is in its instructions and
+// where its locals start on the stack.
+type Frame struct {
+	cl          *object.Closure
+	ip          int
+	basePointer int
+
+	// the function and instruction that made the call, for error reports
+	caller   *object.CompiledFunction
+	callSite int
+	numArgs  int
+}
+
+func NewFrame(cl *object.Closure, basePointer int) *Frame {
+	return &Frame{cl: cl, ip: -1, basePointer: basePointer}
+}
+
+// call describes the call that made the frame.
+func (fr *Frame) call() object.Frame {
+	return object.Frame{
+		Function: fr.cl.Fn.Name,
+		Pos:      fr.caller.Lines.Lookup(fr.callSite),
+		Args:     fr.numArgs,
+	}
+}
+
+func (fr *Frame) String() string {
+	return fr.call().String()
+}
+
+func (fr *Frame) Instructions() code.Instructions {
+	return fr.cl.Fn.Instructions
+}
@@ -0,0 +1,552 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+
+	"Interpreter_in_Go/code"
+	"Interpreter_in_Go/compiler"
+	"Interpreter_in_Go/object"
+)
+
+const (
+	StackSize   = 2048 // initial size; the stack grows as calls nest
+	GlobalsSize = 65536
+
+	// MaxCallDepth bounds the number of function calls in progress at once,
+	// tail calls excepted, as the evaluator does by default.
+	MaxCallDepth = 10000
+)
+
+var (
+	NULL  = object.NULL
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
+)
+
+// VM executes the bytecode produced by the compiler.
+type VM struct {
+	constants   []object.Object
+	globals     []object.Object
+	globalNames []string
+
+	stack []object.Object
+	sp    int // next free slot; the top of the stack is stack[sp-1]
+
+	frames []*Frame
+
+	result object.Object
+}
+
+func New(bytecode *compiler.Bytecode) *VM {
+	return NewWithGlobalsStore(bytecode, make([]object.Object, GlobalsSize))
+}
+
+// NewWithGlobalsStore creates a VM that keeps its globals in globals, so they
+// carry over between programs compiled with the same symbol table.
+//
+// Globals named after a builtin that are not bound yet hold the builtin, as
+// the evaluator finds the builtin when no binding of its name has been made.
+func NewWithGlobalsStore(bytecode *compiler.Bytecode, globals []object.Object) *VM {
+	for idx, name := range bytecode.GlobalNames {
+		if globals[idx] == nil {
+			if builtIn := object.GetBuiltInByName(name); builtIn != nil {
+				globals[idx] = builtIn
+			}
+		}
+	}
+	mainFn := &object.CompiledFunction{Instructions: bytecode.Instructions, Lines: bytecode.Lines}
+	mainFrame := NewFrame(&object.Closure{Fn: mainFn}, 0)
+
+	return &VM{
+		constants:   bytecode.Constants,
+		globals:     globals,
+		globalNames: bytecode.GlobalNames,
+		stack:       make([]object.Object, StackSize),
+		frames:      []*Frame{mainFrame},
+	}
+}
+
+// Result returns the value of the program's last statement, like the
+// evaluator does: nil when that was not an expression.
+func (vm *VM) Result() object.Object {
+	return vm.result
+}
+
+func (vm *VM) currentFrame() *Frame { return vm.frames[len(vm.frames)-1] }
+
+func (vm *VM) pushFrame(fr *Frame) { vm.frames = append(vm.frames, fr) }
+
+func (vm *VM) popFrame() *Frame {
+	fr := vm.currentFrame()
+	vm.frames = vm.frames[:len(vm.frames)-1]
+	return fr
+}
+
+// RuntimeError is the error of a program that failed while running. Its
+// Error is the message alone, as the evaluator reports it.
+type RuntimeError struct {
+	Err *object.Error
+}
+
+func (re *RuntimeError) Error() string { return re.Err.Message }
+
+// Run runs the program. Errors it ends in are *RuntimeErrors, holding the
+// position of the failed instruction and the calls in progress.
+func (vm *VM) Run() error {
+	err := vm.run()
+	if err == nil {
+		return nil
+	}
+	var runtimeErr *RuntimeError
+	if errors.As(err, &runtimeErr) {
+		return runtimeErr
+	}
+	frame := vm.currentFrame()
+	return &RuntimeError{Err: &object.Error{
+		Message: err.Error(),
+		Pos:     frame.cl.Fn.Lines.Lookup(frame.ip),
+		Stack:   vm.callStack(),
+	}}
+}
+
+// callStack returns the calls in progress, innermost first.
+func (vm *VM) callStack() []object.Frame {
+	// the main program's frame is not a call
+	stack := make([]object.Frame, 0, len(vm.frames)-1)
+	for idx := len(vm.frames) - 1; idx >= 1; idx-- {
+		stack = append(stack, vm.frames[idx].call())
+	}
+	return stack
+}
+
+func (vm *VM) run() error {
+	for {
+		frame := vm.currentFrame()
+		frame.ip++
+		ins := frame.Instructions()
+		if frame.ip >= len(ins) {
+			return nil
+		}
+		ip := frame.ip
+		op := code.Opcode(ins[ip])
+
+		var err error
+		switch op {
+		case code.OpConstant:
+			constIndex := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			err = vm.push(vm.constants[constIndex])
+		case code.OpPop:
+			vm.result = vm.pop()
+
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv,
+			code.OpEqual, code.OpNotEqual, code.OpGreaterThan, code.OpLessThan:
+			err = vm.executeBinaryOperation(op)
+
+		case code.OpTrue:
+			err = vm.push(TRUE)
+		case code.OpFalse:
+			err = vm.push(FALSE)
+		case code.OpNull:
+			err = vm.push(NULL)
+
+		case code.OpBang:
+			err = vm.push(nativeBoolToBooleanObject(!isTruthy(vm.pop())))
+		case code.OpMinus:
+			err = vm.executeMinusOperator()
+
+		case code.OpJump:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip = pos - 1
+		case code.OpJumpNotTruthy:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			if !isTruthy(vm.pop()) {
+				frame.ip = pos - 1
+			}
+
+		case code.OpSetGlobal:
+			globalIndex := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			vm.globals[globalIndex] = vm.pop()
+			vm.result = nil
+		case code.OpGetGlobal:
+			globalIndex := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			value := vm.globals[globalIndex]
+			if value == nil {
+				return notFound(vm.globalNames, globalIndex)
+			}
+			err = vm.push(value)
+
+		case code.OpSetLocal:
+			localIndex := int(code.ReadUint8(ins[ip+1:]))
+			frame.ip += 1
+			vm.stack[frame.basePointer+localIndex] = vm.pop()
+		case code.OpGetLocal:
+			localIndex := int(code.ReadUint8(ins[ip+1:]))
+			frame.ip += 1
+			value := vm.stack[frame.basePointer+localIndex]
+			if value == nil {
+				return notFound(frame.cl.Fn.LocalNames, localIndex)
+			}
+			err = vm.push(value)
+
+		case code.OpSetCell:
+			localIndex := int(code.ReadUint8(ins[ip+1:]))
+			frame.ip += 1
+			vm.stack[frame.basePointer+localIndex].(*object.Cell).Value = vm.pop()
+		case code.OpGetCell:
+			localIndex := int(code.ReadUint8(ins[ip+1:]))
+			frame.ip += 1
+			value := vm.stack[frame.basePointer+localIndex].(*object.Cell).Value
+			if value == nil {
+				return notFound(frame.cl.Fn.LocalNames, localIndex)
+			}
+			err = vm.push(value)
+		case code.OpLoadCell:
+			localIndex := int(code.ReadUint8(ins[ip+1:]))
+			frame.ip += 1
+			err = vm.push(vm.stack[frame.basePointer+localIndex])
+
+		case code.OpGetFree:
+			freeIndex := int(code.ReadUint8(ins[ip+1:]))
+			frame.ip += 1
+			value := frame.cl.Free[freeIndex].Value
+			if value == nil {
+				return notFound(frame.cl.Fn.FreeNames, freeIndex)
+			}
+			err = vm.push(value)
+		case code.OpLoadFree:
+			freeIndex := int(code.ReadUint8(ins[ip+1:]))
+			frame.ip += 1
+			err = vm.push(frame.cl.Free[freeIndex])
+
+		case code.OpGetBuiltIn:
+			builtInIndex := code.ReadUint8(ins[ip+1:])
+			frame.ip += 1
+			err = vm.push(object.BuiltIns[builtInIndex].BuiltIn)
+
+		case code.OpArray:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			elements := make([]object.Object, numElements)
+			copy(elements, vm.stack[vm.sp-numElements:vm.sp])
+			vm.sp -= numElements
+			err = vm.push(&object.Array{Elements: elements})
+		case code.OpHash:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			var hash object.Object
+			hash, err = vm.buildHash(vm.sp-numElements, vm.sp)
+			if err == nil {
+				vm.sp -= numElements
+				err = vm.push(hash)
+			}
+		case code.OpIndex:
+			index := vm.pop()
+			left := vm.pop()
+			err = vm.executeIndexExpression(left, index)
+
+		case code.OpClosure:
+			constIndex := int(code.ReadUint16(ins[ip+1:]))
+			numFree := int(code.ReadUint8(ins[ip+3:]))
+			frame.ip += 3
+			err = vm.pushClosure(constIndex, numFree)
+		case code.OpCall:
+			numArgs := int(code.ReadUint8(ins[ip+1:]))
+			frame.ip += 1
+			err = vm.executeCall(numArgs, frame, ip)
+		case code.OpTailCall:
+			numArgs := int(code.ReadUint8(ins[ip+1:]))
+			frame.ip += 1
+			err = vm.executeTailCall(numArgs, frame, ip)
+
+		case code.OpReturnValue, code.OpReturn:
+			var returnValue object.Object = NULL
+			if op == code.OpReturnValue {
+				returnValue = vm.pop()
+			}
+			if len(vm.frames) == 1 {
+				// a return outside of any function ends the program
+				vm.result = returnValue
+				return nil
+			}
+			frame := vm.popFrame()
+			vm.sp = frame.basePointer - 1
+			err = vm.push(returnValue)
+
+		default:
+			return fmt.Errorf("unknown opcode %d", op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func notFound(names []string, index int) error {
+	return fmt.Errorf("Identifier '%s' not found", names[index])
+}
+
+func (vm *VM) push(ob object.Object) error {
+	if vm.sp >= len(vm.stack) {
+		vm.grow(1)
+	}
+	vm.stack[vm.sp] = ob
+	vm.sp++
+	return nil
+}
+
+func (vm *VM) pop() object.Object {
+	ob := vm.stack[vm.sp-1]
+	vm.sp--
+	return ob
+}
+
+// grow makes room for at least n more values above sp.
+func (vm *VM) grow(n int) {
+	size := max(2*len(vm.stack), vm.sp+n)
+	stack := make([]object.Object, size)
+	copy(stack, vm.stack[:vm.sp])
+	vm.stack = stack
+}
+
+func (vm *VM) executeBinaryOperation(op code.Opcode) error {
+	right := vm.pop()
+	left := vm.pop()
+
+	if left, ok := left.(*object.Integer); ok {
+		if right, ok := right.(*object.Integer); ok {
+			return vm.executeIntegerOperation(op, left.Value, right.Value)
+		}
+	}
+	operator := binaryOperators[op]
+
+	switch {
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return vm.executeStringOperation(operator, left, right)
+	case op == code.OpEqual:
+		return vm.push(nativeBoolToBooleanObject(left == right))
+	case op == code.OpNotEqual:
+		return vm.push(nativeBoolToBooleanObject(left != right))
+	case left.Type() != right.Type():
+		return fmt.Errorf("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	default:
+		return fmt.Errorf("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+var binaryOperators = map[code.Opcode]string{
+	code.OpAdd:         "+",
+	code.OpSub:         "-",
+	code.OpMul:         "*",
+	code.OpDiv:         "/",
+	code.OpEqual:       "==",
+	code.OpNotEqual:    "!=",
+	code.OpGreaterThan: ">",
+	code.OpLessThan:    "<",
+}
+
+func (vm *VM) executeIntegerOperation(op code.Opcode, ltVal, rtVal int64) error {
+	switch op {
+	case code.OpAdd:
+		return vm.push(&object.Integer{Value: ltVal + rtVal})
+	case code.OpSub:
+		return vm.push(&object.Integer{Value: ltVal - rtVal})
+	case code.OpMul:
+		return vm.push(&object.Integer{Value: ltVal * rtVal})
+	case code.OpDiv:
+		if rtVal == 0 {
+			return errors.New("division by zero")
+		}
+		return vm.push(&object.Integer{Value: ltVal / rtVal})
+	case code.OpLessThan:
+		return vm.push(nativeBoolToBooleanObject(ltVal < rtVal))
+	case code.OpGreaterThan:
+		return vm.push(nativeBoolToBooleanObject(ltVal > rtVal))
+	case code.OpEqual:
+		return vm.push(nativeBoolToBooleanObject(ltVal == rtVal))
+	default:
+		return vm.push(nativeBoolToBooleanObject(ltVal != rtVal))
+	}
+}
+
+func (vm *VM) executeStringOperation(operator string, left, right object.Object) error {
+	ltVal := left.(*object.String).Value
+	rtVal := right.(*object.String).Value
+
+	switch operator {
+	case "+":
+		return vm.push(&object.String{Value: ltVal + rtVal})
+	case "==":
+		return vm.push(nativeBoolToBooleanObject(ltVal == rtVal))
+	case "!=":
+		return vm.push(nativeBoolToBooleanObject(ltVal != rtVal))
+	default:
+		return fmt.Errorf("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func (vm *VM) executeMinusOperator() error {
+	operand := vm.pop()
+	if operand.Type() != object.INTEGER_OBJ {
+		return fmt.Errorf("unknown operator: -%s", operand.Type())
+	}
+	value := operand.(*object.Integer).Value
+	return vm.push(&object.Integer{Value: -value})
+}
+
+func (vm *VM) executeIndexExpression(left, index object.Object) error {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		elements := left.(*object.Array).Elements
+		idx := index.(*object.Integer).Value
+		if idx < 0 || idx > int64(len(elements)-1) {
+			return vm.push(NULL)
+		}
+		return vm.push(elements[idx])
+	case left.Type() == object.HASH_OBJ:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return fmt.Errorf("unusable as hash key: %s", index.Type())
+		}
+		pair, ok := left.(*object.Hash).Pairs[key.HashKey()]
+		if !ok {
+			return vm.push(NULL)
+		}
+		return vm.push(pair.Value)
+	default:
+		return fmt.Errorf("index operator not supported: %s", left.Type())
+	}
+}
+
+func (vm *VM) buildHash(startIndex, endIndex int) (object.Object, error) {
+	pairs := make(map[object.HashKey]object.HashPair)
+
+	for idx := startIndex; idx < endIndex; idx += 2 {
+		key := vm.stack[idx]
+		value := vm.stack[idx+1]
+
+		hashKey, ok := key.(object.Hashable)
+		if !ok {
+			return nil, fmt.Errorf("unusable as hash key: %s", key.Type())
+		}
+		pairs[hashKey.HashKey()] = object.HashPair{Key: key, Value: value}
+	}
+	return &object.Hash{Pairs: pairs}, nil
+}
+
+func (vm *VM) pushClosure(constIndex, numFree int) error {
+	fn, ok := vm.constants[constIndex].(*object.CompiledFunction)
+	if !ok {
+		return fmt.Errorf("not a function: %+v", vm.constants[constIndex])
+	}
+	free := make([]*object.Cell, numFree)
+	for idx := range free {
+		cell, ok := vm.stack[vm.sp-numFree+idx].(*object.Cell)
+		if !ok {
+			return fmt.Errorf("cannot capture %s", vm.stack[vm.sp-numFree+idx].Type())
+		}
+		free[idx] = cell
+	}
+	vm.sp -= numFree
+	return vm.push(&object.Closure{Fn: fn, Free: free})
+}
+
+// executeCall calls the callee below the numArgs arguments on the stack.
+// The call is made by the instruction at callSite in caller.
+func (vm *VM) executeCall(numArgs int, caller *Frame, callSite int) error {
+	switch callee := vm.stack[vm.sp-1-numArgs].(type) {
+	case *object.Closure:
+		if len(vm.frames) > MaxCallDepth {
+			return vm.callDepthError(caller, callSite)
+		}
+		return vm.callClosure(callee, numArgs, caller.cl.Fn, callSite)
+	case *object.BuiltIn:
+		return vm.callBuiltIn(callee, numArgs)
+	default:
+		return fmt.Errorf("unknown function: %s", callee.Type())
+	}
+}
+
+// executeTailCall makes a call whose result the caller returns. A closure
+// takes over the caller's frame, so tail recursion does not grow the stack.
+func (vm *VM) executeTailCall(numArgs int, caller *Frame, callSite int) error {
+	callee, ok := vm.stack[vm.sp-1-numArgs].(*object.Closure)
+	if !ok || len(vm.frames) == 1 {
+		return vm.executeCall(numArgs, caller, callSite)
+	}
+	// move the callee and its arguments over the caller's
+	start := vm.sp - 1 - numArgs
+	copy(vm.stack[caller.basePointer-1:], vm.stack[start:vm.sp])
+	vm.sp = caller.basePointer + numArgs
+	vm.popFrame()
+	return vm.callClosure(callee, numArgs, caller.cl.Fn, callSite)
+}
+
+// callDepthError reports the call depth limit being exceeded by the call at
+// callSite, with the calls in progress as its stack.
+func (vm *VM) callDepthError(caller *Frame, callSite int) error {
+	return &RuntimeError{Err: &object.Error{
+		Message: fmt.Sprintf("maximum call depth %d exceeded", MaxCallDepth),
+		Kind:    object.RecursionErrorKind,
+		Pos:     caller.cl.Fn.Lines.Lookup(callSite),
+		Stack:   vm.callStack(),
+	}}
+}
+
+func (vm *VM) callClosure(cl *object.Closure, numArgs int, caller *object.CompiledFunction, callSite int) error {
+	fn := cl.Fn
+	if numArgs < fn.NumParameters {
+		return fmt.Errorf("wrong number of arguments. got=%d, want=%d", numArgs, fn.NumParameters)
+	}
+	basePointer := vm.sp - numArgs
+	if basePointer+fn.NumLocals > len(vm.stack) {
+		vm.grow(fn.NumLocals)
+	}
+	// locals start out unbound; this also drops surplus arguments
+	for idx := fn.NumParameters; idx < fn.NumLocals; idx++ {
+		vm.stack[basePointer+idx] = nil
+	}
+	for _, slot := range fn.Cells {
+		vm.stack[basePointer+slot] = &object.Cell{Value: vm.stack[basePointer+slot]}
+	}
+	frame := NewFrame(cl, basePointer)
+	frame.caller, frame.callSite, frame.numArgs = caller, callSite, numArgs
+	vm.pushFrame(frame)
+	vm.sp = basePointer + fn.NumLocals
+	return nil
+}
+
+func (vm *VM) callBuiltIn(builtIn *object.BuiltIn, numArgs int) error {
+	args := make([]object.Object, numArgs)
+	copy(args, vm.stack[vm.sp-numArgs:vm.sp])
+	vm.sp = vm.sp - numArgs - 1
+
+	result := builtIn.Func(args...)
+	if err, ok := result.(*object.Error); ok {
+		return fmt.Errorf("%s", err.Message)
+	}
+	if result == nil {
+		result = NULL
+	}
+	return vm.push(result)
+}
+
+func isTruthy(ob object.Object) bool {
+	switch ob {
+	case NULL, FALSE:
+		return false
+	default:
+		return true
+	}
+}
+
+func nativeBoolToBooleanObject(value bool) *object.Boolean {
+	if value {
+		return TRUE
+	}
+	return FALSE
+}
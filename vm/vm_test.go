@@ -0,0 +1,157 @@
+package vm
+
+import (
+	"testing"
+
+	"Interpreter_in_Go/ast"
+	"Interpreter_in_Go/compiler"
+	"Interpreter_in_Go/lexer"
+	"Interpreter_in_Go/object"
+	"Interpreter_in_Go/parser"
+)
+
+func parse(input string) *ast.RootStatement {
+	return parser.NewParser(lexer.NewLexer(input)).ParseRootStatement()
+}
+
+func run(t *testing.T, input string) (object.Object, error) {
+	t.Helper()
+	cmp := compiler.New()
+	if err := cmp.Compile(parse(input)); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+	machine := New(cmp.Bytecode())
+	err := machine.Run()
+	return machine.Result(), err
+}
+
+func TestRecursiveFibonacci(t *testing.T) {
+	input := `
+let fibonacci = func(x) {
+	if (x < 2) { return x; }
+	fibonacci(x - 1) + fibonacci(x - 2)
+};
+fibonacci(15);`
+	result, err := run(t, input)
+	if err != nil {
+		t.Fatalf("vm error: %s", err)
+	}
+	if result.Inspect() != "610" {
+		t.Errorf("wrong result. want=610, got=%s", result.Inspect())
+	}
+}
+
+func TestDeepRecursionGrowsTheStack(t *testing.T) {
+	input := `
+let count = func(n) { if (n == 0) { 0 } else { 1 + count(n - 1) } };
+count(5000);`
+	result, err := run(t, input)
+	if err != nil {
+		t.Fatalf("vm error: %s", err)
+	}
+	if result.Inspect() != "5000" {
+		t.Errorf("wrong result. want=5000, got=%s", result.Inspect())
+	}
+}
+
+func TestResult(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string // empty for no result
+	}{
+		{"1; 2", "2"},
+		{"1; let a = 2;", ""},
+		{"", ""},
+		{"return 3; 4", "3"},
+		{"func(a) { a }(1, 2)", "1"},
+		{"func() { let a = 1; }()", "nil"},
+	}
+	for _, tt := range tests {
+		result, err := run(t, tt.input)
+		if err != nil {
+			t.Fatalf("vm error for %q: %s", tt.input, err)
+		}
+		got := ""
+		if result != nil {
+			got = result.Inspect()
+		}
+		if got != tt.expected {
+			t.Errorf("wrong result for %q. want=%q, got=%q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		pos      string
+	}{
+		{"func(a, b) { a }(1)", "wrong number of arguments. got=1, want=2", "1:1"},
+		{"1()", "unknown function: INTEGER", "1:1"},
+		{"len(1, 2)", "wrong number of arguments. got=2, want=1", "1:1"},
+		{"let d = 0; 10 / d", "division by zero", "1:12"},
+		{"let f = func() { g() }; f()", "Identifier 'g' not found", "1:18"},
+		{"func() { let f = func() { v }; f(); let v = 1; }()", "Identifier 'v' not found", "1:27"},
+	}
+	for _, tt := range tests {
+		_, err := run(t, tt.input)
+		if err == nil {
+			t.Errorf("expected an error for %q", tt.input)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error for %q. want=%q, got=%q", tt.input, tt.expected, err)
+		}
+		runtimeErr, ok := err.(*RuntimeError)
+		if !ok {
+			t.Errorf("error is not *RuntimeError. got=%T", err)
+			continue
+		}
+		if runtimeErr.Err.Pos.String() != tt.pos {
+			t.Errorf("wrong position for %q. want=%s, got=%s", tt.input, tt.pos, runtimeErr.Err.Pos)
+		}
+	}
+}
+
+func TestGlobalsCarryOver(t *testing.T) {
+	symbolTable := compiler.NewSymbolTable()
+	constants := []object.Object{}
+	globals := make([]object.Object, GlobalsSize)
+
+	var result object.Object
+	for _, line := range []string{"let a = 2;", "let double = func(x) { x * a };", "double(21)"} {
+		cmp := compiler.NewWithState(symbolTable, constants)
+		if err := cmp.Compile(parse(line)); err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+		bytecode := cmp.Bytecode()
+		constants = bytecode.Constants
+
+		machine := NewWithGlobalsStore(bytecode, globals)
+		if err := machine.Run(); err != nil {
+			t.Fatalf("vm error: %s", err)
+		}
+		result = machine.Result()
+	}
+	if result.Inspect() != "42" {
+		t.Errorf("wrong result. want=42, got=%s", result.Inspect())
+	}
+}
+
+func BenchmarkFibonacci(b *testing.B) {
+	cmp := compiler.New()
+	err := cmp.Compile(parse(`
+let fibonacci = func(x) { if (x < 2) { return x; } fibonacci(x - 1) + fibonacci(x - 2) };
+fibonacci(18);`))
+	if err != nil {
+		b.Fatal(err)
+	}
+	bytecode := cmp.Bytecode()
+
+	for i := 0; i < b.N; i++ {
+		if err := New(bytecode).Run(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}